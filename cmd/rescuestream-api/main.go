@@ -114,11 +114,28 @@ func main() {
 	broadcasterRepo := database.NewBroadcasterRepo(pool)
 	streamKeyRepo := database.NewStreamKeyRepo(pool)
 	streamRepo := database.NewStreamRepo(pool)
+	lockoutRepo := database.NewAuthLockoutRepo(pool)
+	batchRepo := database.NewStreamKeyBatchRepo(pool)
+	enrollmentRepo := database.NewEnrollmentRepo(pool)
+	orgRepo := database.NewOrganizationRepo(pool)
+	apiClientRepo := database.NewAPIClientRepo(pool)
+	groupRepo := database.NewBroadcasterGroupRepo(pool)
+	groupJobRepo := database.NewGroupJobRepo(pool)
+	deviceRepo := database.NewDeviceRepo(pool)
+	webhookInboxRepo := database.NewWebhookInboxRepo(pool)
+	unitOfWork := database.NewUnitOfWork(pool)
 
 	// Create MediaMTX client
 	mediaMTXClient, err := service.NewMediaMTXClient(
 		c.MediaMTXAPIURL,
-		c.MediaMTXPublicURL,
+		service.PublicEndpoints{
+			RTMP:   c.MediaMTXRTMPURL,
+			RTSP:   c.MediaMTXRTSPURL,
+			SRT:    c.MediaMTXSRTURL,
+			WebRTC: c.MediaMTXWebRTC(),
+			HLS:    c.MediaMTXHLSURL,
+			LLHLS:  c.MediaMTXLLHLSURL,
+		},
 		service.WithMediaMTXLogger(logger),
 	)
 	if err != nil {
@@ -127,32 +144,146 @@ func main() {
 	}
 
 	// Create services
-	authService := service.NewAuthService(pool, streamKeyRepo, streamRepo, service.WithAuthLogger(logger))
+	eventPublisher := service.NewEventPublisher(
+		service.WithEventWebhookURL(c.EventWebhookURL),
+		service.WithEventLogger(logger),
+	)
+	lockoutService := service.NewLockoutService(lockoutRepo,
+		service.WithLockoutLogger(logger),
+		service.WithLockoutEventPublisher(eventPublisher),
+		service.WithLockoutPolicy(service.LockoutPolicy{
+			MaxFailures:  c.AuthLockoutMaxFailures,
+			Window:       c.AuthLockoutWindow,
+			BaseDuration: c.AuthLockoutBaseDuration,
+			MaxDuration:  c.AuthLockoutMaxDuration,
+			LockPaths:    c.AuthLockoutPaths,
+		}),
+	)
+	quotaPolicy := service.QuotaPolicy{
+		MaxActiveKeys:        c.BroadcasterMaxActiveKeys,
+		MaxConcurrentStreams: c.BroadcasterMaxConcurrentStreams,
+		MaxStreamDuration:    c.BroadcasterMaxStreamDuration,
+	}
+	authOpts := []service.AuthServiceOption{
+		service.WithAuthLogger(logger),
+		service.WithAuthQuotaPolicy(quotaPolicy),
+		service.WithReservationTTL(c.StreamReservationTTL),
+	}
+	if c.AuthLockoutEnabled {
+		authOpts = append(authOpts, service.WithLockoutService(lockoutService))
+	}
+	authService := service.NewAuthService(unitOfWork, authOpts...)
 	streamService := service.NewStreamService(streamRepo, mediaMTXClient, service.WithStreamLogger(logger))
-	streamKeyService := service.NewStreamKeyService(streamKeyRepo, streamRepo, mediaMTXClient, service.WithStreamKeyLogger(logger))
-	broadcasterService := service.NewBroadcasterService(broadcasterRepo, service.WithBroadcasterLogger(logger))
+	streamKeyService := service.NewStreamKeyService(streamKeyRepo, streamRepo, broadcasterRepo, mediaMTXClient, unitOfWork,
+		service.WithStreamKeyLogger(logger),
+		service.WithStreamKeyQuotaPolicy(quotaPolicy),
+	)
+	batchService := service.NewStreamKeyBatchService(batchRepo, unitOfWork, streamKeyService, service.WithStreamKeyBatchLogger(logger))
+	enrollmentOpts := []service.EnrollmentServiceOption{service.WithEnrollmentLogger(logger)}
+	if c.AuthLockoutEnabled {
+		enrollmentOpts = append(enrollmentOpts, service.WithEnrollmentLockoutService(lockoutService))
+	}
+	enrollmentService := service.NewEnrollmentService(enrollmentRepo, streamKeyService, enrollmentOpts...)
+	orgService := service.NewOrganizationService(orgRepo, apiClientRepo, service.WithOrganizationLogger(logger))
+	broadcasterService := service.NewBroadcasterService(broadcasterRepo, streamRepo, streamKeyService, service.WithBroadcasterLogger(logger))
+	groupService := service.NewBroadcasterGroupService(groupRepo, groupJobRepo, broadcasterRepo, batchRepo, streamService, streamKeyService,
+		service.WithBroadcasterGroupLogger(logger),
+	)
+	deviceService := service.NewDeviceService(deviceRepo, broadcasterRepo,
+		service.WithDeviceLogger(logger),
+	)
+	groupJobRunner := service.NewGroupJobRunner(groupJobRepo, streamKeyService,
+		service.WithGroupJobRunnerLogger(logger),
+		service.WithGroupJobPollInterval(c.GroupJobPollInterval),
+	)
+	expirySweeper := service.NewExpirySweeper(streamKeyRepo, streamKeyService,
+		service.WithExpirySweeperLogger(logger),
+		service.WithExpirySweepInterval(c.StreamKeyExpirySweepInterval),
+		service.WithExpiryNoticeLeadTimes(c.StreamKeyExpiryNoticeLeadTimes),
+		service.WithExpiryEventPublisher(eventPublisher),
+	)
+	durationEnforcer := service.NewDurationEnforcer(streamRepo, mediaMTXClient,
+		service.WithDurationEnforcerLogger(logger),
+		service.WithDurationEnforcerInterval(c.StreamDurationSweepInterval),
+		service.WithDurationEnforcerQuotaPolicy(quotaPolicy),
+		service.WithDurationWarningLeadTime(c.StreamDurationWarningLeadTime),
+		service.WithDurationEnforcerEventPublisher(eventPublisher),
+	)
+	reconnectSweeper := service.NewReconnectSweeper(streamRepo, c.StreamReconnectGrace,
+		service.WithReconnectSweeperLogger(logger),
+		service.WithReconnectSweepInterval(c.StreamReconnectSweepInterval),
+	)
+	reservationSweeper := service.NewReservationSweeper(streamRepo,
+		service.WithReservationSweeperLogger(logger),
+		service.WithReservationSweepInterval(c.StreamReservationSweepInterval),
+	)
+	healthMonitor := service.NewStreamHealthMonitor(streamRepo, mediaMTXClient,
+		service.WithStreamHealthMonitorLogger(logger),
+		service.WithStreamHealthSampleInterval(c.StreamHealthSampleInterval),
+		service.WithStreamHealthMinBitrate(c.StreamHealthMinBitrateKbps),
+		service.WithStreamHealthEventPublisher(eventPublisher),
+	)
+	webhookProcessor := service.NewWebhookProcessor(streamRepo, streamKeyRepo,
+		service.WithWebhookProcessorLogger(logger),
+		service.WithWebhookMediaMTXClient(mediaMTXClient),
+		service.WithWebhookReconnectGrace(c.StreamReconnectGrace),
+	)
+	webhookInbox := service.NewWebhookInbox(webhookInboxRepo, webhookProcessor,
+		service.WithWebhookInboxLogger(logger),
+		service.WithWebhookInboxPollInterval(c.WebhookInboxPollInterval),
+		service.WithWebhookInboxMaxAttempts(c.WebhookInboxMaxAttempts),
+		service.WithWebhookInboxRetryBackoff(c.WebhookInboxRetryBackoff),
+		service.WithWebhookInboxRetention(c.WebhookInboxRetention),
+	)
 
 	// Create handlers
 	authHandler := handler.NewAuthHandler(authService, logger)
-	webhookHandler := handler.NewWebhookHandler(streamRepo, streamKeyRepo, logger)
+	webhookHandler := handler.NewWebhookHandler(webhookProcessor, logger,
+		handler.WithWebhookInbox(webhookInbox),
+	)
 	streamHandler := handler.NewStreamHandler(streamService, logger)
 	streamKeyHandler := handler.NewStreamKeyHandler(streamKeyService, logger)
+	batchHandler := handler.NewStreamKeyBatchHandler(batchService, logger)
+	enrollmentTokenHandler := handler.NewEnrollmentTokenHandler(enrollmentService, logger)
+	enrollHandler := handler.NewEnrollHandler(enrollmentService, logger)
 	broadcasterHandler := handler.NewBroadcasterHandler(broadcasterService, logger)
+	groupHandler := handler.NewBroadcasterGroupHandler(groupService, logger)
+	deviceHandler := handler.NewDeviceHandler(deviceService, logger)
+	lockoutHandler := handler.NewLockoutHandler(lockoutService, logger)
+	webhookInboxHandler := handler.NewWebhookInboxHandler(webhookInbox, logger)
+	orgHandler := handler.NewOrganizationHandler(orgService, logger)
 	healthHandler := handler.NewHealthHandler(pool)
 
-	// Create key store for HMAC auth
-	keyStore := handler.NewEnvKeyStore(c.APISecret)
+	// Create key store for HMAC auth; organization API clients are checked
+	// first, falling back to the deployment-wide API_SECRET
+	keyStore := handler.NewAPIClientKeyStore(orgService, handler.NewEnvKeyStore(c.APISecret))
 	authMiddleware := handler.NewAuthMiddleware(keyStore, logger)
 
+	// Restrict MediaMTX callbacks to MediaMTX nodes
+	mediaMTXMiddleware, err := handler.NewMediaMTXMiddleware(c.MediaMTXWebhookToken, c.MediaMTXAllowedCIDRs, logger)
+	if err != nil {
+		slog.Error("failed to create MediaMTX middleware, set MEDIAMTX_WEBHOOK_TOKEN or MEDIAMTX_ALLOWED_CIDRS", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
 	// Create and start HTTP server
 	srv := server.New(c.APIPort,
 		server.WithLogger(logger),
 		server.WithAuthMiddleware(authMiddleware),
+		server.WithMediaMTXMiddleware(mediaMTXMiddleware),
 		server.WithAuthHandler(authHandler),
 		server.WithWebhookHandler(webhookHandler),
 		server.WithStreamHandler(streamHandler),
 		server.WithStreamKeyHandler(streamKeyHandler),
+		server.WithStreamKeyBatchHandler(batchHandler),
+		server.WithEnrollmentTokenHandler(enrollmentTokenHandler),
+		server.WithEnrollHandler(enrollHandler),
 		server.WithBroadcasterHandler(broadcasterHandler),
+		server.WithBroadcasterGroupHandler(groupHandler),
+		server.WithDeviceHandler(deviceHandler),
+		server.WithOrganizationHandler(orgHandler),
+		server.WithLockoutHandler(lockoutHandler),
+		server.WithWebhookInboxHandler(webhookInboxHandler),
 		server.WithHealthHandler(healthHandler),
 	)
 
@@ -169,8 +300,21 @@ func main() {
 
 	slog.Info("server started", slog.Int("port", c.APIPort))
 
+	// Start background workers
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	defer stopWorkers()
+
+	go expirySweeper.Run(workerCtx)
+	go durationEnforcer.Run(workerCtx)
+	go reconnectSweeper.Run(workerCtx)
+	go reservationSweeper.Run(workerCtx)
+	go healthMonitor.Run(workerCtx)
+	go groupJobRunner.Run(workerCtx)
+	go webhookInbox.Run(workerCtx)
+
 	<-sigCh
 	slog.Info("shutting down...")
+	stopWorkers()
 
 	shutdownCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 )
@@ -31,8 +32,74 @@ type Config struct {
 	APISecret string `env:"API_SECRET,required"`
 
 	// MediaMTX Integration
-	MediaMTXAPIURL    string `env:"MEDIAMTX_API_URL" envDefault:"http://localhost:9997"`
+	MediaMTXAPIURL string `env:"MEDIAMTX_API_URL" envDefault:"http://localhost:9997"`
+
+	// MediaMTX public endpoints per protocol; leave empty for protocols that
+	// are disabled in MediaMTX. MEDIAMTX_PUBLIC_URL is the WebRTC endpoint
+	// when MEDIAMTX_WEBRTC_URL is unset.
 	MediaMTXPublicURL string `env:"MEDIAMTX_PUBLIC_URL" envDefault:"http://localhost:8889"`
+	MediaMTXRTMPURL   string `env:"MEDIAMTX_RTMP_URL" envDefault:"rtmp://localhost:1935"`
+	MediaMTXRTSPURL   string `env:"MEDIAMTX_RTSP_URL" envDefault:"rtsp://localhost:8554"`
+	MediaMTXSRTURL    string `env:"MEDIAMTX_SRT_URL"`
+	MediaMTXWebRTCURL string `env:"MEDIAMTX_WEBRTC_URL"`
+	MediaMTXHLSURL    string `env:"MEDIAMTX_HLS_URL"`
+	MediaMTXLLHLSURL  string `env:"MEDIAMTX_LLHLS_URL"`
+
+	// MediaMTX Callback Authentication
+	MediaMTXWebhookToken string   `env:"MEDIAMTX_WEBHOOK_TOKEN"`
+	MediaMTXAllowedCIDRs []string `env:"MEDIAMTX_ALLOWED_CIDRS" envSeparator:","`
+
+	// Authentication Lockout
+	AuthLockoutEnabled      bool          `env:"AUTH_LOCKOUT_ENABLED" envDefault:"true"`
+	AuthLockoutMaxFailures  int           `env:"AUTH_LOCKOUT_MAX_FAILURES" envDefault:"5"`
+	AuthLockoutWindow       time.Duration `env:"AUTH_LOCKOUT_WINDOW" envDefault:"15m"`
+	AuthLockoutBaseDuration time.Duration `env:"AUTH_LOCKOUT_BASE_DURATION" envDefault:"1m"`
+	AuthLockoutMaxDuration  time.Duration `env:"AUTH_LOCKOUT_MAX_DURATION" envDefault:"1h"`
+	AuthLockoutPaths        bool          `env:"AUTH_LOCKOUT_PATHS" envDefault:"true"`
+
+	// Broadcaster Quotas (0 is unlimited; broadcasters may override them)
+	BroadcasterMaxActiveKeys        int           `env:"BROADCASTER_MAX_ACTIVE_KEYS" envDefault:"0"`
+	BroadcasterMaxConcurrentStreams int           `env:"BROADCASTER_MAX_CONCURRENT_STREAMS" envDefault:"0"`
+	BroadcasterMaxStreamDuration    time.Duration `env:"BROADCASTER_MAX_STREAM_DURATION" envDefault:"0"`
+	StreamDurationSweepInterval     time.Duration `env:"STREAM_DURATION_SWEEP_INTERVAL" envDefault:"30s"`
+	StreamDurationWarningLeadTime   time.Duration `env:"STREAM_DURATION_WARNING_LEAD_TIME" envDefault:"5m"`
+
+	// Stream Key Expiry
+	StreamKeyExpirySweepInterval   time.Duration   `env:"STREAM_KEY_EXPIRY_SWEEP_INTERVAL" envDefault:"1m"`
+	StreamKeyExpiryNoticeLeadTimes []time.Duration `env:"STREAM_KEY_EXPIRY_NOTICE_LEAD_TIMES" envSeparator:"," envDefault:"24h,1h,15m"`
+
+	// Stream Reconnects (a grace of 0 ends streams as soon as the publisher disconnects)
+	StreamReconnectGrace         time.Duration `env:"STREAM_RECONNECT_GRACE" envDefault:"15s"`
+	StreamReconnectSweepInterval time.Duration `env:"STREAM_RECONNECT_SWEEP_INTERVAL" envDefault:"5s"`
+
+	// Stream Reservations (a publish approved at /auth holds its key until the ready webhook)
+	StreamReservationTTL           time.Duration `env:"STREAM_RESERVATION_TTL" envDefault:"30s"`
+	StreamReservationSweepInterval time.Duration `env:"STREAM_RESERVATION_SWEEP_INTERVAL" envDefault:"10s"`
+
+	// Stream Health (a minimum bitrate of 0 only detects stalls; keys may override it)
+	StreamHealthSampleInterval time.Duration `env:"STREAM_HEALTH_SAMPLE_INTERVAL" envDefault:"10s"`
+	StreamHealthMinBitrateKbps int           `env:"STREAM_HEALTH_MIN_BITRATE_KBPS" envDefault:"0"`
+
+	// Webhook Inbox (a retention of 0 keeps processed webhooks forever)
+	WebhookInboxPollInterval time.Duration `env:"WEBHOOK_INBOX_POLL_INTERVAL" envDefault:"1s"`
+	WebhookInboxMaxAttempts  int           `env:"WEBHOOK_INBOX_MAX_ATTEMPTS" envDefault:"5"`
+	WebhookInboxRetryBackoff time.Duration `env:"WEBHOOK_INBOX_RETRY_BACKOFF" envDefault:"2s"`
+	WebhookInboxRetention    time.Duration `env:"WEBHOOK_INBOX_RETENTION" envDefault:"168h"`
+
+	// Broadcaster Groups
+	GroupJobPollInterval time.Duration `env:"GROUP_JOB_POLL_INTERVAL" envDefault:"5s"`
+
+	// Events
+	EventWebhookURL string `env:"EVENT_WEBHOOK_URL"`
+}
+
+// MediaMTXWebRTC returns the public WebRTC endpoint, falling back to
+// MEDIAMTX_PUBLIC_URL.
+func (c *Config) MediaMTXWebRTC() string {
+	if c.MediaMTXWebRTCURL != "" {
+		return c.MediaMTXWebRTCURL
+	}
+	return c.MediaMTXPublicURL
 }
 
 func NewConfig() (*Config, error) {
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -13,38 +15,74 @@ import (
 	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
 )
 
+// broadcasterLiveCondition matches broadcasters with an active stream.
+const broadcasterLiveCondition = `EXISTS (
+	SELECT 1 FROM streams s
+	JOIN stream_keys k ON k.id = s.stream_key_id
+	WHERE k.broadcaster_id = broadcasters.id AND s.status = 'active'
+)`
+
+// broadcasterColumns is the column list scanned by scanBroadcaster. Status
+// is live while a stream is active, and last seen is the latest key use or
+// stream end, or now while live.
+const broadcasterColumns = `id, organization_id, display_name, metadata, created_at, updated_at, archived_at,
+	max_active_keys, max_concurrent_streams, max_stream_duration_seconds,
+	CASE WHEN ` + broadcasterLiveCondition + ` THEN 'live' ELSE status END,
+	(
+		SELECT GREATEST(MAX(k.last_used_at), MAX(CASE WHEN s.status = 'active' THEN NOW() ELSE s.ended_at END))
+		FROM stream_keys k
+		LEFT JOIN streams s ON s.stream_key_id = k.id
+		WHERE k.broadcaster_id = broadcasters.id
+	)`
+
 // BroadcasterRepo implements domain.BroadcasterRepository using pgxpool.
 type BroadcasterRepo struct {
-	pool *pgxpool.Pool
+	db dbtx
 }
 
 // NewBroadcasterRepo creates a new BroadcasterRepo.
 func NewBroadcasterRepo(pool *pgxpool.Pool) *BroadcasterRepo {
-	return &BroadcasterRepo{pool: pool}
+	return &BroadcasterRepo{db: pool}
 }
 
 // Create creates a new broadcaster.
 func (r *BroadcasterRepo) Create(ctx context.Context, broadcaster *domain.Broadcaster) error {
+	return insertBroadcaster(ctx, r.db, broadcaster)
+}
+
+func insertBroadcaster(ctx context.Context, q querier, broadcaster *domain.Broadcaster) error {
 	metadataJSON, err := json.Marshal(broadcaster.Metadata)
 	if err != nil {
 		return fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 
 	query := `
-		INSERT INTO broadcasters (id, display_name, metadata, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO broadcasters (id, organization_id, display_name, metadata, status, created_at, updated_at,
+			max_active_keys, max_concurrent_streams, max_stream_duration_seconds)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
 
 	if broadcaster.ID == uuid.Nil {
 		broadcaster.ID = uuid.New()
 	}
 
-	_, err = r.pool.Exec(ctx, query,
+	broadcaster.OrganizationID = organizationForInsert(ctx, broadcaster.OrganizationID)
+
+	if broadcaster.Status == "" {
+		broadcaster.Status = domain.BroadcasterStatusOffline
+	}
+
+	_, err = q.Exec(ctx, query,
 		broadcaster.ID,
+		broadcaster.OrganizationID,
 		broadcaster.DisplayName,
 		metadataJSON,
+		broadcaster.Status,
 		broadcaster.CreatedAt,
 		broadcaster.UpdatedAt,
+		broadcaster.Quotas.MaxActiveKeys,
+		broadcaster.Quotas.MaxConcurrentStreams,
+		broadcaster.Quotas.MaxStreamDurationSeconds,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create broadcaster: %w", err)
@@ -56,33 +94,26 @@ func (r *BroadcasterRepo) Create(ctx context.Context, broadcaster *domain.Broadc
 // GetByID retrieves a broadcaster by ID.
 func (r *BroadcasterRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Broadcaster, error) {
 	query := `
-		SELECT id, display_name, metadata, created_at, updated_at
+		SELECT ` + broadcasterColumns + `
 		FROM broadcasters
 		WHERE id = $1
 	`
+	query, args := scopeToOrganization(ctx, query, "organization_id", id)
 
-	var b domain.Broadcaster
-	var metadataJSON []byte
-
-	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&b.ID,
-		&b.DisplayName,
-		&metadataJSON,
-		&b.CreatedAt,
-		&b.UpdatedAt,
-	)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, domain.ErrNotFound
-		}
-		return nil, fmt.Errorf("failed to get broadcaster: %w", err)
-	}
+	return r.scanBroadcaster(r.db.QueryRow(ctx, query, args...))
+}
 
-	if err := json.Unmarshal(metadataJSON, &b.Metadata); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
-	}
+// GetAndLockByID retrieves and locks a broadcaster for update. This must be
+// called within a unit of work.
+func (r *BroadcasterRepo) GetAndLockByID(ctx context.Context, id uuid.UUID) (*domain.Broadcaster, error) {
+	query := `
+		SELECT ` + broadcasterColumns + `
+		FROM broadcasters
+		WHERE id = $1
+	`
+	query, args := scopeToOrganization(ctx, query, "organization_id", id)
 
-	return &b, nil
+	return r.scanBroadcaster(r.db.QueryRow(ctx, query+" FOR UPDATE", args...))
 }
 
 // Update updates an existing broadcaster.
@@ -94,15 +125,21 @@ func (r *BroadcasterRepo) Update(ctx context.Context, broadcaster *domain.Broadc
 
 	query := `
 		UPDATE broadcasters
-		SET display_name = $2, metadata = $3, updated_at = NOW()
+		SET display_name = $2, metadata = $3,
+			max_active_keys = $4, max_concurrent_streams = $5, max_stream_duration_seconds = $6,
+			updated_at = NOW()
 		WHERE id = $1
 	`
-
-	result, err := r.pool.Exec(ctx, query,
+	query, args := scopeToOrganization(ctx, query, "organization_id",
 		broadcaster.ID,
 		broadcaster.DisplayName,
 		metadataJSON,
+		broadcaster.Quotas.MaxActiveKeys,
+		broadcaster.Quotas.MaxConcurrentStreams,
+		broadcaster.Quotas.MaxStreamDurationSeconds,
 	)
+
+	result, err := r.db.Exec(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to update broadcaster: %w", err)
 	}
@@ -114,11 +151,61 @@ func (r *BroadcasterRepo) Update(ctx context.Context, broadcaster *domain.Broadc
 	return nil
 }
 
-// Delete deletes a broadcaster by ID.
+// UpdateStatus sets the operator-set status of a broadcaster.
+func (r *BroadcasterRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status domain.BroadcasterStatus) error {
+	query, args := scopeToOrganization(ctx, `UPDATE broadcasters SET status = $2 WHERE id = $1`, "organization_id", id, status)
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update broadcaster status: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Archive marks a broadcaster as archived. Archiving an archived
+// broadcaster keeps the original archive time.
+func (r *BroadcasterRepo) Archive(ctx context.Context, id uuid.UUID) error {
+	query, args := scopeToOrganization(ctx, `UPDATE broadcasters SET archived_at = COALESCE(archived_at, NOW()) WHERE id = $1`, "organization_id", id)
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to archive broadcaster: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Restore clears the archived mark of a broadcaster.
+func (r *BroadcasterRepo) Restore(ctx context.Context, id uuid.UUID) error {
+	query, args := scopeToOrganization(ctx, `UPDATE broadcasters SET archived_at = NULL WHERE id = $1`, "organization_id", id)
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to restore broadcaster: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete permanently deletes a broadcaster by ID. Its stream keys and
+// streams are removed with it.
 func (r *BroadcasterRepo) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM broadcasters WHERE id = $1`
+	query, args := scopeToOrganization(ctx, `DELETE FROM broadcasters WHERE id = $1`, "organization_id", id)
 
-	result, err := r.pool.Exec(ctx, query, id)
+	result, err := r.db.Exec(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to delete broadcaster: %w", err)
 	}
@@ -130,15 +217,85 @@ func (r *BroadcasterRepo) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-// List retrieves all broadcasters.
-func (r *BroadcasterRepo) List(ctx context.Context) ([]domain.Broadcaster, error) {
-	query := `
-		SELECT id, display_name, metadata, created_at, updated_at
+// List retrieves a filtered, sorted page of broadcasters.
+func (r *BroadcasterRepo) List(ctx context.Context, opts domain.BroadcasterListOptions) (*domain.BroadcasterPage, error) {
+	var conditions []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if orgID, ok := domain.OrganizationFromContext(ctx); ok {
+		conditions = append(conditions, "organization_id = "+arg(orgID))
+	}
+
+	if !opts.IncludeArchived {
+		conditions = append(conditions, "archived_at IS NULL")
+	}
+
+	switch {
+	case opts.Status == domain.BroadcasterStatusLive:
+		conditions = append(conditions, broadcasterLiveCondition)
+	case opts.Status != "":
+		conditions = append(conditions, "status = "+arg(opts.Status)+" AND NOT "+broadcasterLiveCondition)
+	}
+
+	if opts.Search != "" {
+		conditions = append(conditions, "display_name ILIKE '%' || "+arg(escapeLike(opts.Search))+" || '%'")
+	}
+
+	if len(opts.Metadata) > 0 {
+		metadataJSON, err := json.Marshal(opts.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata filter: %w", err)
+		}
+		conditions = append(conditions, "metadata @> "+arg(metadataJSON)+"::jsonb")
+	}
+
+	var total int
+	countQuery := "SELECT COUNT(*) FROM broadcasters " + whereClause(conditions)
+	if err := r.db.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count broadcasters: %w", err)
+	}
+
+	sortBy := opts.SortBy
+	if !sortBy.IsValid() {
+		sortBy = domain.BroadcasterSortCreatedAt
+	}
+
+	direction, comparator := "ASC", ">"
+	if opts.Descending {
+		direction, comparator = "DESC", "<"
+	}
+
+	if opts.Cursor != "" {
+		cursor, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := broadcasterCursorValue(sortBy, cursor.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s, %s)", sortBy, comparator, arg(value), arg(cursor.ID)))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
 		FROM broadcasters
-		ORDER BY created_at DESC
-	`
+		%s
+		ORDER BY %s %s, id %s
+	`, broadcasterColumns, whereClause(conditions), sortBy, direction, direction)
 
-	rows, err := r.pool.Query(ctx, query)
+	if opts.Limit > 0 {
+		// Fetch one extra row to know whether there is a next page
+		query += " LIMIT " + arg(opts.Limit+1)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list broadcasters: %w", err)
 	}
@@ -146,29 +303,91 @@ func (r *BroadcasterRepo) List(ctx context.Context) ([]domain.Broadcaster, error
 
 	var broadcasters []domain.Broadcaster
 	for rows.Next() {
-		var b domain.Broadcaster
-		var metadataJSON []byte
-
-		if err := rows.Scan(
-			&b.ID,
-			&b.DisplayName,
-			&metadataJSON,
-			&b.CreatedAt,
-			&b.UpdatedAt,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan broadcaster: %w", err)
+		b, err := r.scanBroadcaster(rows)
+		if err != nil {
+			return nil, err
 		}
+		broadcasters = append(broadcasters, *b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating broadcasters: %w", err)
+	}
+
+	page := &domain.BroadcasterPage{
+		Broadcasters: broadcasters,
+		Total:        total,
+	}
+
+	if opts.Limit > 0 && len(broadcasters) > opts.Limit {
+		page.Broadcasters = broadcasters[:opts.Limit]
+		last := page.Broadcasters[opts.Limit-1]
+		page.NextCursor = encodeCursor(broadcasterSortValue(sortBy, &last), last.ID)
+	}
+
+	return page, nil
+}
 
-		if err := json.Unmarshal(metadataJSON, &b.Metadata); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+func (r *BroadcasterRepo) scanBroadcaster(row pgx.Row) (*domain.Broadcaster, error) {
+	var b domain.Broadcaster
+	var metadataJSON []byte
+
+	err := row.Scan(
+		&b.ID,
+		&b.OrganizationID,
+		&b.DisplayName,
+		&metadataJSON,
+		&b.CreatedAt,
+		&b.UpdatedAt,
+		&b.ArchivedAt,
+		&b.Quotas.MaxActiveKeys,
+		&b.Quotas.MaxConcurrentStreams,
+		&b.Quotas.MaxStreamDurationSeconds,
+		&b.Status,
+		&b.LastSeenAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
 		}
+		return nil, fmt.Errorf("failed to scan broadcaster: %w", err)
+	}
 
-		broadcasters = append(broadcasters, b)
+	if err := json.Unmarshal(metadataJSON, &b.Metadata); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating broadcasters: %w", err)
+	return &b, nil
+}
+
+// broadcasterSortValue returns the cursor representation of a broadcaster's sort field.
+func broadcasterSortValue(sortBy domain.BroadcasterSortField, b *domain.Broadcaster) string {
+	switch sortBy {
+	case domain.BroadcasterSortDisplayName:
+		return b.DisplayName
+	case domain.BroadcasterSortUpdatedAt:
+		return b.UpdatedAt.Format(time.RFC3339Nano)
+	default:
+		return b.CreatedAt.Format(time.RFC3339Nano)
 	}
+}
+
+// broadcasterCursorValue parses a cursor sort value back into a query argument.
+func broadcasterCursorValue(sortBy domain.BroadcasterSortField, value string) (interface{}, error) {
+	if sortBy == domain.BroadcasterSortDisplayName {
+		return value, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+	return t, nil
+}
 
-	return broadcasters, nil
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
 }
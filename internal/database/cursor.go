@@ -0,0 +1,43 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// pageCursor is the decoded form of an opaque keyset pagination cursor. It
+// holds the sort value and ID of the last row on the previous page.
+type pageCursor struct {
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func encodeCursor(value string, id uuid.UUID) string {
+	// Marshalling a string and a UUID cannot fail
+	data, _ := json.Marshal(pageCursor{Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// escapeLike escapes LIKE wildcards so user input is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -6,9 +6,54 @@ import (
 	"time"
 
 	"github.com/exaring/otelpgx"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
 )
 
+// querier is satisfied by both pgxpool.Pool and pgx.Tx, so statements can be
+// shared by repositories and multi-table transactions.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// dbtx is a querier that can begin transactions. Beginning a transaction on
+// a pgx.Tx creates a savepoint, so repositories bound to a unit of work nest
+// their own transactions in it.
+type dbtx interface {
+	querier
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// scopeToOrganization appends a condition restricting column to the
+// organization ctx is scoped to. The query must end in a WHERE clause.
+// Unscoped contexts are not restricted.
+func scopeToOrganization(ctx context.Context, query, column string, args ...interface{}) (string, []interface{}) {
+	orgID, ok := domain.OrganizationFromContext(ctx)
+	if !ok {
+		return query, args
+	}
+	args = append(args, orgID)
+	return fmt.Sprintf("%s AND %s = $%d", query, column, len(args)), args
+}
+
+// organizationForInsert returns the organization a new row belongs to: id if
+// set, otherwise the organization ctx is scoped to or the default one.
+func organizationForInsert(ctx context.Context, id uuid.UUID) uuid.UUID {
+	if id != uuid.Nil {
+		return id
+	}
+	if orgID, ok := domain.OrganizationFromContext(ctx); ok {
+		return orgID
+	}
+	return domain.DefaultOrganizationID
+}
+
 // Option is a functional option for configuring the database client.
 type Option func(*options)
 
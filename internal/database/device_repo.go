@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// deviceColumns is the column list scanned by scanDevice.
+const deviceColumns = `id, organization_id, broadcaster_id, type, serial, model, capabilities, created_at, updated_at`
+
+// DeviceRepo implements domain.DeviceRepository using pgxpool.
+type DeviceRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewDeviceRepo creates a new DeviceRepo.
+func NewDeviceRepo(pool *pgxpool.Pool) *DeviceRepo {
+	return &DeviceRepo{pool: pool}
+}
+
+// Create creates a new device in the organization of its broadcaster. Serials
+// are unique within an organization.
+func (r *DeviceRepo) Create(ctx context.Context, device *domain.Device) error {
+	query := `
+		INSERT INTO devices (id, broadcaster_id, type, serial, model, capabilities, created_at, updated_at, organization_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, (SELECT organization_id FROM broadcasters WHERE id = $2))
+		RETURNING organization_id
+	`
+
+	if device.ID == uuid.Nil {
+		device.ID = uuid.New()
+	}
+
+	if device.Capabilities == nil {
+		device.Capabilities = []string{}
+	}
+
+	err := r.pool.QueryRow(ctx, query,
+		device.ID,
+		device.BroadcasterID,
+		device.Type,
+		device.Serial,
+		device.Model,
+		device.Capabilities,
+		device.CreatedAt,
+		device.UpdatedAt,
+	).Scan(&device.OrganizationID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("failed to create device: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a device by ID.
+func (r *DeviceRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Device, error) {
+	query := `
+		SELECT ` + deviceColumns + `
+		FROM devices
+		WHERE id = $1
+	`
+	query, args := scopeToOrganization(ctx, query, "organization_id", id)
+
+	return scanDevice(r.pool.QueryRow(ctx, query, args...))
+}
+
+// List retrieves the devices matching the filter, newest first.
+func (r *DeviceRepo) List(ctx context.Context, filter domain.DeviceFilter) ([]domain.Device, error) {
+	query := `
+		SELECT ` + deviceColumns + `
+		FROM devices
+		WHERE TRUE
+	`
+	query, args := scopeToOrganization(ctx, query, "organization_id")
+
+	if filter.BroadcasterID != nil {
+		args = append(args, *filter.BroadcasterID)
+		query += fmt.Sprintf(" AND broadcaster_id = $%d", len(args))
+	}
+
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		query += fmt.Sprintf(" AND type = $%d", len(args))
+	}
+
+	rows, err := r.pool.Query(ctx, query+" ORDER BY created_at DESC, id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devices: %w", err)
+	}
+	defer rows.Close()
+
+	var devices []domain.Device
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, *device)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating devices: %w", err)
+	}
+
+	return devices, nil
+}
+
+// Update updates the type, serial, model and capabilities of a device.
+func (r *DeviceRepo) Update(ctx context.Context, device *domain.Device) error {
+	query := `
+		UPDATE devices
+		SET type = $2, serial = $3, model = $4, capabilities = $5, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	if device.Capabilities == nil {
+		device.Capabilities = []string{}
+	}
+
+	query, args := scopeToOrganization(ctx, query, "organization_id",
+		device.ID, device.Type, device.Serial, device.Model, device.Capabilities)
+
+	result, err := r.pool.Exec(ctx, query, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("failed to update device: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete deletes a device. Stream keys bound to it are unbound and past
+// streams lose their device reference.
+func (r *DeviceRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	query, args := scopeToOrganization(ctx, "DELETE FROM devices WHERE id = $1", "organization_id", id)
+
+	result, err := r.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete device: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func scanDevice(row pgx.Row) (*domain.Device, error) {
+	var device domain.Device
+	err := row.Scan(
+		&device.ID,
+		&device.OrganizationID,
+		&device.BroadcasterID,
+		&device.Type,
+		&device.Serial,
+		&device.Model,
+		&device.Capabilities,
+		&device.CreatedAt,
+		&device.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan device: %w", err)
+	}
+
+	return &device, nil
+}
@@ -0,0 +1,250 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// enrollmentTokenColumns is the column list scanned by scanEnrollmentToken.
+const enrollmentTokenColumns = `id, organization_id, label, max_uses, use_count, expires_at, default_metadata, key_ttl_seconds, created_at, revoked_at`
+
+// EnrollmentRepo implements domain.EnrollmentRepository using pgxpool.
+type EnrollmentRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewEnrollmentRepo creates a new EnrollmentRepo.
+func NewEnrollmentRepo(pool *pgxpool.Pool) *EnrollmentRepo {
+	return &EnrollmentRepo{pool: pool}
+}
+
+// Create stores a token under the hash of its value.
+func (r *EnrollmentRepo) Create(ctx context.Context, token *domain.EnrollmentToken, tokenHash string) error {
+	metadataJSON, err := json.Marshal(token.DefaultMetadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal default metadata: %w", err)
+	}
+
+	query := `
+		INSERT INTO enrollment_tokens (id, organization_id, token_hash, label, max_uses, expires_at, default_metadata, key_ttl_seconds, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	if token.ID == uuid.Nil {
+		token.ID = uuid.New()
+	}
+
+	token.OrganizationID = organizationForInsert(ctx, token.OrganizationID)
+
+	_, err = r.pool.Exec(ctx, query,
+		token.ID,
+		token.OrganizationID,
+		tokenHash,
+		token.Label,
+		token.MaxUses,
+		token.ExpiresAt,
+		metadataJSON,
+		token.KeyTTLSeconds,
+		token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create enrollment token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an enrollment token by ID.
+func (r *EnrollmentRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.EnrollmentToken, error) {
+	query, args := scopeToOrganization(ctx,
+		`SELECT `+enrollmentTokenColumns+` FROM enrollment_tokens WHERE id = $1`, "organization_id", id)
+
+	return scanEnrollmentToken(r.pool.QueryRow(ctx, query, args...))
+}
+
+// GetByHash retrieves an enrollment token by the hash of its value.
+func (r *EnrollmentRepo) GetByHash(ctx context.Context, tokenHash string) (*domain.EnrollmentToken, error) {
+	query := `SELECT ` + enrollmentTokenColumns + ` FROM enrollment_tokens WHERE token_hash = $1`
+
+	return scanEnrollmentToken(r.pool.QueryRow(ctx, query, tokenHash))
+}
+
+// List retrieves all enrollment tokens, newest first.
+func (r *EnrollmentRepo) List(ctx context.Context) ([]domain.EnrollmentToken, error) {
+	query, args := scopeToOrganization(ctx,
+		`SELECT `+enrollmentTokenColumns+` FROM enrollment_tokens WHERE TRUE`, "organization_id")
+
+	rows, err := r.pool.Query(ctx, query+" ORDER BY created_at DESC", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list enrollment tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []domain.EnrollmentToken
+	for rows.Next() {
+		token, err := scanEnrollmentToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating enrollment tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Revoke marks a token revoked, keeping the original revocation time.
+func (r *EnrollmentRepo) Revoke(ctx context.Context, id uuid.UUID) error {
+	query, args := scopeToOrganization(ctx,
+		`UPDATE enrollment_tokens SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1`, "organization_id", id)
+
+	result, err := r.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to revoke enrollment token: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListRedemptions retrieves the redemptions of a token, newest first.
+func (r *EnrollmentRepo) ListRedemptions(ctx context.Context, tokenID uuid.UUID) ([]domain.EnrollmentRedemption, error) {
+	query := `
+		SELECT id, token_id, broadcaster_id, stream_key_id, display_name, COALESCE(ip, ''), COALESCE(user_agent, ''), redeemed_at
+		FROM enrollment_redemptions
+		WHERE token_id = $1
+		ORDER BY redeemed_at DESC
+	`
+
+	rows, err := r.pool.Query(ctx, query, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list enrollment redemptions: %w", err)
+	}
+	defer rows.Close()
+
+	var redemptions []domain.EnrollmentRedemption
+	for rows.Next() {
+		var rd domain.EnrollmentRedemption
+		if err := rows.Scan(
+			&rd.ID,
+			&rd.TokenID,
+			&rd.BroadcasterID,
+			&rd.StreamKeyID,
+			&rd.DisplayName,
+			&rd.IP,
+			&rd.UserAgent,
+			&rd.RedeemedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan enrollment redemption: %w", err)
+		}
+		redemptions = append(redemptions, rd)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating enrollment redemptions: %w", err)
+	}
+
+	return redemptions, nil
+}
+
+// Redeem atomically re-checks that the token is redeemable, consumes a use
+// and creates the broadcaster, stream key and redemption record. The
+// broadcaster is created in the token's organization.
+func (r *EnrollmentRepo) Redeem(ctx context.Context, tokenHash string, broadcaster *domain.Broadcaster, key *domain.StreamKey, redemption *domain.EnrollmentRedemption) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		query := `SELECT ` + enrollmentTokenColumns + ` FROM enrollment_tokens WHERE token_hash = $1 FOR UPDATE`
+
+		token, err := scanEnrollmentToken(tx.QueryRow(ctx, query, tokenHash))
+		if err != nil {
+			return err
+		}
+
+		if err := token.CheckRedeemable(time.Now()); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, "UPDATE enrollment_tokens SET use_count = use_count + 1 WHERE id = $1", token.ID); err != nil {
+			return fmt.Errorf("failed to consume enrollment token: %w", err)
+		}
+
+		broadcaster.OrganizationID = token.OrganizationID
+		if err := insertBroadcaster(ctx, tx, broadcaster); err != nil {
+			return err
+		}
+
+		if err := insertStreamKey(ctx, tx, key); err != nil {
+			return err
+		}
+
+		if redemption.ID == uuid.Nil {
+			redemption.ID = uuid.New()
+		}
+		redemption.TokenID = token.ID
+		redemption.BroadcasterID = &broadcaster.ID
+		redemption.StreamKeyID = &key.ID
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO enrollment_redemptions (id, token_id, broadcaster_id, stream_key_id, display_name, ip, user_agent, redeemed_at)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8)
+		`,
+			redemption.ID,
+			redemption.TokenID,
+			redemption.BroadcasterID,
+			redemption.StreamKeyID,
+			redemption.DisplayName,
+			redemption.IP,
+			redemption.UserAgent,
+			redemption.RedeemedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record enrollment redemption: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func scanEnrollmentToken(row pgx.Row) (*domain.EnrollmentToken, error) {
+	var token domain.EnrollmentToken
+	var metadataJSON []byte
+
+	err := row.Scan(
+		&token.ID,
+		&token.OrganizationID,
+		&token.Label,
+		&token.MaxUses,
+		&token.UseCount,
+		&token.ExpiresAt,
+		&metadataJSON,
+		&token.KeyTTLSeconds,
+		&token.CreatedAt,
+		&token.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan enrollment token: %w", err)
+	}
+
+	if err := json.Unmarshal(metadataJSON, &token.DefaultMetadata); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal default metadata: %w", err)
+	}
+
+	return &token, nil
+}
@@ -0,0 +1,540 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// groupColumns is the column list scanned by scanGroup.
+const groupColumns = `id, organization_id, name, description, created_at, updated_at,
+	(SELECT COUNT(*) FROM broadcaster_group_members m WHERE m.group_id = broadcaster_groups.id)`
+
+// BroadcasterGroupRepo implements domain.BroadcasterGroupRepository using pgxpool.
+type BroadcasterGroupRepo struct {
+	pool         *pgxpool.Pool
+	broadcasters *BroadcasterRepo
+}
+
+// NewBroadcasterGroupRepo creates a new BroadcasterGroupRepo.
+func NewBroadcasterGroupRepo(pool *pgxpool.Pool) *BroadcasterGroupRepo {
+	return &BroadcasterGroupRepo{pool: pool, broadcasters: NewBroadcasterRepo(pool)}
+}
+
+// Create creates a new broadcaster group. Group names are unique, ignoring
+// case, within an organization.
+func (r *BroadcasterGroupRepo) Create(ctx context.Context, group *domain.BroadcasterGroup) error {
+	query := `
+		INSERT INTO broadcaster_groups (id, organization_id, name, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	if group.ID == uuid.Nil {
+		group.ID = uuid.New()
+	}
+
+	group.OrganizationID = organizationForInsert(ctx, group.OrganizationID)
+
+	_, err := r.pool.Exec(ctx, query,
+		group.ID,
+		group.OrganizationID,
+		group.Name,
+		group.Description,
+		group.CreatedAt,
+		group.UpdatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("failed to create broadcaster group: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a broadcaster group by ID.
+func (r *BroadcasterGroupRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.BroadcasterGroup, error) {
+	query := `
+		SELECT ` + groupColumns + `
+		FROM broadcaster_groups
+		WHERE id = $1
+	`
+	query, args := scopeToOrganization(ctx, query, "organization_id", id)
+
+	return scanGroup(r.pool.QueryRow(ctx, query, args...))
+}
+
+// List retrieves all broadcaster groups ordered by name.
+func (r *BroadcasterGroupRepo) List(ctx context.Context) ([]domain.BroadcasterGroup, error) {
+	query := `
+		SELECT ` + groupColumns + `
+		FROM broadcaster_groups
+		WHERE TRUE
+	`
+	query, args := scopeToOrganization(ctx, query, "organization_id")
+
+	rows, err := r.pool.Query(ctx, query+" ORDER BY LOWER(name)", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list broadcaster groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []domain.BroadcasterGroup
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, *group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating broadcaster groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+// Update updates the name and description of a broadcaster group.
+func (r *BroadcasterGroupRepo) Update(ctx context.Context, group *domain.BroadcasterGroup) error {
+	query := `
+		UPDATE broadcaster_groups
+		SET name = $2, description = $3, updated_at = NOW()
+		WHERE id = $1
+	`
+	query, args := scopeToOrganization(ctx, query, "organization_id", group.ID, group.Name, group.Description)
+
+	result, err := r.pool.Exec(ctx, query, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("failed to update broadcaster group: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete deletes a broadcaster group with its memberships and jobs. The
+// broadcasters themselves are kept.
+func (r *BroadcasterGroupRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	query, args := scopeToOrganization(ctx, "DELETE FROM broadcaster_groups WHERE id = $1", "organization_id", id)
+
+	result, err := r.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete broadcaster group: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// AddMembers adds broadcasters to a group, ignoring existing members.
+func (r *BroadcasterGroupRepo) AddMembers(ctx context.Context, groupID uuid.UUID, broadcasterIDs []uuid.UUID) error {
+	query := `
+		INSERT INTO broadcaster_group_members (group_id, broadcaster_id)
+		SELECT $1, unnest($2::uuid[])
+		ON CONFLICT DO NOTHING
+	`
+
+	if _, err := r.pool.Exec(ctx, query, groupID, broadcasterIDs); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return domain.ErrNotFound
+		}
+		return fmt.Errorf("failed to add broadcaster group members: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveMember removes a broadcaster from a group.
+func (r *BroadcasterGroupRepo) RemoveMember(ctx context.Context, groupID, broadcasterID uuid.UUID) error {
+	query := `DELETE FROM broadcaster_group_members WHERE group_id = $1 AND broadcaster_id = $2`
+
+	result, err := r.pool.Exec(ctx, query, groupID, broadcasterID)
+	if err != nil {
+		return fmt.Errorf("failed to remove broadcaster group member: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListMembers lists the broadcasters in a group ordered by display name.
+func (r *BroadcasterGroupRepo) ListMembers(ctx context.Context, groupID uuid.UUID) ([]domain.Broadcaster, error) {
+	query := `
+		SELECT ` + broadcasterColumns + `
+		FROM broadcasters
+		WHERE id IN (SELECT broadcaster_id FROM broadcaster_group_members WHERE group_id = $1)
+	`
+	query, args := scopeToOrganization(ctx, query, "organization_id", groupID)
+
+	rows, err := r.pool.Query(ctx, query+" ORDER BY display_name, id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list broadcaster group members: %w", err)
+	}
+	defer rows.Close()
+
+	var broadcasters []domain.Broadcaster
+	for rows.Next() {
+		b, err := r.broadcasters.scanBroadcaster(rows)
+		if err != nil {
+			return nil, err
+		}
+		broadcasters = append(broadcasters, *b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating broadcaster group members: %w", err)
+	}
+
+	return broadcasters, nil
+}
+
+func scanGroup(row pgx.Row) (*domain.BroadcasterGroup, error) {
+	var group domain.BroadcasterGroup
+	err := row.Scan(
+		&group.ID,
+		&group.OrganizationID,
+		&group.Name,
+		&group.Description,
+		&group.CreatedAt,
+		&group.UpdatedAt,
+		&group.MemberCount,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan broadcaster group: %w", err)
+	}
+
+	return &group, nil
+}
+
+// groupJobColumns is the column list scanned by scanGroupJob, including the
+// result counts.
+const groupJobColumns = `j.id, j.group_id, j.organization_id, j.action, j.status, j.label, j.expires_at, j.batch_id,
+	j.created_at, j.started_at, j.completed_at,
+	c.total, c.pending, c.succeeded, c.skipped, c.failed`
+
+// groupJobCounts joins the per-member result counts of a job aliased j.
+const groupJobCounts = `CROSS JOIN LATERAL (
+	SELECT
+		COUNT(*) AS total,
+		COUNT(*) FILTER (WHERE status = 'pending') AS pending,
+		COUNT(*) FILTER (WHERE status = 'succeeded') AS succeeded,
+		COUNT(*) FILTER (WHERE status = 'skipped') AS skipped,
+		COUNT(*) FILTER (WHERE status = 'failed') AS failed
+	FROM group_job_results
+	WHERE job_id = j.id
+) c`
+
+// GroupJobRepo implements domain.GroupJobRepository using pgxpool.
+type GroupJobRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewGroupJobRepo creates a new GroupJobRepo.
+func NewGroupJobRepo(pool *pgxpool.Pool) *GroupJobRepo {
+	return &GroupJobRepo{pool: pool}
+}
+
+// Create creates a job with a pending result for every current member of its group.
+func (r *GroupJobRepo) Create(ctx context.Context, job *domain.GroupJob) error {
+	if job.ID == uuid.Nil {
+		job.ID = uuid.New()
+	}
+
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		query := `
+			INSERT INTO group_jobs (id, group_id, organization_id, action, status, label, expires_at, batch_id, created_at)
+			VALUES ($1, $2, (SELECT organization_id FROM broadcaster_groups WHERE id = $2), $3, $4, $5, $6, $7, $8)
+			RETURNING organization_id
+		`
+
+		err := tx.QueryRow(ctx, query,
+			job.ID,
+			job.GroupID,
+			job.Action,
+			job.Status,
+			job.Label,
+			job.ExpiresAt,
+			job.BatchID,
+			job.CreatedAt,
+		).Scan(&job.OrganizationID)
+		if err != nil {
+			return fmt.Errorf("failed to create group job: %w", err)
+		}
+
+		result, err := tx.Exec(ctx, `
+			INSERT INTO group_job_results (job_id, broadcaster_id)
+			SELECT $1, broadcaster_id FROM broadcaster_group_members WHERE group_id = $2
+		`, job.ID, job.GroupID)
+		if err != nil {
+			return fmt.Errorf("failed to create group job results: %w", err)
+		}
+
+		job.Counts = domain.GroupJobCounts{
+			Total:   int(result.RowsAffected()),
+			Pending: int(result.RowsAffected()),
+		}
+
+		return nil
+	})
+}
+
+// GetByID retrieves a job of a group by ID.
+func (r *GroupJobRepo) GetByID(ctx context.Context, groupID, id uuid.UUID) (*domain.GroupJob, error) {
+	query := `
+		SELECT ` + groupJobColumns + `
+		FROM group_jobs j
+		` + groupJobCounts + `
+		WHERE j.id = $1 AND j.group_id = $2
+	`
+	query, args := scopeToOrganization(ctx, query, "j.organization_id", id, groupID)
+
+	return scanGroupJob(r.pool.QueryRow(ctx, query, args...))
+}
+
+// ListByGroup lists the jobs of a group, newest first.
+func (r *GroupJobRepo) ListByGroup(ctx context.Context, groupID uuid.UUID) ([]domain.GroupJob, error) {
+	query := `
+		SELECT ` + groupJobColumns + `
+		FROM group_jobs j
+		` + groupJobCounts + `
+		WHERE j.group_id = $1
+	`
+	query, args := scopeToOrganization(ctx, query, "j.organization_id", groupID)
+
+	rows, err := r.pool.Query(ctx, query+" ORDER BY j.created_at DESC", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []domain.GroupJob
+	for rows.Next() {
+		job, err := scanGroupJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating group jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// ListResults lists the per-member results of a job.
+func (r *GroupJobRepo) ListResults(ctx context.Context, jobID uuid.UUID) ([]domain.GroupJobResult, error) {
+	query := `
+		SELECT broadcaster_id, status, stream_key_ids, error, completed_at
+		FROM group_job_results
+		WHERE job_id = $1
+		ORDER BY completed_at NULLS LAST, broadcaster_id
+	`
+
+	rows, err := r.pool.Query(ctx, query, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group job results: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.GroupJobResult
+	for rows.Next() {
+		var result domain.GroupJobResult
+		if err := rows.Scan(
+			&result.BroadcasterID,
+			&result.Status,
+			&result.StreamKeyIDs,
+			&result.Error,
+			&result.CompletedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan group job result: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating group job results: %w", err)
+	}
+
+	return results, nil
+}
+
+// ClaimNext marks the oldest pending job, or running job whose lease ran out,
+// running until lease passes and returns it. Concurrent runners never claim
+// the same job.
+func (r *GroupJobRepo) ClaimNext(ctx context.Context, lease time.Duration) (*domain.GroupJob, error) {
+	query := `
+		UPDATE group_jobs
+		SET status = 'running', started_at = COALESCE(started_at, NOW()),
+			lease_expires_at = NOW() + make_interval(secs => $1)
+		WHERE id = (
+			SELECT id FROM group_jobs
+			WHERE status = 'pending' OR (status = 'running' AND lease_expires_at <= NOW())
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, group_id
+	`
+
+	var id, groupID uuid.UUID
+	if err := r.pool.QueryRow(ctx, query, lease.Seconds()).Scan(&id, &groupID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to claim group job: %w", err)
+	}
+
+	return r.GetByID(ctx, groupID, id)
+}
+
+// ListPendingMembers lists the members a job has not processed yet.
+func (r *GroupJobRepo) ListPendingMembers(ctx context.Context, jobID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT broadcaster_id
+		FROM group_job_results
+		WHERE job_id = $1 AND status = 'pending'
+		ORDER BY broadcaster_id
+	`
+
+	rows, err := r.pool.Query(ctx, query, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending group job members: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan pending group job member: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pending group job members: %w", err)
+	}
+
+	return ids, nil
+}
+
+// RecordResult records the outcome of a job for one member.
+func (r *GroupJobRepo) RecordResult(ctx context.Context, jobID uuid.UUID, result domain.GroupJobResult) error {
+	query := `
+		UPDATE group_job_results
+		SET status = $3, stream_key_ids = $4, error = $5, completed_at = NOW()
+		WHERE job_id = $1 AND broadcaster_id = $2
+	`
+
+	keyIDs := result.StreamKeyIDs
+	if keyIDs == nil {
+		keyIDs = []uuid.UUID{}
+	}
+
+	tag, err := r.pool.Exec(ctx, query, jobID, result.BroadcasterID, result.Status, keyIDs, result.Error)
+	if err != nil {
+		return fmt.Errorf("failed to record group job result: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Complete marks a job completed.
+func (r *GroupJobRepo) Complete(ctx context.Context, jobID uuid.UUID) error {
+	query := `UPDATE group_jobs SET status = 'completed', completed_at = NOW(), lease_expires_at = NULL WHERE id = $1`
+
+	result, err := r.pool.Exec(ctx, query, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to complete group job: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// RenewLease extends the lease of a running job until lease passes.
+func (r *GroupJobRepo) RenewLease(ctx context.Context, jobID uuid.UUID, lease time.Duration) error {
+	query := `
+		UPDATE group_jobs
+		SET lease_expires_at = NOW() + make_interval(secs => $2)
+		WHERE id = $1 AND status = 'running'
+	`
+
+	result, err := r.pool.Exec(ctx, query, jobID, lease.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to renew group job lease: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func scanGroupJob(row pgx.Row) (*domain.GroupJob, error) {
+	var job domain.GroupJob
+	err := row.Scan(
+		&job.ID,
+		&job.GroupID,
+		&job.OrganizationID,
+		&job.Action,
+		&job.Status,
+		&job.Label,
+		&job.ExpiresAt,
+		&job.BatchID,
+		&job.CreatedAt,
+		&job.StartedAt,
+		&job.CompletedAt,
+		&job.Counts.Total,
+		&job.Counts.Pending,
+		&job.Counts.Succeeded,
+		&job.Counts.Skipped,
+		&job.Counts.Failed,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan group job: %w", err)
+	}
+
+	return &job, nil
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// AuthLockoutRepo implements domain.AuthLockoutRepository using pgxpool.
+type AuthLockoutRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewAuthLockoutRepo creates a new AuthLockoutRepo.
+func NewAuthLockoutRepo(pool *pgxpool.Pool) *AuthLockoutRepo {
+	return &AuthLockoutRepo{pool: pool}
+}
+
+// Get retrieves the lockout state for a scope and subject.
+func (r *AuthLockoutRepo) Get(ctx context.Context, scope domain.LockoutScope, subject string) (*domain.AuthLockout, error) {
+	query := `
+		SELECT scope, subject, failure_count, lockout_count, first_failure_at, last_failure_at, locked_until
+		FROM auth_lockouts
+		WHERE scope = $1 AND subject = $2
+	`
+
+	return r.scanLockout(r.pool.QueryRow(ctx, query, scope, subject))
+}
+
+// List retrieves all tracked lockout states.
+func (r *AuthLockoutRepo) List(ctx context.Context) ([]domain.AuthLockout, error) {
+	query := `
+		SELECT scope, subject, failure_count, lockout_count, first_failure_at, last_failure_at, locked_until
+		FROM auth_lockouts
+		ORDER BY last_failure_at DESC
+	`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list auth lockouts: %w", err)
+	}
+	defer rows.Close()
+
+	var lockouts []domain.AuthLockout
+	for rows.Next() {
+		var l domain.AuthLockout
+		if err := rows.Scan(
+			&l.Scope,
+			&l.Subject,
+			&l.FailureCount,
+			&l.LockoutCount,
+			&l.FirstFailureAt,
+			&l.LastFailureAt,
+			&l.LockedUntil,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan auth lockout: %w", err)
+		}
+		lockouts = append(lockouts, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating auth lockouts: %w", err)
+	}
+
+	return lockouts, nil
+}
+
+// Delete clears the lockout state for a scope and subject.
+func (r *AuthLockoutRepo) Delete(ctx context.Context, scope domain.LockoutScope, subject string) error {
+	query := `DELETE FROM auth_lockouts WHERE scope = $1 AND subject = $2`
+
+	result, err := r.pool.Exec(ctx, query, scope, subject)
+	if err != nil {
+		return fmt.Errorf("failed to delete auth lockout: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// RecordFailure increments the failure counter for a scope and subject.
+// The counter restarts when the previous failure is older than windowStart.
+func (r *AuthLockoutRepo) RecordFailure(ctx context.Context, scope domain.LockoutScope, subject string, windowStart time.Time) (*domain.AuthLockout, error) {
+	query := `
+		INSERT INTO auth_lockouts (scope, subject, failure_count, first_failure_at, last_failure_at)
+		VALUES ($1, $2, 1, NOW(), NOW())
+		ON CONFLICT (scope, subject) DO UPDATE SET
+			failure_count = CASE
+				WHEN auth_lockouts.last_failure_at < $3 THEN 1
+				ELSE auth_lockouts.failure_count + 1
+			END,
+			first_failure_at = CASE
+				WHEN auth_lockouts.last_failure_at < $3 THEN NOW()
+				ELSE auth_lockouts.first_failure_at
+			END,
+			last_failure_at = NOW()
+		RETURNING scope, subject, failure_count, lockout_count, first_failure_at, last_failure_at, locked_until
+	`
+
+	lockout, err := r.scanLockout(r.pool.QueryRow(ctx, query, scope, subject, windowStart))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record auth failure: %w", err)
+	}
+
+	return lockout, nil
+}
+
+// Lock locks a scope and subject until the given time.
+func (r *AuthLockoutRepo) Lock(ctx context.Context, scope domain.LockoutScope, subject string, lockedUntil time.Time) (*domain.AuthLockout, error) {
+	query := `
+		UPDATE auth_lockouts
+		SET failure_count = 0, lockout_count = lockout_count + 1, locked_until = $3
+		WHERE scope = $1 AND subject = $2
+		RETURNING scope, subject, failure_count, lockout_count, first_failure_at, last_failure_at, locked_until
+	`
+
+	return r.scanLockout(r.pool.QueryRow(ctx, query, scope, subject, lockedUntil))
+}
+
+// ResetFailures clears the failure counter for a scope and subject.
+func (r *AuthLockoutRepo) ResetFailures(ctx context.Context, scope domain.LockoutScope, subject string) error {
+	query := `UPDATE auth_lockouts SET failure_count = 0 WHERE scope = $1 AND subject = $2`
+
+	if _, err := r.pool.Exec(ctx, query, scope, subject); err != nil {
+		return fmt.Errorf("failed to reset auth failures: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AuthLockoutRepo) scanLockout(row pgx.Row) (*domain.AuthLockout, error) {
+	var l domain.AuthLockout
+
+	err := row.Scan(
+		&l.Scope,
+		&l.Subject,
+		&l.FailureCount,
+		&l.LockoutCount,
+		&l.FirstFailureAt,
+		&l.LastFailureAt,
+		&l.LockedUntil,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan auth lockout: %w", err)
+	}
+
+	return &l, nil
+}
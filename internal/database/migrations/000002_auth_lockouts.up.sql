@@ -0,0 +1,13 @@
+-- Failed publish attempts and lockouts, tracked per source IP and per claimed path
+CREATE TABLE auth_lockouts (
+    scope VARCHAR(20) NOT NULL CHECK (scope IN ('ip', 'path')),
+    subject VARCHAR(255) NOT NULL,
+    failure_count INTEGER NOT NULL DEFAULT 0,
+    lockout_count INTEGER NOT NULL DEFAULT 0,
+    first_failure_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_failure_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMPTZ,
+    PRIMARY KEY (scope, subject)
+);
+
+CREATE INDEX idx_auth_lockouts_locked_until ON auth_lockouts(locked_until) WHERE locked_until IS NOT NULL;
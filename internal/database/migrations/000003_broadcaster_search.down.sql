@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_broadcasters_display_name;
+DROP INDEX IF EXISTS idx_broadcasters_updated_at;
+DROP INDEX IF EXISTS idx_broadcasters_created_at;
+DROP INDEX IF EXISTS idx_broadcasters_metadata;
+DROP INDEX IF EXISTS idx_broadcasters_display_name_trgm;
+DROP EXTENSION IF EXISTS pg_trgm;
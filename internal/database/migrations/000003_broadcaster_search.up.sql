@@ -0,0 +1,12 @@
+-- Trigram index for substring search on display names
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_broadcasters_display_name_trgm ON broadcasters USING GIN (display_name gin_trgm_ops);
+
+-- Containment (@>) filters on metadata
+CREATE INDEX idx_broadcasters_metadata ON broadcasters USING GIN (metadata jsonb_path_ops);
+
+-- Keyset pagination
+CREATE INDEX idx_broadcasters_created_at ON broadcasters(created_at, id);
+CREATE INDEX idx_broadcasters_updated_at ON broadcasters(updated_at, id);
+CREATE INDEX idx_broadcasters_display_name ON broadcasters(display_name, id);
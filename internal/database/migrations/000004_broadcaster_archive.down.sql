@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_broadcasters_archived_at;
+ALTER TABLE broadcasters DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,4 @@
+-- Broadcasters are archived instead of deleted so their history is kept
+ALTER TABLE broadcasters ADD COLUMN archived_at TIMESTAMPTZ;
+
+CREATE INDEX idx_broadcasters_archived_at ON broadcasters(archived_at) WHERE archived_at IS NOT NULL;
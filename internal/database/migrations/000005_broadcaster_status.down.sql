@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_streams_stream_key_started;
+DROP INDEX IF EXISTS idx_broadcasters_status;
+ALTER TABLE broadcasters DROP COLUMN IF EXISTS status;
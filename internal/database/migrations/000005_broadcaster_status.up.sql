@@ -0,0 +1,10 @@
+-- Operator-set broadcaster availability. Live is derived from active streams
+-- and never stored.
+ALTER TABLE broadcasters
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'offline'
+    CONSTRAINT broadcasters_status_check CHECK (status IN ('offline', 'available', 'out_of_service'));
+
+CREATE INDEX idx_broadcasters_status ON broadcasters(status);
+
+-- Supports recent stream counts and last seen lookups per key
+CREATE INDEX idx_streams_stream_key_started ON streams(stream_key_id, started_at DESC);
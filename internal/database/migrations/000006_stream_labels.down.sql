@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_streams_tags;
+ALTER TABLE streams
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS notes,
+    DROP COLUMN IF EXISTS title;
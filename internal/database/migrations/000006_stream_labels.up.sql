@@ -0,0 +1,7 @@
+-- Operator-editable stream labels
+ALTER TABLE streams
+    ADD COLUMN title VARCHAR(255),
+    ADD COLUMN notes TEXT,
+    ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_streams_tags ON streams USING GIN (tags);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_stream_keys_publish_path;
+ALTER TABLE stream_keys DROP COLUMN IF EXISTS publish_path;
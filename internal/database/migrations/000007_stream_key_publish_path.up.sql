@@ -0,0 +1,6 @@
+-- The path a key last published to. Keys used with username/password or SRT
+-- credentials publish to paths unrelated to the key value, so lifecycle
+-- webhooks resolve the key through this column.
+ALTER TABLE stream_keys ADD COLUMN publish_path VARCHAR(255);
+
+CREATE INDEX idx_stream_keys_publish_path ON stream_keys(publish_path, last_used_at DESC) WHERE publish_path IS NOT NULL;
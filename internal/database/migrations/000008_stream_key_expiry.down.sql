@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_stream_keys_active_expires_at;
+DROP TABLE IF EXISTS stream_key_expiry_notices;
@@ -0,0 +1,12 @@
+-- Expiring-soon notices already sent, so each lead time is notified once per
+-- expiry time. A changed expiry time is notified again.
+CREATE TABLE stream_key_expiry_notices (
+    stream_key_id UUID NOT NULL REFERENCES stream_keys(id) ON DELETE CASCADE,
+    lead_time_seconds INTEGER NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    notified_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (stream_key_id, lead_time_seconds, expires_at)
+);
+
+-- Supports the expiry sweep over active keys
+CREATE INDEX idx_stream_keys_active_expires_at ON stream_keys(expires_at) WHERE status = 'active' AND expires_at IS NOT NULL;
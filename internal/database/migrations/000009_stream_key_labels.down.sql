@@ -0,0 +1,10 @@
+UPDATE stream_keys SET status = 'revoked', revoked_at = NOW() WHERE status = 'suspended';
+
+ALTER TABLE stream_keys DROP CONSTRAINT stream_keys_status_check;
+ALTER TABLE stream_keys
+    ADD CONSTRAINT stream_keys_status_check CHECK (status IN ('active', 'revoked', 'expired'));
+
+ALTER TABLE stream_keys
+    DROP COLUMN IF EXISTS suspended_at,
+    DROP COLUMN IF EXISTS description,
+    DROP COLUMN IF EXISTS label;
@@ -0,0 +1,10 @@
+-- Operator-editable label and description for stream keys
+ALTER TABLE stream_keys
+    ADD COLUMN label VARCHAR(255),
+    ADD COLUMN description TEXT,
+    ADD COLUMN suspended_at TIMESTAMPTZ;
+
+-- Suspended keys are temporarily rejected and can be resumed
+ALTER TABLE stream_keys DROP CONSTRAINT stream_keys_status_check;
+ALTER TABLE stream_keys
+    ADD CONSTRAINT stream_keys_status_check CHECK (status IN ('active', 'suspended', 'revoked', 'expired'));
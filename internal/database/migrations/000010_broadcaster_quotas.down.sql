@@ -0,0 +1,4 @@
+ALTER TABLE broadcasters
+    DROP COLUMN IF EXISTS max_stream_duration_seconds,
+    DROP COLUMN IF EXISTS max_concurrent_streams,
+    DROP COLUMN IF EXISTS max_active_keys;
@@ -0,0 +1,6 @@
+-- Per-broadcaster overrides of the deployment-wide quotas. NULL uses the
+-- deployment default and 0 means unlimited.
+ALTER TABLE broadcasters
+    ADD COLUMN max_active_keys INTEGER CHECK (max_active_keys >= 0),
+    ADD COLUMN max_concurrent_streams INTEGER CHECK (max_concurrent_streams >= 0),
+    ADD COLUMN max_stream_duration_seconds INTEGER CHECK (max_stream_duration_seconds >= 0);
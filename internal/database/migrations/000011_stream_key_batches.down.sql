@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_stream_keys_batch_id;
+ALTER TABLE stream_keys DROP COLUMN IF EXISTS batch_id;
+DROP TABLE IF EXISTS stream_key_batches;
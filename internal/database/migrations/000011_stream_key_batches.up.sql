@@ -0,0 +1,13 @@
+-- Stream keys issued together for an operation. The export containing the
+-- key values can be retrieved once.
+CREATE TABLE stream_key_batches (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    label VARCHAR(255),
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    exported_at TIMESTAMPTZ
+);
+
+ALTER TABLE stream_keys ADD COLUMN batch_id UUID REFERENCES stream_key_batches(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_stream_keys_batch_id ON stream_keys(batch_id) WHERE batch_id IS NOT NULL;
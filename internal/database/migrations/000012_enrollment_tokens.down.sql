@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS enrollment_redemptions;
+DROP TABLE IF EXISTS enrollment_tokens;
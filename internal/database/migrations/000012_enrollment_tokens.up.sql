@@ -0,0 +1,29 @@
+-- Tokens that let devices register themselves as broadcasters. Only a hash
+-- of the token is stored.
+CREATE TABLE enrollment_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    label VARCHAR(255),
+    max_uses INTEGER CHECK (max_uses > 0),
+    use_count INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ,
+    default_metadata JSONB NOT NULL DEFAULT '{}',
+    key_ttl_seconds INTEGER CHECK (key_ttl_seconds > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+-- Every redemption of an enrollment token. Kept when the broadcaster or key
+-- is purged.
+CREATE TABLE enrollment_redemptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    token_id UUID NOT NULL REFERENCES enrollment_tokens(id) ON DELETE CASCADE,
+    broadcaster_id UUID REFERENCES broadcasters(id) ON DELETE SET NULL,
+    stream_key_id UUID REFERENCES stream_keys(id) ON DELETE SET NULL,
+    display_name VARCHAR(255) NOT NULL,
+    ip VARCHAR(45),
+    user_agent TEXT,
+    redeemed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_enrollment_redemptions_token_id ON enrollment_redemptions(token_id, redeemed_at DESC);
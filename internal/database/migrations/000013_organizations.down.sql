@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS stream_shares;
+DROP TABLE IF EXISTS api_clients;
+DROP INDEX IF EXISTS idx_streams_organization_id;
+DROP INDEX IF EXISTS idx_stream_keys_organization_id;
+DROP INDEX IF EXISTS idx_broadcasters_organization_id;
+ALTER TABLE enrollment_tokens DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE stream_key_batches DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE streams DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE stream_keys DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE broadcasters DROP COLUMN IF EXISTS organization_id;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,50 @@
+-- Organizations (tenants) sharing the deployment. Data created before
+-- organizations existed, or without a tenant, belongs to the default one.
+CREATE TABLE organizations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    slug VARCHAR(63) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO organizations (id, slug, name)
+VALUES ('00000000-0000-0000-0000-000000000001', 'default', 'Default');
+
+ALTER TABLE broadcasters ADD COLUMN organization_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations(id);
+ALTER TABLE stream_keys ADD COLUMN organization_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations(id);
+ALTER TABLE streams ADD COLUMN organization_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations(id);
+ALTER TABLE stream_key_batches ADD COLUMN organization_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations(id);
+ALTER TABLE enrollment_tokens ADD COLUMN organization_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations(id);
+
+CREATE INDEX idx_broadcasters_organization_id ON broadcasters(organization_id);
+CREATE INDEX idx_stream_keys_organization_id ON stream_keys(organization_id);
+CREATE INDEX idx_streams_organization_id ON streams(organization_id);
+
+-- API clients authenticate with HMAC signatures scoped to one organization.
+-- Requests are signed with the secret, so it is stored as is.
+CREATE TABLE api_clients (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    api_key VARCHAR(64) NOT NULL UNIQUE,
+    secret VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_api_clients_organization_id ON api_clients(organization_id);
+
+-- Streams shared with other organizations, e.g. for mutual-aid incidents
+CREATE TABLE stream_shares (
+    stream_id UUID NOT NULL REFERENCES streams(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (stream_id, organization_id)
+);
+
+CREATE INDEX idx_stream_shares_organization_id ON stream_shares(organization_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS group_job_results;
+DROP TABLE IF EXISTS group_jobs;
+DROP TABLE IF EXISTS broadcaster_group_members;
+DROP TABLE IF EXISTS broadcaster_groups;
@@ -0,0 +1,52 @@
+-- Teams of broadcasters (K9, drone unit, swiftwater) within an organization.
+CREATE TABLE broadcaster_groups (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL
+        DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations(id),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_broadcaster_groups_name ON broadcaster_groups(organization_id, LOWER(name));
+
+CREATE TABLE broadcaster_group_members (
+    group_id UUID NOT NULL REFERENCES broadcaster_groups(id) ON DELETE CASCADE,
+    broadcaster_id UUID NOT NULL REFERENCES broadcasters(id) ON DELETE CASCADE,
+    added_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (group_id, broadcaster_id)
+);
+
+CREATE INDEX idx_broadcaster_group_members_broadcaster_id ON broadcaster_group_members(broadcaster_id);
+
+-- Bulk actions on a group. Members are snapshotted into pending results when
+-- the job is created and processed in the background.
+CREATE TABLE group_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    group_id UUID NOT NULL REFERENCES broadcaster_groups(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    action VARCHAR(32) NOT NULL CHECK (action IN ('issue_keys', 'revoke_keys', 'set_expiry')),
+    status VARCHAR(32) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed')),
+    label VARCHAR(255),
+    expires_at TIMESTAMPTZ,
+    -- Keys issued by an issue_keys job, exportable once
+    batch_id UUID REFERENCES stream_key_batches(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_group_jobs_group_id ON group_jobs(group_id, created_at DESC);
+CREATE INDEX idx_group_jobs_pending ON group_jobs(created_at) WHERE status = 'pending';
+
+CREATE TABLE group_job_results (
+    job_id UUID NOT NULL REFERENCES group_jobs(id) ON DELETE CASCADE,
+    broadcaster_id UUID NOT NULL REFERENCES broadcasters(id) ON DELETE CASCADE,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'succeeded', 'skipped', 'failed')),
+    stream_key_ids UUID[] NOT NULL DEFAULT '{}',
+    error TEXT,
+    completed_at TIMESTAMPTZ,
+    PRIMARY KEY (job_id, broadcaster_id)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE streams DROP COLUMN IF EXISTS device_id;
+ALTER TABLE stream_keys DROP CONSTRAINT IF EXISTS stream_keys_device_fkey;
+ALTER TABLE stream_keys DROP COLUMN IF EXISTS device_id;
+DROP TABLE IF EXISTS devices;
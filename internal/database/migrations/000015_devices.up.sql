@@ -0,0 +1,33 @@
+-- Hardware that publishes streams (drones, bodycams, vehicle cameras),
+-- owned by a broadcaster.
+CREATE TABLE devices (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    broadcaster_id UUID NOT NULL REFERENCES broadcasters(id) ON DELETE CASCADE,
+    type VARCHAR(32) NOT NULL
+        CHECK (type IN ('drone', 'bodycam', 'vehicle', 'handheld', 'fixed', 'other')),
+    serial VARCHAR(255),
+    model VARCHAR(255),
+    capabilities TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- Lets stream keys reference a device of their own broadcaster
+    UNIQUE (id, broadcaster_id)
+);
+
+CREATE INDEX idx_devices_broadcaster_id ON devices(broadcaster_id);
+CREATE INDEX idx_devices_type ON devices(organization_id, type);
+CREATE UNIQUE INDEX idx_devices_serial ON devices(organization_id, serial) WHERE serial IS NOT NULL;
+
+-- A stream key may be bound to a device of its broadcaster
+ALTER TABLE stream_keys ADD COLUMN device_id UUID;
+ALTER TABLE stream_keys ADD CONSTRAINT stream_keys_device_fkey
+    FOREIGN KEY (device_id, broadcaster_id) REFERENCES devices(id, broadcaster_id)
+    ON DELETE SET NULL (device_id);
+
+CREATE INDEX idx_stream_keys_device_id ON stream_keys(device_id) WHERE device_id IS NOT NULL;
+
+-- The device a stream was published from, taken from its key
+ALTER TABLE streams ADD COLUMN device_id UUID REFERENCES devices(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_streams_device_id ON streams(device_id) WHERE device_id IS NOT NULL;
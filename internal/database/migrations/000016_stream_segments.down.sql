@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS stream_segments;
+
+DROP INDEX IF EXISTS idx_streams_interrupted;
+
+UPDATE streams SET status = 'ended', ended_at = interrupted_at WHERE status = 'interrupted';
+
+ALTER TABLE streams DROP COLUMN IF EXISTS interrupted_at;
+
+ALTER TABLE streams DROP CONSTRAINT streams_status_check;
+ALTER TABLE streams
+    ADD CONSTRAINT streams_status_check CHECK (status IN ('active', 'ended'));
@@ -0,0 +1,32 @@
+-- Streams whose publisher dropped are interrupted, rather than ended, for a
+-- grace period in which the same key may resume them.
+ALTER TABLE streams DROP CONSTRAINT streams_status_check;
+ALTER TABLE streams
+    ADD CONSTRAINT streams_status_check CHECK (status IN ('active', 'interrupted', 'ended'));
+
+ALTER TABLE streams ADD COLUMN interrupted_at TIMESTAMPTZ;
+
+-- Supports resuming by key and the sweep of interruptions past their grace
+CREATE INDEX idx_streams_interrupted ON streams(stream_key_id, interrupted_at) WHERE status = 'interrupted';
+
+-- Continuous periods of publishing within a stream; gaps between them are
+-- reconnects.
+CREATE TABLE stream_segments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    stream_id UUID NOT NULL REFERENCES streams(id) ON DELETE CASCADE,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ended_at TIMESTAMPTZ,
+    source_type VARCHAR(50),
+    source_id VARCHAR(255)
+);
+
+CREATE INDEX idx_stream_segments_stream_id ON stream_segments(stream_id, started_at);
+
+-- Only the latest segment of a stream may be open
+CREATE UNIQUE INDEX idx_stream_segments_one_open_per_stream
+    ON stream_segments(stream_id)
+    WHERE ended_at IS NULL;
+
+-- Existing streams are a single segment
+INSERT INTO stream_segments (stream_id, started_at, ended_at, source_type, source_id)
+SELECT id, started_at, ended_at, source_type, source_id FROM streams;
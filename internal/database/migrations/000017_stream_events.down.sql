@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS stream_events;
+
+ALTER TABLE streams DROP COLUMN IF EXISTS end_reason;
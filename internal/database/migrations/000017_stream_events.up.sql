@@ -0,0 +1,16 @@
+-- Why a stream ended; NULL while it is active or interrupted, and for
+-- streams ended before reasons were recorded.
+ALTER TABLE streams ADD COLUMN end_reason VARCHAR(32)
+    CHECK (end_reason IN ('publisher_disconnected', 'reconnect_timeout', 'key_revoked', 'key_suspended', 'key_expired', 'duration_exceeded'));
+
+-- Lifecycle events of each stream, in the order they happened
+CREATE TABLE stream_events (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    stream_id UUID NOT NULL REFERENCES streams(id) ON DELETE CASCADE,
+    type VARCHAR(32) NOT NULL
+        CHECK (type IN ('ready', 'not_ready', 'reconnected', 'kicked', 'ended')),
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    details JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX idx_stream_events_stream_id ON stream_events(stream_id, id);
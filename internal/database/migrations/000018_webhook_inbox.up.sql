@@ -0,0 +1,24 @@
+-- MediaMTX ready/not-ready webhooks, persisted before they are applied to
+-- streams so a failure is retried instead of lost
+CREATE TABLE webhook_inbox (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    type VARCHAR(16) NOT NULL CHECK (type IN ('ready', 'not_ready')),
+    path VARCHAR(255) NOT NULL,
+    source_type VARCHAR(50),
+    source_id VARCHAR(255),
+    metadata JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processed', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at TIMESTAMPTZ
+);
+
+-- A redelivered webhook for the same publisher connection is a duplicate
+CREATE UNIQUE INDEX idx_webhook_inbox_delivery
+    ON webhook_inbox(type, path, source_id)
+    WHERE source_id IS NOT NULL;
+
+CREATE INDEX idx_webhook_inbox_pending ON webhook_inbox(path, id) WHERE status = 'pending';
+CREATE INDEX idx_webhook_inbox_status ON webhook_inbox(status, id);
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_streams_reserved_until;
+
+DELETE FROM streams WHERE status = 'pending';
+
+DROP INDEX IF EXISTS idx_streams_one_live_per_key;
+CREATE UNIQUE INDEX idx_streams_one_active_per_key
+    ON streams(stream_key_id)
+    WHERE status = 'active';
+
+ALTER TABLE streams DROP COLUMN IF EXISTS reserved_until;
+
+ALTER TABLE streams DROP CONSTRAINT streams_status_check;
+ALTER TABLE streams
+    ADD CONSTRAINT streams_status_check CHECK (status IN ('active', 'interrupted', 'ended'));
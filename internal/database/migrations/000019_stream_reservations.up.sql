@@ -0,0 +1,16 @@
+-- A publish approved at authentication reserves its key as a pending stream
+-- until the ready webhook activates it, or the reservation expires.
+ALTER TABLE streams DROP CONSTRAINT streams_status_check;
+ALTER TABLE streams
+    ADD CONSTRAINT streams_status_check CHECK (status IN ('pending', 'active', 'interrupted', 'ended'));
+
+ALTER TABLE streams ADD COLUMN reserved_until TIMESTAMPTZ;
+
+-- A key has at most one reservation or active stream
+DROP INDEX IF EXISTS idx_streams_one_active_per_key;
+CREATE UNIQUE INDEX idx_streams_one_live_per_key
+    ON streams(stream_key_id)
+    WHERE status IN ('pending', 'active');
+
+-- Supports the sweep of expired reservations
+CREATE INDEX idx_streams_reserved_until ON streams(reserved_until) WHERE status = 'pending';
@@ -0,0 +1,9 @@
+DELETE FROM stream_events WHERE type = 'health_changed';
+
+ALTER TABLE stream_events DROP CONSTRAINT stream_events_type_check;
+ALTER TABLE stream_events
+    ADD CONSTRAINT stream_events_type_check CHECK (type IN ('ready', 'not_ready', 'reconnected', 'kicked', 'ended'));
+
+ALTER TABLE stream_keys DROP COLUMN IF EXISTS min_bitrate_kbps;
+
+ALTER TABLE streams DROP COLUMN IF EXISTS health;
@@ -0,0 +1,12 @@
+-- The health of an active stream as last sampled from MediaMTX; NULL until
+-- the stream has been sampled twice.
+ALTER TABLE streams ADD COLUMN health VARCHAR(16)
+    CHECK (health IN ('healthy', 'degraded', 'stalled'));
+
+-- Bitrate below which the streams of a key are degraded, overriding the
+-- deployment-wide threshold
+ALTER TABLE stream_keys ADD COLUMN min_bitrate_kbps INTEGER CHECK (min_bitrate_kbps >= 0);
+
+ALTER TABLE stream_events DROP CONSTRAINT stream_events_type_check;
+ALTER TABLE stream_events
+    ADD CONSTRAINT stream_events_type_check CHECK (type IN ('ready', 'not_ready', 'reconnected', 'kicked', 'ended', 'health_changed'));
@@ -0,0 +1,9 @@
+DELETE FROM stream_events WHERE type = 'duration_warning';
+
+ALTER TABLE stream_events DROP CONSTRAINT stream_events_type_check;
+ALTER TABLE stream_events
+    ADD CONSTRAINT stream_events_type_check CHECK (type IN ('ready', 'not_ready', 'reconnected', 'kicked', 'ended', 'health_changed'));
+
+ALTER TABLE streams DROP COLUMN IF EXISTS duration_warned_at;
+
+ALTER TABLE stream_keys DROP COLUMN IF EXISTS max_stream_duration_seconds;
@@ -0,0 +1,9 @@
+-- Maximum duration of a single stream of a key, overriding its broadcaster's
+ALTER TABLE stream_keys ADD COLUMN max_stream_duration_seconds INTEGER CHECK (max_stream_duration_seconds >= 0);
+
+-- When a stream was warned that it is about to reach its maximum duration
+ALTER TABLE streams ADD COLUMN duration_warned_at TIMESTAMPTZ;
+
+ALTER TABLE stream_events DROP CONSTRAINT stream_events_type_check;
+ALTER TABLE stream_events
+    ADD CONSTRAINT stream_events_type_check CHECK (type IN ('ready', 'not_ready', 'reconnected', 'kicked', 'ended', 'health_changed', 'duration_warning'));
@@ -0,0 +1 @@
+ALTER TABLE group_jobs DROP COLUMN lease_expires_at;
@@ -0,0 +1,5 @@
+-- A running job whose lease ran out was abandoned and is claimed again
+ALTER TABLE group_jobs ADD COLUMN lease_expires_at TIMESTAMPTZ;
+
+-- Jobs left running before leases existed are reclaimed
+UPDATE group_jobs SET lease_expires_at = NOW() WHERE status = 'running';
@@ -0,0 +1,218 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// PostgreSQL error codes for constraint violations.
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// OrganizationRepo implements domain.OrganizationRepository using pgxpool.
+type OrganizationRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewOrganizationRepo creates a new OrganizationRepo.
+func NewOrganizationRepo(pool *pgxpool.Pool) *OrganizationRepo {
+	return &OrganizationRepo{pool: pool}
+}
+
+// Create creates a new organization.
+func (r *OrganizationRepo) Create(ctx context.Context, org *domain.Organization) error {
+	query := `
+		INSERT INTO organizations (id, slug, name, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	if org.ID == uuid.Nil {
+		org.ID = uuid.New()
+	}
+
+	_, err := r.pool.Exec(ctx, query, org.ID, org.Slug, org.Name, org.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an organization by ID.
+func (r *OrganizationRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Organization, error) {
+	query := `SELECT id, slug, name, created_at FROM organizations WHERE id = $1`
+
+	var org domain.Organization
+	err := r.pool.QueryRow(ctx, query, id).Scan(&org.ID, &org.Slug, &org.Name, &org.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan organization: %w", err)
+	}
+
+	return &org, nil
+}
+
+// List retrieves all organizations ordered by slug.
+func (r *OrganizationRepo) List(ctx context.Context) ([]domain.Organization, error) {
+	query := `SELECT id, slug, name, created_at FROM organizations ORDER BY slug`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	defer rows.Close()
+
+	var orgs []domain.Organization
+	for rows.Next() {
+		var org domain.Organization
+		if err := rows.Scan(&org.ID, &org.Slug, &org.Name, &org.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		orgs = append(orgs, org)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating organizations: %w", err)
+	}
+
+	return orgs, nil
+}
+
+// APIClientRepo implements domain.APIClientRepository using pgxpool.
+type APIClientRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewAPIClientRepo creates a new APIClientRepo.
+func NewAPIClientRepo(pool *pgxpool.Pool) *APIClientRepo {
+	return &APIClientRepo{pool: pool}
+}
+
+// Create creates a new API client.
+func (r *APIClientRepo) Create(ctx context.Context, client *domain.APIClient) error {
+	query := `
+		INSERT INTO api_clients (id, organization_id, name, api_key, secret, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	if client.ID == uuid.Nil {
+		client.ID = uuid.New()
+	}
+
+	_, err := r.pool.Exec(ctx, query,
+		client.ID,
+		client.OrganizationID,
+		client.Name,
+		client.APIKey,
+		client.Secret,
+		client.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create API client: %w", err)
+	}
+
+	return nil
+}
+
+// GetByAPIKey retrieves an API client, including its secret, by API key.
+func (r *APIClientRepo) GetByAPIKey(ctx context.Context, apiKey string) (*domain.APIClient, error) {
+	query := `
+		SELECT id, organization_id, name, api_key, secret, created_at, revoked_at
+		FROM api_clients
+		WHERE api_key = $1
+	`
+
+	var client domain.APIClient
+	err := r.pool.QueryRow(ctx, query, apiKey).Scan(
+		&client.ID,
+		&client.OrganizationID,
+		&client.Name,
+		&client.APIKey,
+		&client.Secret,
+		&client.CreatedAt,
+		&client.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan API client: %w", err)
+	}
+
+	return &client, nil
+}
+
+// ListByOrganization lists an organization's API clients without their secrets.
+func (r *APIClientRepo) ListByOrganization(ctx context.Context, organizationID uuid.UUID) ([]domain.APIClient, error) {
+	query := `
+		SELECT id, organization_id, name, api_key, created_at, revoked_at
+		FROM api_clients
+		WHERE organization_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.pool.Query(ctx, query, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API clients: %w", err)
+	}
+	defer rows.Close()
+
+	var clients []domain.APIClient
+	for rows.Next() {
+		var client domain.APIClient
+		if err := rows.Scan(
+			&client.ID,
+			&client.OrganizationID,
+			&client.Name,
+			&client.APIKey,
+			&client.CreatedAt,
+			&client.RevokedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan API client: %w", err)
+		}
+		clients = append(clients, client)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API clients: %w", err)
+	}
+
+	return clients, nil
+}
+
+// Revoke revokes an organization's API client. Revoking a revoked client
+// keeps the original revocation time.
+func (r *APIClientRepo) Revoke(ctx context.Context, organizationID, id uuid.UUID) error {
+	query := `
+		UPDATE api_clients
+		SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1 AND organization_id = $2
+	`
+
+	result, err := r.pool.Exec(ctx, query, id, organizationID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API client: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
@@ -5,25 +5,37 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
 )
 
+// streamColumns is the column list scanned by scanStream.
+const streamColumns = `id, stream_key_id, device_id, path, status, started_at, ended_at, source_type, source_id, metadata, recording_ref, title, notes, tags, organization_id, interrupted_at, end_reason, health`
+
 // StreamRepo implements domain.StreamRepository using pgxpool.
 type StreamRepo struct {
-	pool *pgxpool.Pool
+	db dbtx
 }
 
 // NewStreamRepo creates a new StreamRepo.
 func NewStreamRepo(pool *pgxpool.Pool) *StreamRepo {
-	return &StreamRepo{pool: pool}
+	return &StreamRepo{db: pool}
 }
 
-// Create creates a new stream.
+// Create creates a new stream in the organization of its stream key,
+// recording the device the key is bound to, with its first segment and ready
+// event. A pending reservation of the key by the same connection becomes the
+// stream; an active stream or another connection's reservation is
+// ErrStreamKeyInUse. The key is locked against status changes while the
+// stream is created, and a key that is no longer active is
+// ErrInvalidStreamKey.
 func (r *StreamRepo) Create(ctx context.Context, stream *domain.Stream) error {
 	metadataJSON, err := json.Marshal(stream.Metadata)
 	if err != nil {
@@ -31,86 +43,228 @@ func (r *StreamRepo) Create(ctx context.Context, stream *domain.Stream) error {
 	}
 
 	query := `
-		INSERT INTO streams (id, stream_key_id, path, status, started_at, source_type, source_id, metadata)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		WITH stream AS (
+			INSERT INTO streams (id, stream_key_id, path, status, started_at, source_type, source_id, metadata, title, notes, tags, organization_id, device_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11,
+				(SELECT organization_id FROM stream_keys WHERE id = $2),
+				(SELECT device_id FROM stream_keys WHERE id = $2))
+			ON CONFLICT (stream_key_id) WHERE status IN ('pending', 'active') DO UPDATE
+			SET id = EXCLUDED.id, path = EXCLUDED.path, status = EXCLUDED.status, started_at = EXCLUDED.started_at,
+				source_type = EXCLUDED.source_type, source_id = EXCLUDED.source_id, metadata = EXCLUDED.metadata,
+				title = EXCLUDED.title, notes = EXCLUDED.notes, tags = EXCLUDED.tags, reserved_until = NULL
+			WHERE streams.status = 'pending'
+				AND (streams.source_id IS NULL OR EXCLUDED.source_id IS NULL OR streams.source_id = EXCLUDED.source_id)
+			RETURNING id, started_at, source_type, source_id, organization_id, device_id
+		), segment AS (
+			INSERT INTO stream_segments (stream_id, started_at, source_type, source_id)
+			SELECT id, started_at, source_type, source_id FROM stream
+		), event AS (
+			INSERT INTO stream_events (stream_id, type, occurred_at, details)
+			SELECT id, 'ready', started_at, ` + streamSourceDetails + ` FROM stream
+		)
+		SELECT organization_id, device_id FROM stream
 	`
 
 	if stream.ID == uuid.Nil {
 		stream.ID = uuid.New()
 	}
 
-	_, err = r.pool.Exec(ctx, query,
-		stream.ID,
-		stream.StreamKeyID,
-		stream.Path,
-		stream.Status,
-		stream.StartedAt,
-		stream.SourceType,
-		stream.SourceID,
-		metadataJSON,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create stream: %w", err)
+	if stream.Tags == nil {
+		stream.Tags = []string{}
 	}
 
-	return nil
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var usable bool
+		err := tx.QueryRow(ctx, `
+			SELECT status = 'active' AND (expires_at IS NULL OR expires_at > NOW())
+			FROM stream_keys
+			WHERE id = $1
+			FOR SHARE`, stream.StreamKeyID).Scan(&usable)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrInvalidStreamKey
+			}
+			return fmt.Errorf("failed to check stream key: %w", err)
+		}
+		if !usable {
+			return domain.ErrInvalidStreamKey
+		}
+
+		err = tx.QueryRow(ctx, query,
+			stream.ID,
+			stream.StreamKeyID,
+			stream.Path,
+			stream.Status,
+			stream.StartedAt,
+			stream.SourceType,
+			stream.SourceID,
+			metadataJSON,
+			stream.Title,
+			stream.Notes,
+			stream.Tags,
+		).Scan(&stream.OrganizationID, &stream.DeviceID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrStreamKeyInUse
+			}
+			return fmt.Errorf("failed to create stream: %w", err)
+		}
+
+		return nil
+	})
 }
 
-// GetByID retrieves a stream by ID.
+// GetByID retrieves a stream by ID, including streams shared with the
+// organization ctx is scoped to. Reservations are not returned.
 func (r *StreamRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Stream, error) {
 	query := `
-		SELECT id, stream_key_id, path, status, started_at, ended_at, source_type, source_id, metadata, recording_ref
+		SELECT ` + streamColumns + `
 		FROM streams
-		WHERE id = $1
+		WHERE id = $1 AND status <> 'pending'
 	`
+	query, args := scopeStreamsToOrganization(ctx, query, id)
 
-	return r.scanStream(r.pool.QueryRow(ctx, query, id))
+	return r.scanStream(r.db.QueryRow(ctx, query, args...))
 }
 
 // GetActiveByPath retrieves an active stream by path.
 func (r *StreamRepo) GetActiveByPath(ctx context.Context, path string) (*domain.Stream, error) {
 	query := `
-		SELECT id, stream_key_id, path, status, started_at, ended_at, source_type, source_id, metadata, recording_ref
+		SELECT ` + streamColumns + `
 		FROM streams
 		WHERE path = $1 AND status = 'active'
 	`
 
-	return r.scanStream(r.pool.QueryRow(ctx, query, path))
+	return r.scanStream(r.db.QueryRow(ctx, query, path))
 }
 
-// GetActiveByStreamKeyID retrieves an active stream by stream key ID.
+// GetActiveByStreamKeyID retrieves the active or interrupted stream of a
+// key, preferring the active one.
 func (r *StreamRepo) GetActiveByStreamKeyID(ctx context.Context, keyID uuid.UUID) (*domain.Stream, error) {
 	query := `
-		SELECT id, stream_key_id, path, status, started_at, ended_at, source_type, source_id, metadata, recording_ref
+		SELECT ` + streamColumns + `
 		FROM streams
-		WHERE stream_key_id = $1 AND status = 'active'
+		WHERE stream_key_id = $1 AND status IN ('active', 'interrupted')
+		ORDER BY status = 'active' DESC, started_at DESC
+		LIMIT 1
 	`
 
-	return r.scanStream(r.pool.QueryRow(ctx, query, keyID))
+	return r.scanStream(r.db.QueryRow(ctx, query, keyID))
 }
 
-// ListActive retrieves all active streams.
-func (r *StreamRepo) ListActive(ctx context.Context) ([]domain.Stream, error) {
+// GetActiveByBroadcasterID retrieves the most recent active or interrupted
+// stream of a broadcaster.
+func (r *StreamRepo) GetActiveByBroadcasterID(ctx context.Context, broadcasterID uuid.UUID) (*domain.Stream, error) {
 	query := `
-		SELECT id, stream_key_id, path, status, started_at, ended_at, source_type, source_id, metadata, recording_ref
+		SELECT ` + streamColumns + `
 		FROM streams
-		WHERE status = 'active'
+		WHERE stream_key_id IN (SELECT id FROM stream_keys WHERE broadcaster_id = $1) AND status IN ('active', 'interrupted')
 		ORDER BY started_at DESC
+		LIMIT 1
+	`
+
+	return r.scanStream(r.db.QueryRow(ctx, query, broadcasterID))
+}
+
+// CountByBroadcasterSince counts the streams a broadcaster started since the given time.
+func (r *StreamRepo) CountByBroadcasterSince(ctx context.Context, broadcasterID uuid.UUID, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM streams
+		WHERE stream_key_id IN (SELECT id FROM stream_keys WHERE broadcaster_id = $1) AND started_at >= $2
+			AND status <> 'pending'
+	`
+
+	var count int
+	if err := r.db.QueryRow(ctx, query, broadcasterID, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count streams: %w", err)
+	}
+
+	return count, nil
+}
+
+// ListActive retrieves active and interrupted streams matching the filter,
+// including streams shared with the organization ctx is scoped to.
+func (r *StreamRepo) ListActive(ctx context.Context, filter domain.StreamFilter) ([]domain.Stream, error) {
+	query := `
+		SELECT ` + streamColumns + `
+		FROM streams
+		WHERE status IN ('active', 'interrupted')
+	`
+	query, args := scopeStreamsToOrganization(ctx, query)
+
+	if len(filter.Tags) > 0 {
+		args = append(args, filter.Tags)
+		query += fmt.Sprintf(" AND tags @> $%d", len(args))
+	}
+
+	if filter.GroupID != nil {
+		args = append(args, *filter.GroupID)
+		query += fmt.Sprintf(` AND stream_key_id IN (
+			SELECT k.id FROM stream_keys k
+			JOIN broadcaster_group_members m ON m.broadcaster_id = k.broadcaster_id
+			WHERE m.group_id = $%d
+		)`, len(args))
+	}
+
+	if filter.DeviceID != nil {
+		args = append(args, *filter.DeviceID)
+		query += fmt.Sprintf(" AND device_id = $%d", len(args))
+	}
+
+	if filter.DeviceType != "" {
+		args = append(args, filter.DeviceType)
+		query += fmt.Sprintf(" AND device_id IN (SELECT id FROM devices WHERE type = $%d)", len(args))
+	}
+
+	query += " ORDER BY started_at DESC"
+
+	return r.queryStreams(ctx, query, args...)
+}
+
+// CountActiveByBroadcasterID counts a broadcaster's active streams.
+func (r *StreamRepo) CountActiveByBroadcasterID(ctx context.Context, broadcasterID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM streams
+		WHERE stream_key_id IN (SELECT id FROM stream_keys WHERE broadcaster_id = $1) AND status = 'active'
 	`
 
-	rows, err := r.pool.Query(ctx, query)
+	var count int
+	if err := r.db.QueryRow(ctx, query, broadcasterID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count active streams: %w", err)
+	}
+
+	return count, nil
+}
+
+// ListMonitored lists active streams with the minimum bitrate of their keys.
+func (r *StreamRepo) ListMonitored(ctx context.Context) ([]domain.MonitoredStream, error) {
+	query := `
+		SELECT ` + streamColumns + `, keys.min_bitrate_kbps
+		FROM streams
+		JOIN (SELECT id AS stream_key_id, min_bitrate_kbps FROM stream_keys) keys USING (stream_key_id)
+		WHERE status = 'active'
+		ORDER BY started_at
+	`
+
+	rows, err := r.db.Query(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list active streams: %w", err)
+		return nil, fmt.Errorf("failed to list monitored streams: %w", err)
 	}
 	defer rows.Close()
 
-	var streams []domain.Stream
+	var streams []domain.MonitoredStream
 	for rows.Next() {
-		stream, err := r.scanStreamFromRows(rows)
-		if err != nil {
-			return nil, err
+		var monitored domain.MonitoredStream
+		var metadataJSON []byte
+		if err := rows.Scan(append(streamFields(&monitored.Stream, &metadataJSON), &monitored.MinBitrateKbps)...); err != nil {
+			return nil, fmt.Errorf("failed to scan stream: %w", err)
 		}
-		streams = append(streams, *stream)
+		if err := json.Unmarshal(metadataJSON, &monitored.Stream.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+		streams = append(streams, monitored)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -120,17 +274,143 @@ func (r *StreamRepo) ListActive(ctx context.Context) ([]domain.Stream, error) {
 	return streams, nil
 }
 
-// EndStream ends a stream by ID.
-func (r *StreamRepo) EndStream(ctx context.Context, id uuid.UUID) error {
+// streamDurationLimits selects the maximum stream duration in seconds of each
+// key: its own, else its broadcaster's, else the default in $1.
+const streamDurationLimits = `
+	SELECT k.id AS stream_key_id, COALESCE(k.max_stream_duration_seconds, b.max_stream_duration_seconds, $1) AS max_seconds
+	FROM stream_keys k
+	JOIN broadcasters b ON b.id = k.broadcaster_id
+`
+
+// ListActiveOverDuration lists active streams running longer than their
+// maximum stream duration.
+func (r *StreamRepo) ListActiveOverDuration(ctx context.Context, defaultMax time.Duration) ([]domain.Stream, error) {
+	query := `
+		SELECT ` + streamColumns + `
+		FROM streams
+		JOIN (` + streamDurationLimits + `) limits USING (stream_key_id)
+		WHERE status = 'active'
+			AND limits.max_seconds > 0
+			AND started_at <= NOW() - make_interval(secs => limits.max_seconds)
+		ORDER BY started_at
+	`
+
+	return r.queryStreams(ctx, query, int(defaultMax.Seconds()))
+}
+
+// ListApproachingDuration lists the active streams reaching their maximum
+// duration within lead that have not been warned yet, soonest first.
+func (r *StreamRepo) ListApproachingDuration(ctx context.Context, defaultMax, lead time.Duration) ([]domain.StreamDurationWarning, error) {
+	query := `
+		SELECT ` + streamColumns + `, started_at + make_interval(secs => limits.max_seconds) AS ends_at
+		FROM streams
+		JOIN (` + streamDurationLimits + `) limits USING (stream_key_id)
+		WHERE status = 'active' AND duration_warned_at IS NULL
+			AND limits.max_seconds > 0
+			AND started_at + make_interval(secs => limits.max_seconds) > NOW()
+			AND started_at + make_interval(secs => limits.max_seconds) <= NOW() + make_interval(secs => $2)
+		ORDER BY ends_at
+	`
+
+	rows, err := r.db.Query(ctx, query, int(defaultMax.Seconds()), lead.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list streams approaching their duration: %w", err)
+	}
+	defer rows.Close()
+
+	var warnings []domain.StreamDurationWarning
+	for rows.Next() {
+		var warning domain.StreamDurationWarning
+		var metadataJSON []byte
+		if err := rows.Scan(append(streamFields(&warning.Stream, &metadataJSON), &warning.EndsAt)...); err != nil {
+			return nil, fmt.Errorf("failed to scan stream: %w", err)
+		}
+		if err := json.Unmarshal(metadataJSON, &warning.Stream.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+		warnings = append(warnings, warning)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating streams: %w", err)
+	}
+
+	return warnings, nil
+}
+
+// MarkDurationWarned records that an active stream was warned it ends at
+// endsAt, recording a duration warning event.
+func (r *StreamRepo) MarkDurationWarned(ctx context.Context, id uuid.UUID, endsAt time.Time) error {
+	query := `
+		WITH warned AS (
+			UPDATE streams
+			SET duration_warned_at = NOW()
+			WHERE id = $1 AND status = 'active' AND duration_warned_at IS NULL
+			RETURNING id, duration_warned_at
+		), event AS (
+			INSERT INTO stream_events (stream_id, type, occurred_at, details)
+			SELECT id, 'duration_warning', duration_warned_at, jsonb_build_object('ends_at', $2::timestamptz) FROM warned
+		)
+		SELECT COUNT(*) FROM warned
+	`
+
+	var count int
+	if err := r.db.QueryRow(ctx, query, id, endsAt).Scan(&count); err != nil {
+		return fmt.Errorf("failed to mark stream duration warned: %w", err)
+	}
+
+	if count == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// ClearDurationWarning forgets that the live stream of a key was warned about
+// its maximum duration.
+func (r *StreamRepo) ClearDurationWarning(ctx context.Context, keyID uuid.UUID) error {
 	query := `
 		UPDATE streams
-		SET status = 'ended', ended_at = NOW()
-		WHERE id = $1 AND status = 'active'
+		SET duration_warned_at = NULL
+		WHERE stream_key_id = $1 AND status IN ('active', 'interrupted')
 	`
 
-	result, err := r.pool.Exec(ctx, query, id)
+	if _, err := r.db.Exec(ctx, query, keyID); err != nil {
+		return fmt.Errorf("failed to clear stream duration warning: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateLabels updates the operator-editable title, notes, tags and metadata of a stream.
+func (r *StreamRepo) UpdateLabels(ctx context.Context, stream *domain.Stream) error {
+	metadataJSON, err := json.Marshal(stream.Metadata)
 	if err != nil {
-		return fmt.Errorf("failed to end stream: %w", err)
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	tags := stream.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	query := `
+		UPDATE streams
+		SET title = $2, notes = $3, tags = $4, metadata = $5
+		WHERE id = $1
+	`
+	// Shared streams are read-only
+	query, args := scopeToOrganization(ctx, query, "organization_id",
+		stream.ID,
+		stream.Title,
+		stream.Notes,
+		tags,
+		metadataJSON,
+	)
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update stream labels: %w", err)
 	}
 
 	if result.RowsAffected() == 0 {
@@ -140,17 +420,430 @@ func (r *StreamRepo) EndStream(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-// EndStreamByPath ends a stream by path.
-func (r *StreamRepo) EndStreamByPath(ctx context.Context, path string) error {
-	query := `
+// UpdateHealth sets the sampled health of an active stream.
+func (r *StreamRepo) UpdateHealth(ctx context.Context, id uuid.UUID, health domain.StreamHealth) error {
+	query := `UPDATE streams SET health = $2 WHERE id = $1 AND status = 'active'`
+
+	result, err := r.db.Exec(ctx, query, id, health)
+	if err != nil {
+		return fmt.Errorf("failed to update stream health: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// EndStream ends an active or interrupted stream by ID. An interrupted
+// stream ends as of when it was interrupted.
+func (r *StreamRepo) EndStream(ctx context.Context, id uuid.UUID, reason domain.StreamEndReason) error {
+	where, args := scopeToOrganization(ctx, "WHERE id = $1 AND status IN ('active', 'interrupted')", "organization_id", id, reason)
+	query := streamTransition(`
+		UPDATE streams
+		SET status = 'ended', ended_at = COALESCE(interrupted_at, NOW()), end_reason = $2, health = NULL
+		`+where+`
+		RETURNING id, ended_at AS at, end_reason
+	`, domain.StreamEventEnded)
+
+	var count int
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&count); err != nil {
+		return fmt.Errorf("failed to end stream: %w", err)
+	}
+
+	if count == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// EndStreamByPath ends, as of the given time, the active stream on a path
+// whose publisher disconnected.
+func (r *StreamRepo) EndStreamByPath(ctx context.Context, path string, reason domain.StreamEndReason, at time.Time) error {
+	query := streamTransition(`
+		UPDATE streams
+		SET status = 'ended', ended_at = $3, end_reason = $2, health = NULL
+		WHERE path = $1 AND status = 'active'
+		RETURNING id, ended_at AS at, end_reason
+	`, domain.StreamEventNotReady, domain.StreamEventEnded)
+
+	var count int
+	if err := r.db.QueryRow(ctx, query, path, reason, at).Scan(&count); err != nil {
+		return fmt.Errorf("failed to end stream by path: %w", err)
+	}
+
+	if count == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// InterruptStreamByPath marks the active stream on a path as interrupted at
+// the given time, closing its current segment.
+func (r *StreamRepo) InterruptStreamByPath(ctx context.Context, path string, at time.Time) error {
+	query := streamTransition(`
 		UPDATE streams
-		SET status = 'ended', ended_at = NOW()
+		SET status = 'interrupted', interrupted_at = $2, health = NULL
 		WHERE path = $1 AND status = 'active'
+		RETURNING id, interrupted_at AS at, end_reason
+	`, domain.StreamEventNotReady)
+
+	var count int
+	if err := r.db.QueryRow(ctx, query, path, at).Scan(&count); err != nil {
+		return fmt.Errorf("failed to interrupt stream by path: %w", err)
+	}
+
+	if count == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// ResumeInterrupted resumes the most recent stream of stream.StreamKeyID
+// interrupted at or after since, opening a new segment at stream.StartedAt
+// with the path and source of stream and releasing the key's reservation. On
+// success stream is replaced by the resumed stream.
+func (r *StreamRepo) ResumeInterrupted(ctx context.Context, stream *domain.Stream, since time.Time) error {
+	query := `
+		WITH resumed AS (
+			UPDATE streams
+			SET status = 'active', interrupted_at = NULL, health = NULL, path = $2, source_type = $3, source_id = $4
+			WHERE id = (
+				SELECT id FROM streams
+				WHERE stream_key_id = $1 AND status = 'interrupted' AND interrupted_at >= $5
+				ORDER BY interrupted_at DESC
+				LIMIT 1
+			) AND status = 'interrupted'
+			RETURNING ` + streamColumns + `
+		), segment AS (
+			INSERT INTO stream_segments (stream_id, started_at, source_type, source_id)
+			SELECT id, $6, source_type, source_id FROM resumed
+		), event AS (
+			INSERT INTO stream_events (stream_id, type, occurred_at, details)
+			SELECT id, 'reconnected', $6, ` + streamSourceDetails + ` FROM resumed
+		)
+		SELECT ` + streamColumns + ` FROM resumed
+	`
+
+	// The reservation is released first, as the key may not have both a
+	// reservation and an active stream; it is kept if nothing is resumed
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "DELETE FROM streams WHERE stream_key_id = $1 AND status = 'pending'", stream.StreamKeyID); err != nil {
+			return fmt.Errorf("failed to release stream reservation: %w", err)
+		}
+
+		resumed, err := r.scanStream(tx.QueryRow(ctx, query,
+			stream.StreamKeyID,
+			stream.Path,
+			stream.SourceType,
+			stream.SourceID,
+			since,
+			stream.StartedAt,
+		))
+		if err != nil {
+			return err
+		}
+
+		*stream = *resumed
+		return nil
+	})
+}
+
+// EndInterruptedBefore ends the streams interrupted before the given time, as
+// of when they were interrupted. Their segments were closed on interruption.
+func (r *StreamRepo) EndInterruptedBefore(ctx context.Context, before time.Time) ([]domain.Stream, error) {
+	query := `
+		WITH ended AS (
+			UPDATE streams
+			SET status = 'ended', ended_at = interrupted_at, end_reason = $2, health = NULL
+			WHERE status = 'interrupted' AND interrupted_at < $1
+			RETURNING ` + streamColumns + `
+		), event AS (
+			INSERT INTO stream_events (stream_id, type, occurred_at, details)
+			SELECT id, 'ended', ended_at, jsonb_build_object('reason', end_reason) FROM ended
+		)
+		SELECT ` + streamColumns + ` FROM ended
+	`
+
+	return r.queryStreams(ctx, query, before, domain.StreamEndReconnectTimeout)
+}
+
+// Reserve reserves a key for a connection with a pending stream on path
+// until ttl passes, replacing an expired reservation or renewing the
+// connection's own. It returns ErrStreamKeyInUse if the key has an active
+// stream.
+func (r *StreamRepo) Reserve(ctx context.Context, keyID uuid.UUID, path, connectionID string, ttl time.Duration) error {
+	query := `
+		INSERT INTO streams (stream_key_id, path, status, source_id, reserved_until, organization_id, device_id)
+		SELECT id, $2, 'pending', NULLIF($3, ''), NOW() + make_interval(secs => $4), organization_id, device_id
+		FROM stream_keys
+		WHERE id = $1
+		ON CONFLICT (stream_key_id) WHERE status IN ('pending', 'active') DO UPDATE
+		SET path = EXCLUDED.path, source_id = EXCLUDED.source_id, started_at = NOW(), reserved_until = EXCLUDED.reserved_until
+		WHERE streams.status = 'pending'
 	`
 
-	result, err := r.pool.Exec(ctx, query, path)
+	result, err := r.db.Exec(ctx, query, keyID, path, connectionID, ttl.Seconds())
 	if err != nil {
-		return fmt.Errorf("failed to end stream by path: %w", err)
+		return fmt.Errorf("failed to reserve stream key: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrStreamKeyInUse
+	}
+
+	return nil
+}
+
+// GetLiveByStreamKeyID retrieves the active stream or unexpired reservation
+// of a key.
+func (r *StreamRepo) GetLiveByStreamKeyID(ctx context.Context, keyID uuid.UUID) (*domain.Stream, error) {
+	query := `
+		SELECT ` + streamColumns + `
+		FROM streams
+		WHERE stream_key_id = $1
+			AND (status = 'active' OR (status = 'pending' AND reserved_until > NOW()))
+	`
+
+	return r.scanStream(r.db.QueryRow(ctx, query, keyID))
+}
+
+// CountLiveByBroadcasterID counts the active streams and unexpired
+// reservations of a broadcaster's keys other than exceptKeyID.
+func (r *StreamRepo) CountLiveByBroadcasterID(ctx context.Context, broadcasterID, exceptKeyID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM streams
+		WHERE stream_key_id IN (SELECT id FROM stream_keys WHERE broadcaster_id = $1 AND id <> $2)
+			AND (status = 'active' OR (status = 'pending' AND reserved_until > NOW()))
+	`
+
+	var count int
+	if err := r.db.QueryRow(ctx, query, broadcasterID, exceptKeyID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count live streams: %w", err)
+	}
+
+	return count, nil
+}
+
+// IsPathLive checks if a key other than exceptKeyID has an active stream or
+// unexpired reservation on path.
+func (r *StreamRepo) IsPathLive(ctx context.Context, path string, exceptKeyID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM streams
+			WHERE path = $1 AND stream_key_id <> $2
+				AND (status = 'active' OR (status = 'pending' AND reserved_until > NOW()))
+		)
+	`
+
+	var live bool
+	if err := r.db.QueryRow(ctx, query, path, exceptKeyID).Scan(&live); err != nil {
+		return false, fmt.Errorf("failed to check path: %w", err)
+	}
+
+	return live, nil
+}
+
+// DeleteReservation deletes the pending reservation of a key, if any.
+func (r *StreamRepo) DeleteReservation(ctx context.Context, keyID uuid.UUID) error {
+	query := `DELETE FROM streams WHERE stream_key_id = $1 AND status = 'pending'`
+
+	if _, err := r.db.Exec(ctx, query, keyID); err != nil {
+		return fmt.Errorf("failed to delete stream reservation: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredReservations deletes the pending reservations that expired
+// before the given time without being activated.
+func (r *StreamRepo) DeleteExpiredReservations(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM streams WHERE status = 'pending' AND reserved_until < $1`
+
+	result, err := r.db.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired stream reservations: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// RecordEvent records a lifecycle event of a stream.
+func (r *StreamRepo) RecordEvent(ctx context.Context, event *domain.StreamEvent) error {
+	query := `
+		INSERT INTO stream_events (stream_id, type, occurred_at, details)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	if event.Details == nil {
+		event.Details = make(map[string]interface{})
+	}
+
+	detailsJSON, err := json.Marshal(event.Details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal stream event details: %w", err)
+	}
+
+	if err := r.db.QueryRow(ctx, query, event.StreamID, event.Type, event.OccurredAt, detailsJSON).Scan(&event.ID); err != nil {
+		return fmt.Errorf("failed to record stream event: %w", err)
+	}
+
+	return nil
+}
+
+// ListEvents lists the lifecycle events of a stream in the order they were
+// recorded.
+func (r *StreamRepo) ListEvents(ctx context.Context, streamID uuid.UUID) ([]domain.StreamEvent, error) {
+	query := `
+		SELECT id, stream_id, type, occurred_at, details
+		FROM stream_events
+		WHERE stream_id = $1
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(ctx, query, streamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stream events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []domain.StreamEvent
+	for rows.Next() {
+		var event domain.StreamEvent
+		var detailsJSON []byte
+		if err := rows.Scan(
+			&event.ID,
+			&event.StreamID,
+			&event.Type,
+			&event.OccurredAt,
+			&detailsJSON,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan stream event: %w", err)
+		}
+		if err := json.Unmarshal(detailsJSON, &event.Details); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal stream event details: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stream events: %w", err)
+	}
+
+	return events, nil
+}
+
+// ListSegments lists the segments of a stream in order.
+func (r *StreamRepo) ListSegments(ctx context.Context, streamID uuid.UUID) ([]domain.StreamSegment, error) {
+	query := `
+		SELECT id, stream_id, started_at, ended_at, source_type, source_id
+		FROM stream_segments
+		WHERE stream_id = $1
+		ORDER BY started_at
+	`
+
+	rows, err := r.db.Query(ctx, query, streamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stream segments: %w", err)
+	}
+	defer rows.Close()
+
+	var segments []domain.StreamSegment
+	for rows.Next() {
+		var segment domain.StreamSegment
+		if err := rows.Scan(
+			&segment.ID,
+			&segment.StreamID,
+			&segment.StartedAt,
+			&segment.EndedAt,
+			&segment.SourceType,
+			&segment.SourceID,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan stream segment: %w", err)
+		}
+		segments = append(segments, segment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stream segments: %w", err)
+	}
+
+	return segments, nil
+}
+
+// streamSourceDetails is the details of ready and reconnected events: the
+// publisher's source, read from a row with source_type and source_id.
+const streamSourceDetails = `jsonb_strip_nulls(jsonb_build_object('source_type', source_type, 'source_id', source_id))`
+
+// streamTransition wraps an UPDATE of streams returning id, at and end_reason
+// so that, in the same statement, the open segments of the updated streams
+// are closed at that time and the given lifecycle events are recorded for
+// them in order. The query returns the number of streams updated.
+func streamTransition(update string, events ...domain.StreamEventType) string {
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = "'" + string(event) + "'"
+	}
+
+	return `
+		WITH updated AS (` + update + `),
+		closed AS (
+			UPDATE stream_segments s
+			SET ended_at = u.at
+			FROM updated u
+			WHERE s.stream_id = u.id AND s.ended_at IS NULL
+		),
+		events AS (
+			INSERT INTO stream_events (stream_id, type, occurred_at, details)
+			SELECT u.id, e.type, u.at,
+				CASE WHEN e.type = 'ended' THEN jsonb_build_object('reason', u.end_reason) ELSE '{}' END
+			FROM updated u
+			CROSS JOIN unnest(ARRAY[` + strings.Join(types, ", ") + `]) WITH ORDINALITY AS e(type, ord)
+			ORDER BY u.id, e.ord
+		)
+		SELECT COUNT(*) FROM updated
+	`
+}
+
+// Share grants an organization read access to a stream.
+func (r *StreamRepo) Share(ctx context.Context, streamID, organizationID uuid.UUID) (*domain.StreamShare, error) {
+	query := `
+		INSERT INTO stream_shares (stream_id, organization_id)
+		VALUES ($1, $2)
+		ON CONFLICT (stream_id, organization_id) DO UPDATE SET stream_id = EXCLUDED.stream_id
+		RETURNING stream_id, organization_id, created_at
+	`
+
+	var share domain.StreamShare
+	err := r.db.QueryRow(ctx, query, streamID, organizationID).Scan(
+		&share.StreamID,
+		&share.OrganizationID,
+		&share.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to share stream: %w", err)
+	}
+
+	return &share, nil
+}
+
+// Unshare revokes an organization's access to a stream.
+func (r *StreamRepo) Unshare(ctx context.Context, streamID, organizationID uuid.UUID) error {
+	query := `DELETE FROM stream_shares WHERE stream_id = $1 AND organization_id = $2`
+
+	result, err := r.db.Exec(ctx, query, streamID, organizationID)
+	if err != nil {
+		return fmt.Errorf("failed to unshare stream: %w", err)
 	}
 
 	if result.RowsAffected() == 0 {
@@ -160,23 +853,57 @@ func (r *StreamRepo) EndStreamByPath(ctx context.Context, path string) error {
 	return nil
 }
 
+// ListShares lists the organizations a stream is shared with.
+func (r *StreamRepo) ListShares(ctx context.Context, streamID uuid.UUID) ([]domain.StreamShare, error) {
+	query := `
+		SELECT stream_id, organization_id, created_at
+		FROM stream_shares
+		WHERE stream_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, streamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stream shares: %w", err)
+	}
+	defer rows.Close()
+
+	var shares []domain.StreamShare
+	for rows.Next() {
+		var share domain.StreamShare
+		if err := rows.Scan(&share.StreamID, &share.OrganizationID, &share.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan stream share: %w", err)
+		}
+		shares = append(shares, share)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stream shares: %w", err)
+	}
+
+	return shares, nil
+}
+
+// scopeStreamsToOrganization is scopeToOrganization for stream reads, which
+// also match streams shared with the organization.
+func scopeStreamsToOrganization(ctx context.Context, query string, args ...interface{}) (string, []interface{}) {
+	orgID, ok := domain.OrganizationFromContext(ctx)
+	if !ok {
+		return query, args
+	}
+	args = append(args, orgID)
+	n := len(args)
+	return fmt.Sprintf(
+		"%s AND (organization_id = $%d OR id IN (SELECT stream_id FROM stream_shares WHERE organization_id = $%d))",
+		query, n, n,
+	), args
+}
+
 func (r *StreamRepo) scanStream(row pgx.Row) (*domain.Stream, error) {
 	var stream domain.Stream
 	var metadataJSON []byte
 
-	err := row.Scan(
-		&stream.ID,
-		&stream.StreamKeyID,
-		&stream.Path,
-		&stream.Status,
-		&stream.StartedAt,
-		&stream.EndedAt,
-		&stream.SourceType,
-		&stream.SourceID,
-		&metadataJSON,
-		&stream.RecordingRef,
-	)
-	if err != nil {
+	if err := row.Scan(streamFields(&stream, &metadataJSON)...); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, domain.ErrNotFound
 		}
@@ -190,23 +917,59 @@ func (r *StreamRepo) scanStream(row pgx.Row) (*domain.Stream, error) {
 	return &stream, nil
 }
 
-func (r *StreamRepo) scanStreamFromRows(rows pgx.Rows) (*domain.Stream, error) {
-	var stream domain.Stream
-	var metadataJSON []byte
-
-	err := rows.Scan(
+// streamFields returns the scan destinations for streamColumns. The metadata
+// is scanned as JSON into metadataJSON.
+func streamFields(stream *domain.Stream, metadataJSON *[]byte) []interface{} {
+	return []interface{}{
 		&stream.ID,
 		&stream.StreamKeyID,
+		&stream.DeviceID,
 		&stream.Path,
 		&stream.Status,
 		&stream.StartedAt,
 		&stream.EndedAt,
 		&stream.SourceType,
 		&stream.SourceID,
-		&metadataJSON,
+		metadataJSON,
 		&stream.RecordingRef,
-	)
+		&stream.Title,
+		&stream.Notes,
+		&stream.Tags,
+		&stream.OrganizationID,
+		&stream.InterruptedAt,
+		&stream.EndReason,
+		&stream.Health,
+	}
+}
+
+func (r *StreamRepo) queryStreams(ctx context.Context, query string, args ...interface{}) ([]domain.Stream, error) {
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
+		return nil, fmt.Errorf("failed to query streams: %w", err)
+	}
+	defer rows.Close()
+
+	var streams []domain.Stream
+	for rows.Next() {
+		stream, err := r.scanStreamFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		streams = append(streams, *stream)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating streams: %w", err)
+	}
+
+	return streams, nil
+}
+
+func (r *StreamRepo) scanStreamFromRows(rows pgx.Rows) (*domain.Stream, error) {
+	var stream domain.Stream
+	var metadataJSON []byte
+
+	if err := rows.Scan(streamFields(&stream, &metadataJSON)...); err != nil {
 		return nil, fmt.Errorf("failed to scan stream: %w", err)
 	}
 
@@ -0,0 +1,174 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// StreamKeyBatchRepo implements domain.StreamKeyBatchRepository using pgxpool.
+type StreamKeyBatchRepo struct {
+	db dbtx
+}
+
+// NewStreamKeyBatchRepo creates a new StreamKeyBatchRepo.
+func NewStreamKeyBatchRepo(pool *pgxpool.Pool) *StreamKeyBatchRepo {
+	return &StreamKeyBatchRepo{db: pool}
+}
+
+// Create atomically creates a batch, any new broadcasters and the batch's keys.
+func (r *StreamKeyBatchRepo) Create(ctx context.Context, batch *domain.StreamKeyBatch, broadcasters []domain.Broadcaster, keys []domain.StreamKey) error {
+	if batch.ID == uuid.Nil {
+		batch.ID = uuid.New()
+	}
+
+	batch.OrganizationID = organizationForInsert(ctx, batch.OrganizationID)
+
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			"INSERT INTO stream_key_batches (id, organization_id, label, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)",
+			batch.ID, batch.OrganizationID, batch.Label, batch.ExpiresAt, batch.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create stream key batch: %w", err)
+		}
+
+		for i := range broadcasters {
+			if err := insertBroadcaster(ctx, tx, &broadcasters[i]); err != nil {
+				return err
+			}
+		}
+
+		for i := range keys {
+			keys[i].BatchID = &batch.ID
+			if err := insertStreamKey(ctx, tx, &keys[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetByID retrieves a stream key batch by ID.
+func (r *StreamKeyBatchRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.StreamKeyBatch, error) {
+	query := `
+		SELECT id, organization_id, label, expires_at, created_at, exported_at
+		FROM stream_key_batches
+		WHERE id = $1
+	`
+	query, args := scopeToOrganization(ctx, query, "organization_id", id)
+
+	var batch domain.StreamKeyBatch
+	err := r.db.QueryRow(ctx, query, args...).Scan(
+		&batch.ID,
+		&batch.OrganizationID,
+		&batch.Label,
+		&batch.ExpiresAt,
+		&batch.CreatedAt,
+		&batch.ExportedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan stream key batch: %w", err)
+	}
+
+	return &batch, nil
+}
+
+// ListEntries lists the keys of a batch without their key values.
+func (r *StreamKeyBatchRepo) ListEntries(ctx context.Context, id uuid.UUID) ([]domain.StreamKeyBatchEntry, error) {
+	entries, err := queryBatchEntries(ctx, r.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entries[i].KeyValue = ""
+	}
+
+	return entries, nil
+}
+
+// ClaimExport marks the batch exported and returns its keys with their key
+// values. The batch of a group job cannot be exported until the job has
+// issued all of its keys.
+func (r *StreamKeyBatchRepo) ClaimExport(ctx context.Context, id uuid.UUID) ([]domain.StreamKeyBatchEntry, error) {
+	var entries []domain.StreamKeyBatchEntry
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		query, args := scopeToOrganization(ctx, `
+			UPDATE stream_key_batches SET exported_at = NOW()
+			WHERE id = $1 AND exported_at IS NULL
+				AND NOT EXISTS (
+					SELECT 1 FROM group_jobs j
+					WHERE j.batch_id = stream_key_batches.id AND j.status <> 'completed'
+				)`, "organization_id", id)
+
+		result, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to claim stream key batch export: %w", err)
+		}
+
+		if result.RowsAffected() == 0 {
+			query, args := scopeToOrganization(ctx, "SELECT 1 FROM stream_key_batches WHERE id = $1", "organization_id", id)
+
+			var exists bool
+			if err := tx.QueryRow(ctx, "SELECT EXISTS ("+query+")", args...).Scan(&exists); err != nil {
+				return fmt.Errorf("failed to check stream key batch: %w", err)
+			}
+			if !exists {
+				return domain.ErrNotFound
+			}
+			return domain.ErrExportUnavailable
+		}
+
+		entries, err = queryBatchEntries(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func queryBatchEntries(ctx context.Context, q querier, batchID uuid.UUID) ([]domain.StreamKeyBatchEntry, error) {
+	query := `
+		SELECT ` + streamKeyColumns + `, display_name
+		FROM stream_keys
+		CROSS JOIN LATERAL (
+			SELECT display_name FROM broadcasters WHERE broadcasters.id = stream_keys.broadcaster_id
+		) b
+		WHERE batch_id = $1
+	`
+	query, args := scopeToOrganization(ctx, query, "organization_id", batchID)
+
+	rows, err := q.Query(ctx, query+" ORDER BY display_name, id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stream key batch: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []domain.StreamKeyBatchEntry
+	for rows.Next() {
+		var entry domain.StreamKeyBatchEntry
+		if err := rows.Scan(append(streamKeyFields(&entry.StreamKey), &entry.DisplayName)...); err != nil {
+			return nil, fmt.Errorf("failed to scan stream key: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stream keys: %w", err)
+	}
+
+	return entries, nil
+}
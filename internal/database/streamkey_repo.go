@@ -8,41 +8,58 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
 )
 
+// streamKeyColumns is the column list scanned by scanStreamKey.
+const streamKeyColumns = `id, key_value, broadcaster_id, batch_id, device_id, label, description, status, created_at, expires_at, suspended_at, revoked_at, last_used_at, publish_path, organization_id, min_bitrate_kbps, max_stream_duration_seconds`
+
 // StreamKeyRepo implements domain.StreamKeyRepository using pgxpool.
 type StreamKeyRepo struct {
-	pool *pgxpool.Pool
+	db dbtx
 }
 
 // NewStreamKeyRepo creates a new StreamKeyRepo.
 func NewStreamKeyRepo(pool *pgxpool.Pool) *StreamKeyRepo {
-	return &StreamKeyRepo{pool: pool}
+	return &StreamKeyRepo{db: pool}
 }
 
 // Create creates a new stream key.
 func (r *StreamKeyRepo) Create(ctx context.Context, key *domain.StreamKey) error {
+	return insertStreamKey(ctx, r.db, key)
+}
+
+// insertStreamKey creates a stream key in the organization of its broadcaster.
+func insertStreamKey(ctx context.Context, q querier, key *domain.StreamKey) error {
 	query := `
-		INSERT INTO stream_keys (id, key_value, broadcaster_id, status, created_at, expires_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO stream_keys (id, key_value, broadcaster_id, batch_id, device_id, label, description, status, created_at, expires_at, organization_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, (SELECT organization_id FROM broadcasters WHERE id = $3))
+		RETURNING organization_id
 	`
 
 	if key.ID == uuid.Nil {
 		key.ID = uuid.New()
 	}
 
-	_, err := r.pool.Exec(ctx, query,
+	err := q.QueryRow(ctx, query,
 		key.ID,
 		key.KeyValue,
 		key.BroadcasterID,
+		key.BatchID,
+		key.DeviceID,
+		key.Label,
+		key.Description,
 		key.Status,
 		key.CreatedAt,
 		key.ExpiresAt,
-	)
+	).Scan(&key.OrganizationID)
 	if err != nil {
+		if isDeviceViolation(err) {
+			return domain.ErrInvalidDevice
+		}
 		return fmt.Errorf("failed to create stream key: %w", err)
 	}
 
@@ -52,59 +69,115 @@ func (r *StreamKeyRepo) Create(ctx context.Context, key *domain.StreamKey) error
 // GetByID retrieves a stream key by ID.
 func (r *StreamKeyRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.StreamKey, error) {
 	query := `
-		SELECT id, key_value, broadcaster_id, status, created_at, expires_at, revoked_at, last_used_at
+		SELECT ` + streamKeyColumns + `
 		FROM stream_keys
 		WHERE id = $1
 	`
+	query, args := scopeToOrganization(ctx, query, "organization_id", id)
 
-	return r.scanStreamKey(r.pool.QueryRow(ctx, query, id))
+	return r.scanStreamKey(r.db.QueryRow(ctx, query, args...))
 }
 
 // GetByKeyValue retrieves a stream key by its key value.
 func (r *StreamKeyRepo) GetByKeyValue(ctx context.Context, keyValue string) (*domain.StreamKey, error) {
 	query := `
-		SELECT id, key_value, broadcaster_id, status, created_at, expires_at, revoked_at, last_used_at
+		SELECT ` + streamKeyColumns + `
 		FROM stream_keys
 		WHERE key_value = $1
 	`
 
-	return r.scanStreamKey(r.pool.QueryRow(ctx, query, keyValue))
+	return r.scanStreamKey(r.db.QueryRow(ctx, query, keyValue))
+}
+
+// GetByPublishPath retrieves the stream key that most recently authenticated
+// a publish to the given path.
+func (r *StreamKeyRepo) GetByPublishPath(ctx context.Context, path string) (*domain.StreamKey, error) {
+	query := `
+		SELECT ` + streamKeyColumns + `
+		FROM stream_keys
+		WHERE publish_path = $1
+		ORDER BY last_used_at DESC NULLS LAST
+		LIMIT 1
+	`
+
+	return r.scanStreamKey(r.db.QueryRow(ctx, query, path))
+}
+
+// GetByBatchAndBroadcaster retrieves a broadcaster's key in a batch.
+func (r *StreamKeyRepo) GetByBatchAndBroadcaster(ctx context.Context, batchID, broadcasterID uuid.UUID) (*domain.StreamKey, error) {
+	query := `
+		SELECT ` + streamKeyColumns + `
+		FROM stream_keys
+		WHERE batch_id = $1 AND broadcaster_id = $2
+		ORDER BY created_at
+		LIMIT 1
+	`
+	query, args := scopeToOrganization(ctx, query, "organization_id", batchID, broadcasterID)
+
+	return r.scanStreamKey(r.db.QueryRow(ctx, query, args...))
+}
+
+// GetAndLockByID retrieves a stream key by ID and locks it for update. This
+// must be called within a unit of work.
+func (r *StreamKeyRepo) GetAndLockByID(ctx context.Context, id uuid.UUID) (*domain.StreamKey, error) {
+	query := `
+		SELECT ` + streamKeyColumns + `
+		FROM stream_keys
+		WHERE id = $1
+	`
+	query, args := scopeToOrganization(ctx, query, "organization_id", id)
+
+	return r.scanStreamKey(r.db.QueryRow(ctx, query+" FOR UPDATE", args...))
 }
 
 // GetAndLockByKeyValue atomically retrieves and locks a stream key for update.
-// This must be called within a transaction.
+// This must be called within a unit of work.
 func (r *StreamKeyRepo) GetAndLockByKeyValue(ctx context.Context, keyValue string) (*domain.StreamKey, error) {
 	query := `
-		SELECT id, key_value, broadcaster_id, status, created_at, expires_at, revoked_at, last_used_at
+		SELECT ` + streamKeyColumns + `
 		FROM stream_keys
 		WHERE key_value = $1
 		FOR UPDATE
 	`
 
-	return r.scanStreamKey(r.pool.QueryRow(ctx, query, keyValue))
+	return r.scanStreamKey(r.db.QueryRow(ctx, query, keyValue))
 }
 
 // ListByBroadcaster retrieves all stream keys for a broadcaster.
 func (r *StreamKeyRepo) ListByBroadcaster(ctx context.Context, broadcasterID uuid.UUID) ([]domain.StreamKey, error) {
 	query := `
-		SELECT id, key_value, broadcaster_id, status, created_at, expires_at, revoked_at, last_used_at
+		SELECT ` + streamKeyColumns + `
 		FROM stream_keys
 		WHERE broadcaster_id = $1
-		ORDER BY created_at DESC
 	`
+	query, args := scopeToOrganization(ctx, query, "organization_id", broadcasterID)
 
-	return r.queryStreamKeys(ctx, query, broadcasterID)
+	return r.queryStreamKeys(ctx, query+" ORDER BY created_at DESC", args...)
 }
 
-// ListAll retrieves all stream keys.
+// ListAll retrieves all stream keys of the organization ctx is scoped to,
+// or every stream key for unscoped contexts.
 func (r *StreamKeyRepo) ListAll(ctx context.Context) ([]domain.StreamKey, error) {
 	query := `
-		SELECT id, key_value, broadcaster_id, status, created_at, expires_at, revoked_at, last_used_at
+		SELECT ` + streamKeyColumns + `
 		FROM stream_keys
-		ORDER BY created_at DESC
+		WHERE TRUE
 	`
+	query, args := scopeToOrganization(ctx, query, "organization_id")
 
-	return r.queryStreamKeys(ctx, query)
+	return r.queryStreamKeys(ctx, query+" ORDER BY created_at DESC", args...)
+}
+
+// ListByGroup retrieves the stream keys of every member of a broadcaster group.
+func (r *StreamKeyRepo) ListByGroup(ctx context.Context, groupID uuid.UUID) ([]domain.StreamKey, error) {
+	query := `
+		SELECT ` + streamKeyColumns + `
+		FROM stream_keys
+		WHERE broadcaster_id IN (SELECT broadcaster_id FROM broadcaster_group_members WHERE group_id = $1)
+	`
+	query, args := scopeToOrganization(ctx, query, "organization_id", groupID)
+
+	return r.queryStreamKeys(ctx, query+" ORDER BY created_at DESC", args...)
 }
 
 // UpdateStatus updates the status of a stream key.
@@ -114,8 +187,9 @@ func (r *StreamKeyRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status d
 		SET status = $2, revoked_at = $3
 		WHERE id = $1
 	`
+	query, args := scopeToOrganization(ctx, query, "organization_id", id, status, revokedAt)
 
-	result, err := r.pool.Exec(ctx, query, id, status, revokedAt)
+	result, err := r.db.Exec(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to update stream key status: %w", err)
 	}
@@ -127,11 +201,124 @@ func (r *StreamKeyRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status d
 	return nil
 }
 
+// CountActiveByBroadcaster counts a broadcaster's active, unexpired keys.
+func (r *StreamKeyRepo) CountActiveByBroadcaster(ctx context.Context, broadcasterID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM stream_keys
+		WHERE broadcaster_id = $1 AND status = 'active' AND (expires_at IS NULL OR expires_at > NOW())
+	`
+
+	var count int
+	if err := r.db.QueryRow(ctx, query, broadcasterID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count stream keys: %w", err)
+	}
+
+	return count, nil
+}
+
+// Update updates the label, description and expiry of a stream key.
+func (r *StreamKeyRepo) Update(ctx context.Context, key *domain.StreamKey) error {
+	query := `
+		UPDATE stream_keys
+		SET label = $2, description = $3, expires_at = $4, device_id = $5, min_bitrate_kbps = $6, max_stream_duration_seconds = $7
+		WHERE id = $1
+	`
+	query, args := scopeToOrganization(ctx, query, "organization_id", key.ID, key.Label, key.Description, key.ExpiresAt, key.DeviceID, key.MinBitrateKbps, key.MaxStreamDurationSeconds)
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		if isDeviceViolation(err) {
+			return domain.ErrInvalidDevice
+		}
+		return fmt.Errorf("failed to update stream key: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetSuspended suspends an active key or resumes a suspended one.
+func (r *StreamKeyRepo) SetSuspended(ctx context.Context, id uuid.UUID, suspended bool) error {
+	query := `
+		UPDATE stream_keys
+		SET status = 'suspended', suspended_at = NOW()
+		WHERE id = $1 AND status = 'active'
+	`
+	if !suspended {
+		query = `
+			UPDATE stream_keys
+			SET status = 'active', suspended_at = NULL
+			WHERE id = $1 AND status = 'suspended'
+		`
+	}
+	query, args := scopeToOrganization(ctx, query, "organization_id", id)
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update stream key suspension: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListActiveExpiringBefore lists active keys with an expiry time before the given time.
+func (r *StreamKeyRepo) ListActiveExpiringBefore(ctx context.Context, before time.Time) ([]domain.StreamKey, error) {
+	query := `
+		SELECT ` + streamKeyColumns + `
+		FROM stream_keys
+		WHERE status = 'active' AND expires_at IS NOT NULL AND expires_at < $1
+		ORDER BY expires_at
+	`
+
+	return r.queryStreamKeys(ctx, query, before)
+}
+
+// MarkExpired marks an active key as expired.
+func (r *StreamKeyRepo) MarkExpired(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE stream_keys SET status = 'expired' WHERE id = $1 AND status = 'active'`
+
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark stream key expired: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// RecordExpiryNotice records an expiring-soon notice, returning false if it
+// was already recorded.
+func (r *StreamKeyRepo) RecordExpiryNotice(ctx context.Context, id uuid.UUID, leadTime time.Duration, expiresAt time.Time) (bool, error) {
+	query := `
+		INSERT INTO stream_key_expiry_notices (stream_key_id, lead_time_seconds, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`
+
+	result, err := r.db.Exec(ctx, query, id, int(leadTime.Seconds()), expiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to record expiry notice: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
 // UpdateLastUsed updates the last used timestamp of a stream key.
 func (r *StreamKeyRepo) UpdateLastUsed(ctx context.Context, id uuid.UUID) error {
 	query := `UPDATE stream_keys SET last_used_at = NOW() WHERE id = $1`
 
-	result, err := r.pool.Exec(ctx, query, id)
+	result, err := r.db.Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to update last used: %w", err)
 	}
@@ -143,31 +330,69 @@ func (r *StreamKeyRepo) UpdateLastUsed(ctx context.Context, id uuid.UUID) error
 	return nil
 }
 
+// RecordPublish updates the last used timestamp of a stream key and remembers
+// the path it publishes to for lifecycle webhooks.
+func (r *StreamKeyRepo) RecordPublish(ctx context.Context, id uuid.UUID, path string) error {
+	query := `UPDATE stream_keys SET last_used_at = NOW(), publish_path = $2 WHERE id = $1`
+
+	result, err := r.db.Exec(ctx, query, id, path)
+	if err != nil {
+		return fmt.Errorf("failed to record publish: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
 func (r *StreamKeyRepo) scanStreamKey(row pgx.Row) (*domain.StreamKey, error) {
 	var key domain.StreamKey
 
-	err := row.Scan(
+	err := row.Scan(streamKeyFields(&key)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan stream key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// isDeviceViolation reports whether err is a stream key referencing a device
+// that does not exist or belongs to another broadcaster.
+func isDeviceViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation && pgErr.ConstraintName == "stream_keys_device_fkey"
+}
+
+// streamKeyFields returns the scan destinations for streamKeyColumns.
+func streamKeyFields(key *domain.StreamKey) []interface{} {
+	return []interface{}{
 		&key.ID,
 		&key.KeyValue,
 		&key.BroadcasterID,
+		&key.BatchID,
+		&key.DeviceID,
+		&key.Label,
+		&key.Description,
 		&key.Status,
 		&key.CreatedAt,
 		&key.ExpiresAt,
+		&key.SuspendedAt,
 		&key.RevokedAt,
 		&key.LastUsedAt,
-	)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, domain.ErrNotFound
-		}
-		return nil, fmt.Errorf("failed to scan stream key: %w", err)
+		&key.PublishPath,
+		&key.OrganizationID,
+		&key.MinBitrateKbps,
+		&key.MaxStreamDurationSeconds,
 	}
-
-	return &key, nil
 }
 
 func (r *StreamKeyRepo) queryStreamKeys(ctx context.Context, query string, args ...interface{}) ([]domain.StreamKey, error) {
-	rows, err := r.pool.Query(ctx, query, args...)
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query stream keys: %w", err)
 	}
@@ -175,20 +400,11 @@ func (r *StreamKeyRepo) queryStreamKeys(ctx context.Context, query string, args
 
 	var keys []domain.StreamKey
 	for rows.Next() {
-		var key domain.StreamKey
-		if err := rows.Scan(
-			&key.ID,
-			&key.KeyValue,
-			&key.BroadcasterID,
-			&key.Status,
-			&key.CreatedAt,
-			&key.ExpiresAt,
-			&key.RevokedAt,
-			&key.LastUsedAt,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan stream key: %w", err)
+		key, err := r.scanStreamKey(rows)
+		if err != nil {
+			return nil, err
 		}
-		keys = append(keys, key)
+		keys = append(keys, *key)
 	}
 
 	if err := rows.Err(); err != nil {
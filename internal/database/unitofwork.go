@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// UnitOfWork implements domain.UnitOfWork using pgxpool transactions.
+type UnitOfWork struct {
+	pool *pgxpool.Pool
+}
+
+// NewUnitOfWork creates a new UnitOfWork.
+func NewUnitOfWork(pool *pgxpool.Pool) *UnitOfWork {
+	return &UnitOfWork{pool: pool}
+}
+
+// WithTx calls fn with repositories bound to a new transaction, committing it
+// if fn returns nil and rolling it back otherwise.
+func (u *UnitOfWork) WithTx(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	return pgx.BeginFunc(ctx, u.pool, func(tx pgx.Tx) error {
+		return fn(domain.Repositories{
+			Broadcasters:     &BroadcasterRepo{db: tx},
+			StreamKeys:       &StreamKeyRepo{db: tx},
+			Streams:          &StreamRepo{db: tx},
+			StreamKeyBatches: &StreamKeyBatchRepo{db: tx},
+		})
+	})
+}
@@ -0,0 +1,257 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+const webhookEventColumns = `id, type, path, source_type, source_id, metadata, status, attempts, last_error, received_at, next_attempt_at, processed_at`
+
+// WebhookInboxRepo implements domain.WebhookInboxRepository using pgxpool.
+type WebhookInboxRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewWebhookInboxRepo creates a new WebhookInboxRepo.
+func NewWebhookInboxRepo(pool *pgxpool.Pool) *WebhookInboxRepo {
+	return &WebhookInboxRepo{pool: pool}
+}
+
+// Enqueue persists a received webhook as a pending event. Webhooks without a
+// source are never considered duplicates.
+func (r *WebhookInboxRepo) Enqueue(ctx context.Context, event *domain.WebhookEvent) error {
+	if event.Metadata == nil {
+		event.Metadata = make(map[string]interface{})
+	}
+
+	metadataJSON, err := json.Marshal(event.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook event metadata: %w", err)
+	}
+
+	query := `
+		INSERT INTO webhook_inbox (type, path, source_type, source_id, metadata, received_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (type, path, source_id) WHERE source_id IS NOT NULL DO NOTHING
+		RETURNING ` + webhookEventColumns
+
+	enqueued, err := scanWebhookEvent(r.pool.QueryRow(ctx, query,
+		event.Type,
+		event.Path,
+		event.SourceType,
+		event.SourceID,
+		metadataJSON,
+		event.ReceivedAt,
+	))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrAlreadyExists
+		}
+		return err
+	}
+
+	*event = *enqueued
+	return nil
+}
+
+// GetByID retrieves an inbox event by ID.
+func (r *WebhookInboxRepo) GetByID(ctx context.Context, id int64) (*domain.WebhookEvent, error) {
+	query := `SELECT ` + webhookEventColumns + ` FROM webhook_inbox WHERE id = $1`
+
+	return scanWebhookEvent(r.pool.QueryRow(ctx, query, id))
+}
+
+// List retrieves the inbox events matching the filter, most recent first.
+func (r *WebhookInboxRepo) List(ctx context.Context, filter domain.WebhookEventFilter) ([]domain.WebhookEvent, error) {
+	query := `
+		SELECT ` + webhookEventColumns + `
+		FROM webhook_inbox
+		WHERE TRUE
+	`
+	var args []interface{}
+
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+
+	if filter.Path != "" {
+		args = append(args, filter.Path)
+		query += fmt.Sprintf(" AND path = $%d", len(args))
+	}
+
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []domain.WebhookEvent
+	for rows.Next() {
+		event, err := scanWebhookEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook events: %w", err)
+	}
+
+	return events, nil
+}
+
+// ClaimNext claims the oldest due pending event whose path has no earlier
+// pending event, so the events of a path are applied in the order received.
+// Failed events do not hold back their path. An event being processed stays
+// pending until its lease runs out.
+func (r *WebhookInboxRepo) ClaimNext(ctx context.Context, lease time.Duration) (*domain.WebhookEvent, error) {
+	query := `
+		UPDATE webhook_inbox
+		SET attempts = attempts + 1, next_attempt_at = NOW() + make_interval(secs => $1)
+		WHERE id = (
+			SELECT e.id FROM webhook_inbox e
+			WHERE e.status = 'pending' AND e.next_attempt_at <= NOW()
+				AND NOT EXISTS (
+					SELECT 1 FROM webhook_inbox p
+					WHERE p.path = e.path AND p.status = 'pending' AND p.id < e.id
+				)
+			ORDER BY e.id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookEventColumns
+
+	return scanWebhookEvent(r.pool.QueryRow(ctx, query, lease.Seconds()))
+}
+
+// MarkProcessed marks an event processed.
+func (r *WebhookInboxRepo) MarkProcessed(ctx context.Context, id int64) error {
+	query := `
+		UPDATE webhook_inbox
+		SET status = 'processed', processed_at = NOW()
+		WHERE id = $1
+	`
+
+	return r.exec(ctx, "mark webhook event processed", query, id)
+}
+
+// Retry records a failed attempt and schedules the next one.
+func (r *WebhookInboxRepo) Retry(ctx context.Context, id int64, lastError string, nextAttempt time.Time) error {
+	query := `
+		UPDATE webhook_inbox
+		SET last_error = $2, next_attempt_at = $3
+		WHERE id = $1
+	`
+
+	return r.exec(ctx, "schedule webhook event retry", query, id, lastError, nextAttempt)
+}
+
+// MarkFailed records a failed attempt and stops retrying the event.
+func (r *WebhookInboxRepo) MarkFailed(ctx context.Context, id int64, lastError string) error {
+	query := `
+		UPDATE webhook_inbox
+		SET status = 'failed', last_error = $2
+		WHERE id = $1
+	`
+
+	return r.exec(ctx, "mark webhook event failed", query, id, lastError)
+}
+
+// Replay makes a failed event pending again with a fresh set of attempts,
+// unless a later event on its path has been processed. Its last error is kept
+// for reference.
+func (r *WebhookInboxRepo) Replay(ctx context.Context, id int64) (*domain.WebhookEvent, error) {
+	query := `
+		UPDATE webhook_inbox
+		SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+		WHERE id = $1 AND status = 'failed'
+			AND NOT EXISTS (
+				SELECT 1 FROM webhook_inbox l
+				WHERE l.path = webhook_inbox.path AND l.status = 'processed' AND l.id > webhook_inbox.id
+			)
+		RETURNING ` + webhookEventColumns
+
+	event, err := scanWebhookEvent(r.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, domain.ErrNotFound) {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrInvalidStatus
+	}
+
+	return event, err
+}
+
+// DeleteProcessedBefore deletes events processed before the given time.
+func (r *WebhookInboxRepo) DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM webhook_inbox WHERE status = 'processed' AND processed_at < $1`
+
+	result, err := r.pool.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete processed webhook events: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// exec runs an update of a single event, returning ErrNotFound if it does not
+// exist.
+func (r *WebhookInboxRepo) exec(ctx context.Context, action, query string, args ...interface{}) error {
+	result, err := r.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func scanWebhookEvent(row pgx.Row) (*domain.WebhookEvent, error) {
+	var event domain.WebhookEvent
+	var metadataJSON []byte
+	err := row.Scan(
+		&event.ID,
+		&event.Type,
+		&event.Path,
+		&event.SourceType,
+		&event.SourceID,
+		&metadataJSON,
+		&event.Status,
+		&event.Attempts,
+		&event.LastError,
+		&event.ReceivedAt,
+		&event.NextAttempt,
+		&event.ProcessedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan webhook event: %w", err)
+	}
+
+	if err := json.Unmarshal(metadataJSON, &event.Metadata); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webhook event metadata: %w", err)
+	}
+
+	return &event, nil
+}
@@ -7,20 +7,153 @@ import (
 	"github.com/google/uuid"
 )
 
+// BroadcasterStatus represents the availability of a broadcaster.
+type BroadcasterStatus string
+
+const (
+	BroadcasterStatusOffline      BroadcasterStatus = "offline"
+	BroadcasterStatusAvailable    BroadcasterStatus = "available"
+	BroadcasterStatusOutOfService BroadcasterStatus = "out_of_service"
+	// BroadcasterStatusLive is computed from active streams and cannot be set.
+	BroadcasterStatusLive BroadcasterStatus = "live"
+)
+
+// IsValid checks if the status is a known broadcaster status.
+func (s BroadcasterStatus) IsValid() bool {
+	return s.IsSettable() || s == BroadcasterStatusLive
+}
+
+// IsSettable checks if the status can be set by an operator.
+func (s BroadcasterStatus) IsSettable() bool {
+	switch s {
+	case BroadcasterStatusOffline, BroadcasterStatusAvailable, BroadcasterStatusOutOfService:
+		return true
+	}
+	return false
+}
+
 // Broadcaster represents an entity authorized to create streams.
 type Broadcaster struct {
-	ID          uuid.UUID              `json:"id"`
-	DisplayName string                 `json:"display_name"`
-	Metadata    map[string]interface{} `json:"metadata"`
-	CreatedAt   time.Time              `json:"created_at"`
-	UpdatedAt   time.Time              `json:"updated_at"`
+	ID             uuid.UUID              `json:"id"`
+	OrganizationID uuid.UUID              `json:"organization_id"`
+	DisplayName    string                 `json:"display_name"`
+	Metadata       map[string]interface{} `json:"metadata"`
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+	ArchivedAt     *time.Time             `json:"archived_at,omitempty"`
+	// Status is live while the broadcaster has an active stream, otherwise
+	// the status last set by an operator.
+	Status BroadcasterStatus `json:"status"`
+	// LastSeenAt is the most recent stream key use or stream activity.
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	// Quotas overrides the deployment-wide limits for this broadcaster.
+	Quotas BroadcasterQuotas `json:"quotas"`
+}
+
+// BroadcasterQuotas overrides deployment-wide limits for a broadcaster. Nil
+// fields use the deployment default; zero means unlimited.
+type BroadcasterQuotas struct {
+	MaxActiveKeys            *int `json:"max_active_keys,omitempty"`
+	MaxConcurrentStreams     *int `json:"max_concurrent_streams,omitempty"`
+	MaxStreamDurationSeconds *int `json:"max_stream_duration_seconds,omitempty"`
+}
+
+// IsValid checks that no quota is negative.
+func (q BroadcasterQuotas) IsValid() bool {
+	for _, v := range []*int{q.MaxActiveKeys, q.MaxConcurrentStreams, q.MaxStreamDurationSeconds} {
+		if v != nil && *v < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// BroadcasterUsage is a broadcaster's current usage against its effective
+// limits. A zero limit means unlimited.
+type BroadcasterUsage struct {
+	ActiveKeys               int `json:"active_keys"`
+	MaxActiveKeys            int `json:"max_active_keys"`
+	LiveStreams              int `json:"live_streams"`
+	MaxConcurrentStreams     int `json:"max_concurrent_streams"`
+	MaxStreamDurationSeconds int `json:"max_stream_duration_seconds"`
+}
+
+// IsArchived checks if the broadcaster has been archived.
+func (b *Broadcaster) IsArchived() bool {
+	return b.ArchivedAt != nil
+}
+
+// BroadcasterSortField identifies a field broadcaster listings can be sorted by.
+type BroadcasterSortField string
+
+const (
+	BroadcasterSortCreatedAt   BroadcasterSortField = "created_at"
+	BroadcasterSortUpdatedAt   BroadcasterSortField = "updated_at"
+	BroadcasterSortDisplayName BroadcasterSortField = "display_name"
+)
+
+// IsValid checks if the sort field is supported.
+func (f BroadcasterSortField) IsValid() bool {
+	switch f {
+	case BroadcasterSortCreatedAt, BroadcasterSortUpdatedAt, BroadcasterSortDisplayName:
+		return true
+	}
+	return false
+}
+
+// BroadcasterListOptions filters, sorts and paginates broadcaster listings.
+type BroadcasterListOptions struct {
+	// Search matches display names containing the text, case-insensitively.
+	Search string
+	// Metadata only matches broadcasters whose metadata contains this document.
+	Metadata map[string]interface{}
+	// SortBy is the field to sort by. Ties are broken by ID.
+	SortBy     BroadcasterSortField
+	Descending bool
+	// Limit is the maximum number of broadcasters to return.
+	Limit int
+	// Cursor continues a listing from the NextCursor of a previous page.
+	Cursor string
+	// IncludeArchived includes archived broadcasters, which are excluded by default.
+	IncludeArchived bool
+	// Status restricts the listing to broadcasters with the given status.
+	Status BroadcasterStatus
+}
+
+// BroadcasterDetail is a broadcaster with its current activity.
+type BroadcasterDetail struct {
+	Broadcaster
+	LiveStream        *Stream          `json:"live_stream"`
+	ActiveKeys        []StreamKey      `json:"active_keys"`
+	RecentStreamCount int              `json:"recent_stream_count"`
+	Usage             BroadcasterUsage `json:"usage"`
+}
+
+// BroadcasterPage is a page of a broadcaster listing.
+type BroadcasterPage struct {
+	Broadcasters []Broadcaster
+	// Total is the number of broadcasters matching the filters across all pages.
+	Total int
+	// NextCursor is empty on the last page.
+	NextCursor string
 }
 
 // BroadcasterRepository defines the interface for broadcaster persistence.
 type BroadcasterRepository interface {
 	Create(ctx context.Context, broadcaster *Broadcaster) error
 	GetByID(ctx context.Context, id uuid.UUID) (*Broadcaster, error)
+	// GetAndLockByID retrieves and locks a broadcaster for update until the
+	// unit of work it is called in ends, so its quotas can be checked
+	// without racing other keys and streams of the broadcaster.
+	GetAndLockByID(ctx context.Context, id uuid.UUID) (*Broadcaster, error)
 	Update(ctx context.Context, broadcaster *Broadcaster) error
+	UpdateStatus(ctx context.Context, id uuid.UUID, status BroadcasterStatus) error
+	List(ctx context.Context, opts BroadcasterListOptions) (*BroadcasterPage, error)
+
+	// Archive marks a broadcaster as archived, keeping its history.
+	Archive(ctx context.Context, id uuid.UUID) error
+	// Restore clears the archived mark of a broadcaster.
+	Restore(ctx context.Context, id uuid.UUID) error
+	// Delete permanently deletes a broadcaster along with its keys and streams.
 	Delete(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context) ([]Broadcaster, error)
 }
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeviceType is the kind of hardware a device is.
+type DeviceType string
+
+const (
+	DeviceTypeDrone    DeviceType = "drone"
+	DeviceTypeBodycam  DeviceType = "bodycam"
+	DeviceTypeVehicle  DeviceType = "vehicle"
+	DeviceTypeHandheld DeviceType = "handheld"
+	DeviceTypeFixed    DeviceType = "fixed"
+	DeviceTypeOther    DeviceType = "other"
+)
+
+// IsValid checks if the type is a known device type.
+func (t DeviceType) IsValid() bool {
+	switch t {
+	case DeviceTypeDrone, DeviceTypeBodycam, DeviceTypeVehicle, DeviceTypeHandheld, DeviceTypeFixed, DeviceTypeOther:
+		return true
+	}
+	return false
+}
+
+// Device is a piece of hardware that publishes streams, such as a drone or
+// bodycam, owned by a broadcaster.
+type Device struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	BroadcasterID  uuid.UUID  `json:"broadcaster_id"`
+	Type           DeviceType `json:"type"`
+	Serial         *string    `json:"serial,omitempty"`
+	Model          *string    `json:"model,omitempty"`
+	// Capabilities are lowercase feature tags such as thermal or zoom.
+	Capabilities []string  `json:"capabilities"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// DeviceFilter restricts device listings.
+type DeviceFilter struct {
+	BroadcasterID *uuid.UUID
+	Type          DeviceType
+}
+
+// DeviceRepository defines the interface for device persistence.
+type DeviceRepository interface {
+	// Create creates a device in the organization of its broadcaster.
+	Create(ctx context.Context, device *Device) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Device, error)
+	List(ctx context.Context, filter DeviceFilter) ([]Device, error)
+	Update(ctx context.Context, device *Device) error
+	// Delete deletes a device, unbinding it from its stream keys and streams.
+	Delete(ctx context.Context, id uuid.UUID) error
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EnrollmentToken lets devices register themselves as broadcasters and
+// receive a stream key without using the admin API.
+type EnrollmentToken struct {
+	ID uuid.UUID `json:"id"`
+	// OrganizationID owns the broadcasters enrolled with the token.
+	OrganizationID uuid.UUID `json:"organization_id"`
+	// Token is the secret value. It is only set when the token is created.
+	Token string  `json:"token,omitempty"`
+	Label *string `json:"label,omitempty"`
+	// MaxUses limits the number of redemptions; nil is unlimited.
+	MaxUses   *int       `json:"max_uses,omitempty"`
+	UseCount  int        `json:"use_count"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// DefaultMetadata is applied to enrolled broadcasters, under any
+	// metadata the device supplies.
+	DefaultMetadata map[string]interface{} `json:"default_metadata"`
+	// KeyTTLSeconds sets the expiry of issued stream keys; nil never expires.
+	KeyTTLSeconds *int       `json:"key_ttl_seconds,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+}
+
+// CheckRedeemable returns ErrEnrollmentTokenUnusable if the token cannot be
+// redeemed at the given time.
+func (t *EnrollmentToken) CheckRedeemable(now time.Time) error {
+	switch {
+	case t.RevokedAt != nil:
+		return fmt.Errorf("%w: revoked", ErrEnrollmentTokenUnusable)
+	case t.ExpiresAt != nil && !now.Before(*t.ExpiresAt):
+		return fmt.Errorf("%w: expired", ErrEnrollmentTokenUnusable)
+	case t.MaxUses != nil && t.UseCount >= *t.MaxUses:
+		return fmt.Errorf("%w: no uses left", ErrEnrollmentTokenUnusable)
+	}
+	return nil
+}
+
+// EnrollmentRedemption records a device enrolling with a token.
+type EnrollmentRedemption struct {
+	ID            uuid.UUID  `json:"id"`
+	TokenID       uuid.UUID  `json:"token_id"`
+	BroadcasterID *uuid.UUID `json:"broadcaster_id,omitempty"`
+	StreamKeyID   *uuid.UUID `json:"stream_key_id,omitempty"`
+	DisplayName   string     `json:"display_name"`
+	IP            string     `json:"ip,omitempty"`
+	UserAgent     string     `json:"user_agent,omitempty"`
+	RedeemedAt    time.Time  `json:"redeemed_at"`
+}
+
+// EnrollmentRepository defines the interface for enrollment token persistence.
+type EnrollmentRepository interface {
+	// Create stores a token under the hash of its value.
+	Create(ctx context.Context, token *EnrollmentToken, tokenHash string) error
+	GetByID(ctx context.Context, id uuid.UUID) (*EnrollmentToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*EnrollmentToken, error)
+	List(ctx context.Context) ([]EnrollmentToken, error)
+	// Revoke marks a token revoked. Revoking a revoked token keeps the
+	// original revocation time.
+	Revoke(ctx context.Context, id uuid.UUID) error
+	ListRedemptions(ctx context.Context, tokenID uuid.UUID) ([]EnrollmentRedemption, error)
+
+	// Redeem atomically re-checks that the token is redeemable, consumes a
+	// use and creates the broadcaster, stream key and redemption record.
+	Redeem(ctx context.Context, tokenHash string, broadcaster *Broadcaster, key *StreamKey, redemption *EnrollmentRedemption) error
+}
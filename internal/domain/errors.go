@@ -27,4 +27,37 @@ var (
 
 	// ErrUnauthorized indicates the request is not authorized.
 	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrForbidden indicates the caller's organization may not perform the action.
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrInvalidShare indicates a stream cannot be shared with an organization.
+	ErrInvalidShare = errors.New("invalid share")
+
+	// ErrInvalidDevice indicates a device does not belong to the stream key's broadcaster.
+	ErrInvalidDevice = errors.New("invalid device")
+
+	// ErrBroadcasterArchived indicates the broadcaster has been archived.
+	ErrBroadcasterArchived = errors.New("broadcaster archived")
+
+	// ErrQuotaExceeded indicates a broadcaster quota would be exceeded.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+
+	// ErrExportUnavailable indicates a one-time export was already retrieved
+	// or is not ready yet.
+	ErrExportUnavailable = errors.New("export unavailable")
+
+	// ErrEnrollmentTokenUnusable indicates an enrollment token is revoked,
+	// expired or used up.
+	ErrEnrollmentTokenUnusable = errors.New("enrollment token unusable")
+
+	// ErrInvalidCursor indicates a pagination cursor could not be decoded.
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrLockedOut indicates a source is locked out after repeated failed
+	// attempts.
+	ErrLockedOut = errors.New("too many failed attempts")
+
+	// ErrInvalidExpiry indicates an expiry time is not in the future.
+	ErrInvalidExpiry = errors.New("invalid expiry")
 )
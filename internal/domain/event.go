@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// EventType identifies the kind of operational event.
+type EventType string
+
+const (
+	// EventAuthLockout is emitted when repeated authentication failures trigger a lockout.
+	EventAuthLockout EventType = "auth.lockout"
+
+	// EventStreamKeyExpiringSoon is emitted once per configured lead time before a stream key expires.
+	EventStreamKeyExpiringSoon EventType = "stream_key.expiring_soon"
+
+	// EventStreamKeyExpired is emitted when a stream key is marked expired.
+	EventStreamKeyExpired EventType = "stream_key.expired"
+
+	// EventStreamHealthChanged is emitted when an active stream becomes
+	// degraded or stalled, or recovers.
+	EventStreamHealthChanged EventType = "stream.health_changed"
+
+	// EventStreamDurationWarning is emitted once before an active stream
+	// reaches its maximum duration and is ended.
+	EventStreamDurationWarning EventType = "stream.duration_warning"
+)
+
+// Event is an operational notification that on-call staff should hear about.
+type Event struct {
+	Type       EventType              `json:"type"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// EventPublisher delivers operational events to interested parties.
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BroadcasterGroup is a team of broadcasters, such as a K9 or drone unit.
+type BroadcasterGroup struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Name           string    `json:"name"`
+	Description    *string   `json:"description,omitempty"`
+	MemberCount    int       `json:"member_count"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// GroupJobAction is a bulk action applied to every member of a group.
+type GroupJobAction string
+
+const (
+	// GroupJobIssueKeys issues a stream key to every member.
+	GroupJobIssueKeys GroupJobAction = "issue_keys"
+	// GroupJobRevokeKeys revokes every active or suspended key of every
+	// member, terminating live streams.
+	GroupJobRevokeKeys GroupJobAction = "revoke_keys"
+	// GroupJobSetExpiry sets or clears the expiry of every active or
+	// suspended key of every member.
+	GroupJobSetExpiry GroupJobAction = "set_expiry"
+)
+
+// IsValid checks if the action is a known group job action.
+func (a GroupJobAction) IsValid() bool {
+	switch a {
+	case GroupJobIssueKeys, GroupJobRevokeKeys, GroupJobSetExpiry:
+		return true
+	}
+	return false
+}
+
+// GroupJobStatus represents the progress of a group job.
+type GroupJobStatus string
+
+const (
+	GroupJobStatusPending   GroupJobStatus = "pending"
+	GroupJobStatusRunning   GroupJobStatus = "running"
+	GroupJobStatusCompleted GroupJobStatus = "completed"
+)
+
+// GroupJobResultStatus represents the outcome of a group job for one member.
+type GroupJobResultStatus string
+
+const (
+	GroupJobResultPending   GroupJobResultStatus = "pending"
+	GroupJobResultSucceeded GroupJobResultStatus = "succeeded"
+	// GroupJobResultSkipped means the member had no keys to act on.
+	GroupJobResultSkipped GroupJobResultStatus = "skipped"
+	GroupJobResultFailed  GroupJobResultStatus = "failed"
+)
+
+// GroupJob is a bulk action on the members of a group, executed in the
+// background with a result per member.
+type GroupJob struct {
+	ID             uuid.UUID      `json:"id"`
+	GroupID        uuid.UUID      `json:"group_id"`
+	OrganizationID uuid.UUID      `json:"organization_id"`
+	Action         GroupJobAction `json:"action"`
+	Status         GroupJobStatus `json:"status"`
+	// Label is set on keys issued by an issue_keys job.
+	Label *string `json:"label,omitempty"`
+	// ExpiresAt is the expiry of issued keys, or the expiry set by a
+	// set_expiry job; nil clears it.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// BatchID is the batch of keys issued by an issue_keys job, whose key
+	// values can be exported once.
+	BatchID     *uuid.UUID     `json:"batch_id,omitempty"`
+	Counts      GroupJobCounts `json:"counts"`
+	CreatedAt   time.Time      `json:"created_at"`
+	StartedAt   *time.Time     `json:"started_at,omitempty"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+}
+
+// GroupJobCounts tallies the per-member results of a group job.
+type GroupJobCounts struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Succeeded int `json:"succeeded"`
+	Skipped   int `json:"skipped"`
+	Failed    int `json:"failed"`
+}
+
+// GroupJobResult is the outcome of a group job for one member.
+type GroupJobResult struct {
+	BroadcasterID uuid.UUID            `json:"broadcaster_id"`
+	Status        GroupJobResultStatus `json:"status"`
+	// StreamKeyIDs are the keys issued, revoked or updated for the member.
+	StreamKeyIDs []uuid.UUID `json:"stream_key_ids"`
+	Error        *string     `json:"error,omitempty"`
+	CompletedAt  *time.Time  `json:"completed_at,omitempty"`
+}
+
+// BroadcasterGroupRepository defines the interface for broadcaster group persistence.
+type BroadcasterGroupRepository interface {
+	Create(ctx context.Context, group *BroadcasterGroup) error
+	GetByID(ctx context.Context, id uuid.UUID) (*BroadcasterGroup, error)
+	List(ctx context.Context) ([]BroadcasterGroup, error)
+	Update(ctx context.Context, group *BroadcasterGroup) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// AddMembers adds broadcasters to a group, ignoring existing members.
+	AddMembers(ctx context.Context, groupID uuid.UUID, broadcasterIDs []uuid.UUID) error
+	RemoveMember(ctx context.Context, groupID, broadcasterID uuid.UUID) error
+	ListMembers(ctx context.Context, groupID uuid.UUID) ([]Broadcaster, error)
+}
+
+// GroupJobRepository defines the interface for group job persistence.
+type GroupJobRepository interface {
+	// Create creates a job with a pending result for every current member of
+	// its group.
+	Create(ctx context.Context, job *GroupJob) error
+	GetByID(ctx context.Context, groupID, id uuid.UUID) (*GroupJob, error)
+	ListByGroup(ctx context.Context, groupID uuid.UUID) ([]GroupJob, error)
+	ListResults(ctx context.Context, jobID uuid.UUID) ([]GroupJobResult, error)
+	// ClaimNext marks the oldest pending job running and returns it, holding
+	// it until lease passes. A running job whose lease ran out was abandoned
+	// and is claimed again. It returns ErrNotFound if no job is claimable.
+	ClaimNext(ctx context.Context, lease time.Duration) (*GroupJob, error)
+	// ListPendingMembers lists the members a job has not processed yet.
+	ListPendingMembers(ctx context.Context, jobID uuid.UUID) ([]uuid.UUID, error)
+	RecordResult(ctx context.Context, jobID uuid.UUID, result GroupJobResult) error
+	Complete(ctx context.Context, jobID uuid.UUID) error
+	// RenewLease extends the lease of a running job until lease passes. It
+	// returns ErrNotFound if the job is not running.
+	RenewLease(ctx context.Context, jobID uuid.UUID, lease time.Duration) error
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// LockoutScope identifies what an authentication lockout applies to.
+type LockoutScope string
+
+const (
+	LockoutScopeIP   LockoutScope = "ip"
+	LockoutScopePath LockoutScope = "path"
+)
+
+// IsValid checks if the scope is a known lockout scope.
+func (s LockoutScope) IsValid() bool {
+	return s == LockoutScopeIP || s == LockoutScopePath
+}
+
+// AuthLockout tracks failed publish attempts for a source IP or a claimed path.
+type AuthLockout struct {
+	Scope          LockoutScope `json:"scope"`
+	Subject        string       `json:"subject"`
+	FailureCount   int          `json:"failure_count"`
+	LockoutCount   int          `json:"lockout_count"`
+	FirstFailureAt time.Time    `json:"first_failure_at"`
+	LastFailureAt  time.Time    `json:"last_failure_at"`
+	LockedUntil    *time.Time   `json:"locked_until,omitempty"`
+}
+
+// IsLocked checks if the lockout is in effect at the given time.
+func (l *AuthLockout) IsLocked(now time.Time) bool {
+	return l.LockedUntil != nil && now.Before(*l.LockedUntil)
+}
+
+// AuthLockoutRepository defines the interface for authentication lockout persistence.
+type AuthLockoutRepository interface {
+	Get(ctx context.Context, scope LockoutScope, subject string) (*AuthLockout, error)
+	List(ctx context.Context) ([]AuthLockout, error)
+	Delete(ctx context.Context, scope LockoutScope, subject string) error
+
+	// RecordFailure increments the failure counter, restarting it if the
+	// previous failure happened before windowStart.
+	RecordFailure(ctx context.Context, scope LockoutScope, subject string, windowStart time.Time) (*AuthLockout, error)
+
+	// Lock locks the subject until the given time, resetting the failure
+	// counter and incrementing the lockout counter.
+	Lock(ctx context.Context, scope LockoutScope, subject string, lockedUntil time.Time) (*AuthLockout, error)
+
+	// ResetFailures clears the failure counter while keeping the lockout history.
+	ResetFailures(ctx context.Context, scope LockoutScope, subject string) error
+}
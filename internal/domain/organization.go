@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultOrganizationID is the organization that owns data created before
+// organizations existed or without a tenant.
+var DefaultOrganizationID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// Organization is a tenant, such as an agency, sharing the deployment.
+type Organization struct {
+	ID        uuid.UUID `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// APIClient is an HMAC API credential scoped to an organization.
+type APIClient struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Name           string    `json:"name"`
+	APIKey         string    `json:"api_key"`
+	// Secret is only returned when the client is created.
+	Secret    string     `json:"secret,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IsRevoked checks if the client has been revoked.
+func (c *APIClient) IsRevoked() bool {
+	return c.RevokedAt != nil
+}
+
+// StreamShare grants an organization read access to another organization's stream.
+type StreamShare struct {
+	StreamID       uuid.UUID `json:"stream_id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// OrganizationRepository defines the interface for organization persistence.
+type OrganizationRepository interface {
+	// Create returns ErrAlreadyExists if the slug is taken.
+	Create(ctx context.Context, org *Organization) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Organization, error)
+	List(ctx context.Context) ([]Organization, error)
+}
+
+// APIClientRepository defines the interface for API client persistence.
+type APIClientRepository interface {
+	Create(ctx context.Context, client *APIClient) error
+	// GetByAPIKey retrieves a client, including its secret.
+	GetByAPIKey(ctx context.Context, apiKey string) (*APIClient, error)
+	// ListByOrganization lists an organization's clients without their secrets.
+	ListByOrganization(ctx context.Context, organizationID uuid.UUID) ([]APIClient, error)
+	Revoke(ctx context.Context, organizationID, id uuid.UUID) error
+}
+
+type organizationContextKey struct{}
+
+// ContextWithOrganization returns a context scoped to an organization.
+// Repositories only return and modify that organization's data.
+func ContextWithOrganization(ctx context.Context, organizationID uuid.UUID) context.Context {
+	return context.WithValue(ctx, organizationContextKey{}, organizationID)
+}
+
+// OrganizationFromContext returns the organization a context is scoped to.
+// Contexts without one, such as MediaMTX callbacks, background workers and
+// deployment-wide API keys, are unscoped.
+func OrganizationFromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(organizationContextKey{}).(uuid.UUID)
+	return id, ok
+}
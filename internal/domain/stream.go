@@ -7,18 +7,81 @@ import (
 	"github.com/google/uuid"
 )
 
-// StreamStatus represents the status of a stream.
+// StreamStatus represents the status of a stream. A pending stream is a
+// reservation of its key by a publish approved at authentication, until the
+// ready webhook activates it. An interrupted stream's publisher disconnected
+// and may still reconnect within the reconnect grace period.
 type StreamStatus string
 
 const (
-	StreamStatusActive StreamStatus = "active"
-	StreamStatusEnded  StreamStatus = "ended"
+	StreamStatusPending     StreamStatus = "pending"
+	StreamStatusActive      StreamStatus = "active"
+	StreamStatusInterrupted StreamStatus = "interrupted"
+	StreamStatusEnded       StreamStatus = "ended"
 )
 
+// StreamEndReason records why a stream ended.
+type StreamEndReason string
+
+const (
+	StreamEndPublisherDisconnected StreamEndReason = "publisher_disconnected"
+	StreamEndReconnectTimeout      StreamEndReason = "reconnect_timeout"
+	StreamEndKeyRevoked            StreamEndReason = "key_revoked"
+	StreamEndKeySuspended          StreamEndReason = "key_suspended"
+	StreamEndKeyExpired            StreamEndReason = "key_expired"
+	StreamEndDurationExceeded      StreamEndReason = "duration_exceeded"
+)
+
+// StreamHealth is the health of an active stream as sampled from the media
+// server.
+type StreamHealth string
+
+const (
+	StreamHealthHealthy StreamHealth = "healthy"
+	// StreamHealthDegraded streams publish below the minimum bitrate of
+	// their key.
+	StreamHealthDegraded StreamHealth = "degraded"
+	// StreamHealthStalled streams received no data since the last sample.
+	StreamHealthStalled StreamHealth = "stalled"
+)
+
+// StreamEventType identifies a stream lifecycle event.
+type StreamEventType string
+
+const (
+	// StreamEventReady is recorded when the publisher starts a stream.
+	StreamEventReady StreamEventType = "ready"
+	// StreamEventNotReady is recorded when the publisher disconnects.
+	StreamEventNotReady StreamEventType = "not_ready"
+	// StreamEventReconnected is recorded when an interrupted stream resumes.
+	StreamEventReconnected StreamEventType = "reconnected"
+	// StreamEventKicked is recorded when the publisher is kicked from the
+	// media server.
+	StreamEventKicked StreamEventType = "kicked"
+	// StreamEventEnded is recorded when a stream ends, with its end reason.
+	StreamEventEnded StreamEventType = "ended"
+	// StreamEventHealthChanged is recorded when the sampled health of a
+	// stream changes.
+	StreamEventHealthChanged StreamEventType = "health_changed"
+	// StreamEventDurationWarning is recorded when a stream is about to reach
+	// its maximum duration.
+	StreamEventDurationWarning StreamEventType = "duration_warning"
+)
+
+// StreamEvent is an entry in the lifecycle timeline of a stream.
+type StreamEvent struct {
+	ID         int64                  `json:"id"`
+	StreamID   uuid.UUID              `json:"stream_id"`
+	Type       StreamEventType        `json:"type"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Details    map[string]interface{} `json:"details"`
+}
+
 // Stream represents an active or historical video broadcast session.
 type Stream struct {
 	ID           uuid.UUID              `json:"id"`
 	StreamKeyID  uuid.UUID              `json:"stream_key_id"`
+	DeviceID     *uuid.UUID             `json:"device_id,omitempty"`
 	Path         string                 `json:"path"`
 	Status       StreamStatus           `json:"status"`
 	StartedAt    time.Time              `json:"started_at"`
@@ -27,12 +90,67 @@ type Stream struct {
 	SourceID     *string                `json:"source_id,omitempty"`
 	Metadata     map[string]interface{} `json:"metadata"`
 	RecordingRef *string                `json:"recording_ref,omitempty"`
+	Title        *string                `json:"title,omitempty"`
+	Notes        *string                `json:"notes,omitempty"`
+	Tags         []string               `json:"tags"`
+	// OrganizationID is always that of the stream key.
+	OrganizationID uuid.UUID `json:"organization_id"`
+	// InterruptedAt is when the publisher of an interrupted stream disconnected.
+	InterruptedAt *time.Time `json:"interrupted_at,omitempty"`
+	// EndReason is why an ended stream ended.
+	EndReason *StreamEndReason `json:"end_reason,omitempty"`
+	// Health is the last sampled health of an active stream; it is unset
+	// until the stream has been sampled.
+	Health *StreamHealth `json:"health,omitempty"`
+}
+
+// StreamDurationWarning is an active stream about to reach its maximum
+// duration.
+type StreamDurationWarning struct {
+	Stream Stream
+	// EndsAt is when the stream reaches its maximum duration.
+	EndsAt time.Time
+}
+
+// MonitoredStream is an active stream with the minimum bitrate of its key.
+type MonitoredStream struct {
+	Stream Stream
+	// MinBitrateKbps is the key's own minimum bitrate, if it has one.
+	MinBitrateKbps *int
 }
 
-// StreamURLs contains video playback URLs for a stream.
+// StreamSegment is a continuous period of publishing within a stream. Gaps
+// between segments are publisher reconnects.
+type StreamSegment struct {
+	ID         uuid.UUID  `json:"id"`
+	StreamID   uuid.UUID  `json:"stream_id"`
+	StartedAt  time.Time  `json:"started_at"`
+	EndedAt    *time.Time `json:"ended_at,omitempty"`
+	SourceType *string    `json:"source_type,omitempty"`
+	SourceID   *string    `json:"source_id,omitempty"`
+}
+
+// StreamFilter restricts stream listings.
+type StreamFilter struct {
+	// Tags matches streams carrying all of the given tags.
+	Tags []string
+	// GroupID matches streams of members of a broadcaster group.
+	GroupID *uuid.UUID
+	// DeviceID matches streams published from a device.
+	DeviceID *uuid.UUID
+	// DeviceType matches streams published from devices of a type.
+	DeviceType DeviceType
+}
+
+// StreamURLs contains video playback URLs for a stream. URLs are only set
+// for protocols enabled on the media server.
 type StreamURLs struct {
-	HLS    string `json:"hls"`
-	WebRTC string `json:"webrtc"`
+	WebRTC string `json:"webrtc,omitempty"`
+	HLS    string `json:"hls,omitempty"`
+	LLHLS  string `json:"llhls,omitempty"`
+	RTSP   string `json:"rtsp,omitempty"`
+	RTMP   string `json:"rtmp,omitempty"`
+	SRT    string `json:"srt,omitempty"`
 }
 
 // StreamWithURLs is a stream with computed video playback URLs.
@@ -41,13 +159,95 @@ type StreamWithURLs struct {
 	URLs StreamURLs `json:"urls"`
 }
 
-// StreamRepository defines the interface for stream persistence.
+// StreamRepository defines the interface for stream persistence. Creating,
+// interrupting, resuming and ending streams records the matching lifecycle
+// events.
 type StreamRepository interface {
+	// Create creates a stream, activating the pending reservation of its key
+	// if there is one. It returns ErrInvalidStreamKey if the key is no longer
+	// active, and ErrStreamKeyInUse if the key has an active stream or is
+	// reserved by another connection.
 	Create(ctx context.Context, stream *Stream) error
+	// GetByID retrieves a stream by ID; reservations are not returned.
 	GetByID(ctx context.Context, id uuid.UUID) (*Stream, error)
 	GetActiveByPath(ctx context.Context, path string) (*Stream, error)
+	// GetActiveByStreamKeyID retrieves the active or interrupted stream of a key.
 	GetActiveByStreamKeyID(ctx context.Context, keyID uuid.UUID) (*Stream, error)
-	ListActive(ctx context.Context) ([]Stream, error)
-	EndStream(ctx context.Context, id uuid.UUID) error
-	EndStreamByPath(ctx context.Context, path string) error
+	// ListActive lists active and interrupted streams matching the filter.
+	ListActive(ctx context.Context, filter StreamFilter) ([]Stream, error)
+	// GetActiveByBroadcasterID retrieves the most recent active or
+	// interrupted stream of a broadcaster.
+	GetActiveByBroadcasterID(ctx context.Context, broadcasterID uuid.UUID) (*Stream, error)
+	CountByBroadcasterSince(ctx context.Context, broadcasterID uuid.UUID, since time.Time) (int, error)
+	// CountActiveByBroadcasterID counts a broadcaster's active streams.
+	CountActiveByBroadcasterID(ctx context.Context, broadcasterID uuid.UUID) (int, error)
+	// ListMonitored lists active streams with the minimum bitrate of their
+	// keys.
+	ListMonitored(ctx context.Context) ([]MonitoredStream, error)
+	// ListActiveOverDuration lists active streams running longer than the
+	// maximum stream duration of their key, else of their broadcaster, else
+	// defaultMax. A zero limit means unlimited.
+	ListActiveOverDuration(ctx context.Context, defaultMax time.Duration) ([]Stream, error)
+	// ListApproachingDuration lists the active streams reaching their
+	// maximum duration within lead that have not been warned yet.
+	ListApproachingDuration(ctx context.Context, defaultMax, lead time.Duration) ([]StreamDurationWarning, error)
+	// MarkDurationWarned records that an active stream was warned it ends at
+	// endsAt, recording a duration warning event. It returns ErrNotFound if
+	// the stream is not active or was already warned.
+	MarkDurationWarned(ctx context.Context, id uuid.UUID, endsAt time.Time) error
+	// ClearDurationWarning forgets that the live stream of a key was warned,
+	// so it is warned again about a changed maximum duration.
+	ClearDurationWarning(ctx context.Context, keyID uuid.UUID) error
+	UpdateLabels(ctx context.Context, stream *Stream) error
+	// UpdateHealth sets the health of an active stream. It returns
+	// ErrNotFound if the stream is not active.
+	UpdateHealth(ctx context.Context, id uuid.UUID, health StreamHealth) error
+	// EndStream ends an active or interrupted stream.
+	EndStream(ctx context.Context, id uuid.UUID, reason StreamEndReason) error
+	// EndStreamByPath ends, as of the given time, the active stream on a
+	// path whose publisher disconnected.
+	EndStreamByPath(ctx context.Context, path string, reason StreamEndReason, at time.Time) error
+
+	// InterruptStreamByPath marks the active stream on a path as interrupted
+	// at the given time, closing its current segment.
+	InterruptStreamByPath(ctx context.Context, path string, at time.Time) error
+	// ResumeInterrupted resumes the most recent stream of stream.StreamKeyID
+	// interrupted at or after since, opening a new segment at
+	// stream.StartedAt with the path and source of stream. On success stream
+	// is replaced by the resumed stream. It returns ErrNotFound if there is no
+	// such stream.
+	ResumeInterrupted(ctx context.Context, stream *Stream, since time.Time) error
+	// EndInterruptedBefore ends the streams interrupted before the given
+	// time, as of when they were interrupted.
+	EndInterruptedBefore(ctx context.Context, before time.Time) ([]Stream, error)
+	// Reserve reserves a key for a connection with a pending stream on path
+	// until ttl passes, replacing an expired reservation or renewing the
+	// connection's own. It returns ErrStreamKeyInUse if the key has an
+	// active stream.
+	Reserve(ctx context.Context, keyID uuid.UUID, path, connectionID string, ttl time.Duration) error
+	// GetLiveByStreamKeyID retrieves the active stream or unexpired
+	// reservation of a key.
+	GetLiveByStreamKeyID(ctx context.Context, keyID uuid.UUID) (*Stream, error)
+	// CountLiveByBroadcasterID counts the active streams and unexpired
+	// reservations of a broadcaster's keys other than exceptKeyID.
+	CountLiveByBroadcasterID(ctx context.Context, broadcasterID, exceptKeyID uuid.UUID) (int, error)
+	// IsPathLive checks if a key other than exceptKeyID has an active stream
+	// or unexpired reservation on path.
+	IsPathLive(ctx context.Context, path string, exceptKeyID uuid.UUID) (bool, error)
+	// DeleteReservation deletes the pending reservation of a key, if any.
+	DeleteReservation(ctx context.Context, keyID uuid.UUID) error
+	// DeleteExpiredReservations deletes the pending reservations that expired
+	// before the given time without being activated.
+	DeleteExpiredReservations(ctx context.Context, before time.Time) (int64, error)
+	ListSegments(ctx context.Context, streamID uuid.UUID) ([]StreamSegment, error)
+	// RecordEvent records a lifecycle event of a stream not recorded by the
+	// status changes above, such as a kick.
+	RecordEvent(ctx context.Context, event *StreamEvent) error
+	ListEvents(ctx context.Context, streamID uuid.UUID) ([]StreamEvent, error)
+
+	// Share grants an organization read access to a stream. Sharing twice
+	// is not an error.
+	Share(ctx context.Context, streamID, organizationID uuid.UUID) (*StreamShare, error)
+	Unshare(ctx context.Context, streamID, organizationID uuid.UUID) error
+	ListShares(ctx context.Context, streamID uuid.UUID) ([]StreamShare, error)
 }
@@ -11,9 +11,10 @@ import (
 type StreamKeyStatus string
 
 const (
-	StreamKeyStatusActive  StreamKeyStatus = "active"
-	StreamKeyStatusRevoked StreamKeyStatus = "revoked"
-	StreamKeyStatusExpired StreamKeyStatus = "expired"
+	StreamKeyStatusActive    StreamKeyStatus = "active"
+	StreamKeyStatusSuspended StreamKeyStatus = "suspended"
+	StreamKeyStatusRevoked   StreamKeyStatus = "revoked"
+	StreamKeyStatusExpired   StreamKeyStatus = "expired"
 )
 
 // StreamKey is a credential that authorizes a broadcaster to start a stream.
@@ -21,11 +22,68 @@ type StreamKey struct {
 	ID            uuid.UUID       `json:"id"`
 	KeyValue      string          `json:"key_value,omitempty"`
 	BroadcasterID uuid.UUID       `json:"broadcaster_id"`
+	BatchID       *uuid.UUID      `json:"batch_id,omitempty"`
+	DeviceID      *uuid.UUID      `json:"device_id,omitempty"`
+	Label         *string         `json:"label,omitempty"`
+	Description   *string         `json:"description,omitempty"`
 	Status        StreamKeyStatus `json:"status"`
 	CreatedAt     time.Time       `json:"created_at"`
 	ExpiresAt     *time.Time      `json:"expires_at,omitempty"`
+	SuspendedAt   *time.Time      `json:"suspended_at,omitempty"`
 	RevokedAt     *time.Time      `json:"revoked_at,omitempty"`
 	LastUsedAt    *time.Time      `json:"last_used_at,omitempty"`
+	PublishPath   *string         `json:"publish_path,omitempty"`
+	// MinBitrateKbps overrides the deployment-wide bitrate below which the
+	// key's streams are degraded.
+	MinBitrateKbps *int `json:"min_bitrate_kbps,omitempty"`
+	// MaxStreamDurationSeconds overrides the broadcaster's maximum stream
+	// duration for the key's streams. Zero means unlimited.
+	MaxStreamDurationSeconds *int `json:"max_stream_duration_seconds,omitempty"`
+	// OrganizationID is always that of the broadcaster.
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
+// IngestURLs contains publish URLs for a stream key. URLs are only set for
+// protocols enabled on the media server.
+type IngestURLs struct {
+	RTMP string `json:"rtmp,omitempty"`
+	RTSP string `json:"rtsp,omitempty"`
+	SRT  string `json:"srt,omitempty"`
+	WHIP string `json:"whip,omitempty"`
+}
+
+// StreamKeyWithURLs is a stream key with computed publish URLs.
+type StreamKeyWithURLs struct {
+	StreamKey
+	IngestURLs IngestURLs `json:"ingest_urls"`
+}
+
+// ProvisioningBundle contains what an encoder needs to publish with a stream
+// key, in formats common encoders can import.
+type ProvisioningBundle struct {
+	StreamKeyID   uuid.UUID   `json:"stream_key_id"`
+	BroadcasterID uuid.UUID   `json:"broadcaster_id"`
+	ExpiresAt     *time.Time  `json:"expires_at,omitempty"`
+	IngestURLs    IngestURLs  `json:"ingest_urls"`
+	OBS           *OBSService `json:"obs,omitempty"`
+	LarixURL      string      `json:"larix_url,omitempty"`
+	// QRContent is the text encoded in QRCodePNG.
+	QRContent string `json:"qr_content"`
+	QRCodePNG []byte `json:"qr_code_png"`
+}
+
+// OBSService is an OBS Studio service.json for a custom RTMP server.
+type OBSService struct {
+	Type     string             `json:"type"`
+	Settings OBSServiceSettings `json:"settings"`
+}
+
+// OBSServiceSettings are the settings of an OBS Studio custom RTMP service.
+type OBSServiceSettings struct {
+	Server  string `json:"server"`
+	Key     string `json:"key"`
+	UseAuth bool   `json:"use_auth"`
+	BWTest  bool   `json:"bwtest"`
 }
 
 // IsValid checks if the stream key is currently valid for use.
@@ -39,16 +97,50 @@ func (sk *StreamKey) IsValid() bool {
 	return true
 }
 
+// IsRevocable reports whether the stream key can still be revoked.
+func (sk *StreamKey) IsRevocable() bool {
+	return sk.Status == StreamKeyStatusActive || sk.Status == StreamKeyStatusSuspended
+}
+
 // StreamKeyRepository defines the interface for stream key persistence.
 type StreamKeyRepository interface {
 	Create(ctx context.Context, key *StreamKey) error
 	GetByID(ctx context.Context, id uuid.UUID) (*StreamKey, error)
 	GetByKeyValue(ctx context.Context, keyValue string) (*StreamKey, error)
+	GetByPublishPath(ctx context.Context, path string) (*StreamKey, error)
+	// GetByBatchAndBroadcaster retrieves a broadcaster's key in a batch.
+	GetByBatchAndBroadcaster(ctx context.Context, batchID, broadcasterID uuid.UUID) (*StreamKey, error)
 	ListByBroadcaster(ctx context.Context, broadcasterID uuid.UUID) ([]StreamKey, error)
 	ListAll(ctx context.Context) ([]StreamKey, error)
+	// ListByGroup lists the keys of every member of a broadcaster group.
+	ListByGroup(ctx context.Context, groupID uuid.UUID) ([]StreamKey, error)
 	UpdateStatus(ctx context.Context, id uuid.UUID, status StreamKeyStatus, revokedAt *time.Time) error
 	UpdateLastUsed(ctx context.Context, id uuid.UUID) error
+	// RecordPublish updates the last used timestamp of a key and remembers
+	// the path it publishes to.
+	RecordPublish(ctx context.Context, id uuid.UUID, path string) error
+	// Update updates the label, description, expiry, device, minimum bitrate
+	// and maximum stream duration of a stream key. It returns
+	// ErrInvalidDevice if the device is not the broadcaster's.
+	Update(ctx context.Context, key *StreamKey) error
+	// CountActiveByBroadcaster counts a broadcaster's active, unexpired keys.
+	CountActiveByBroadcaster(ctx context.Context, broadcasterID uuid.UUID) (int, error)
+	// SetSuspended suspends an active key or resumes a suspended one. It
+	// returns ErrNotFound if the key is not in the expected status.
+	SetSuspended(ctx context.Context, id uuid.UUID, suspended bool) error
+
+	// ListActiveExpiringBefore lists active keys with an expiry time before the given time.
+	ListActiveExpiringBefore(ctx context.Context, before time.Time) ([]StreamKey, error)
+	// MarkExpired marks an active key as expired. It returns ErrNotFound if
+	// the key is not active.
+	MarkExpired(ctx context.Context, id uuid.UUID) error
+	// RecordExpiryNotice records that a key was notified of its expiry at the
+	// given lead time. It returns false if the notice was already recorded.
+	RecordExpiryNotice(ctx context.Context, id uuid.UUID, leadTime time.Duration, expiresAt time.Time) (bool, error)
 
+	// GetAndLockByID retrieves and locks a stream key for update until the
+	// unit of work it is called in ends.
+	GetAndLockByID(ctx context.Context, id uuid.UUID) (*StreamKey, error)
 	// GetAndLockByKeyValue atomically retrieves and locks a stream key for update.
 	// This is used for authentication to prevent race conditions.
 	GetAndLockByKeyValue(ctx context.Context, keyValue string) (*StreamKey, error)
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StreamKeyBatch is a set of stream keys issued together, typically at the
+// start of an operation.
+type StreamKeyBatch struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	Label          *string    `json:"label,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	// ExportedAt is set once the export containing the key values has been retrieved.
+	ExportedAt *time.Time `json:"exported_at,omitempty"`
+}
+
+// StreamKeyBatchEntry is an issued key with the broadcaster it belongs to.
+type StreamKeyBatchEntry struct {
+	StreamKey
+	DisplayName string `json:"display_name"`
+}
+
+// StreamKeyExportEntry is a row of a stream key batch export.
+type StreamKeyExportEntry struct {
+	BroadcasterID uuid.UUID  `json:"broadcaster_id"`
+	DisplayName   string     `json:"display_name"`
+	StreamKeyID   uuid.UUID  `json:"stream_key_id"`
+	KeyValue      string     `json:"key_value"`
+	Label         *string    `json:"label,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	IngestURLs    IngestURLs `json:"ingest_urls"`
+}
+
+// StreamKeyBatchRepository defines the interface for stream key batch persistence.
+type StreamKeyBatchRepository interface {
+	// Create atomically creates a batch, any new broadcasters and the batch's keys.
+	Create(ctx context.Context, batch *StreamKeyBatch, broadcasters []Broadcaster, keys []StreamKey) error
+	GetByID(ctx context.Context, id uuid.UUID) (*StreamKeyBatch, error)
+	// ListEntries lists the keys of a batch without their key values.
+	ListEntries(ctx context.Context, id uuid.UUID) ([]StreamKeyBatchEntry, error)
+	// ClaimExport marks the batch exported and returns its keys with their
+	// key values. It returns ErrExportUnavailable if already exported, or if
+	// the group job issuing its keys has not completed.
+	ClaimExport(ctx context.Context, id uuid.UUID) ([]StreamKeyBatchEntry, error)
+}
@@ -0,0 +1,19 @@
+package domain
+
+import "context"
+
+// Repositories are the repositories a unit of work operates on.
+type Repositories struct {
+	Broadcasters     BroadcasterRepository
+	StreamKeys       StreamKeyRepository
+	Streams          StreamRepository
+	StreamKeyBatches StreamKeyBatchRepository
+}
+
+// UnitOfWork runs multi-step operations atomically.
+type UnitOfWork interface {
+	// WithTx calls fn with repositories sharing a single transaction,
+	// committed if fn returns nil and rolled back otherwise. The error of fn
+	// is returned unchanged.
+	WithTx(ctx context.Context, fn func(repos Repositories) error) error
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// WebhookEventType identifies the MediaMTX lifecycle webhook an inbox event
+// was received from.
+type WebhookEventType string
+
+const (
+	WebhookEventReady    WebhookEventType = "ready"
+	WebhookEventNotReady WebhookEventType = "not_ready"
+)
+
+// WebhookEventStatus represents the progress of an inbox event.
+type WebhookEventStatus string
+
+const (
+	// WebhookEventPending events are waiting to be processed, including
+	// events being retried.
+	WebhookEventPending   WebhookEventStatus = "pending"
+	WebhookEventProcessed WebhookEventStatus = "processed"
+	// WebhookEventFailed events ran out of attempts and wait to be replayed.
+	WebhookEventFailed WebhookEventStatus = "failed"
+)
+
+// IsValid checks if the status is a known inbox event status.
+func (s WebhookEventStatus) IsValid() bool {
+	switch s {
+	case WebhookEventPending, WebhookEventProcessed, WebhookEventFailed:
+		return true
+	}
+	return false
+}
+
+// WebhookEvent is a MediaMTX webhook persisted in the inbox, applied to
+// streams in the background in the order received on each path.
+type WebhookEvent struct {
+	ID         int64            `json:"id"`
+	Type       WebhookEventType `json:"type"`
+	Path       string           `json:"path"`
+	SourceType *string          `json:"source_type,omitempty"`
+	SourceID   *string          `json:"source_id,omitempty"`
+	// Metadata is the publish metadata parsed from the ingest URL of a ready
+	// webhook.
+	Metadata    map[string]interface{} `json:"metadata"`
+	Status      WebhookEventStatus     `json:"status"`
+	Attempts    int                    `json:"attempts"`
+	LastError   *string                `json:"last_error,omitempty"`
+	ReceivedAt  time.Time              `json:"received_at"`
+	NextAttempt time.Time              `json:"next_attempt_at"`
+	ProcessedAt *time.Time             `json:"processed_at,omitempty"`
+}
+
+// WebhookEventFilter filters inbox event listings.
+type WebhookEventFilter struct {
+	Status WebhookEventStatus
+	Path   string
+	// Limit is the maximum number of events to return, most recent first.
+	Limit int
+}
+
+// WebhookInboxRepository defines the interface for webhook inbox persistence.
+type WebhookInboxRepository interface {
+	// Enqueue persists a received webhook. It returns ErrAlreadyExists if a
+	// webhook of the same type for the same path and source was received
+	// before.
+	Enqueue(ctx context.Context, event *WebhookEvent) error
+	GetByID(ctx context.Context, id int64) (*WebhookEvent, error)
+	List(ctx context.Context, filter WebhookEventFilter) ([]WebhookEvent, error)
+
+	// ClaimNext claims the oldest pending event that is due and has no
+	// earlier pending event on its path, counting an attempt and
+	// deferring its next attempt by lease in case the claimer dies.
+	// Concurrent claimers never claim the same event. It returns ErrNotFound
+	// if none is due.
+	ClaimNext(ctx context.Context, lease time.Duration) (*WebhookEvent, error)
+	MarkProcessed(ctx context.Context, id int64) error
+	// Retry records a failed attempt and schedules the next one.
+	Retry(ctx context.Context, id int64, lastError string, nextAttempt time.Time) error
+	// MarkFailed records a failed attempt and stops retrying the event.
+	MarkFailed(ctx context.Context, id int64, lastError string) error
+	// Replay makes a failed event pending again with a fresh set of
+	// attempts. It returns ErrInvalidStatus if the event has not failed or a
+	// later event on its path has already been processed.
+	Replay(ctx context.Context, id int64) (*WebhookEvent, error)
+	// DeleteProcessedBefore deletes events processed before the given time.
+	DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -227,14 +227,202 @@ func TestAuthHandler_MissingPassword(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, resp.Code, "missing password should return 401")
 }
 
+func TestAuthHandler_PublishMetadata(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create broadcaster and stream key
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+	// Setup handler
+	h := setupAuthHandler(t, db.Pool)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected int
+	}{
+		{"all parameters", "unit=K9-3&title=Sector+4+ridge&lat=47.61&lon=-121.35", http.StatusOK},
+		{"credentials ignored", "user=crew&pass=secret&unit=K9-3", http.StatusOK},
+		{"unknown parameter", "unit=K9-3&colour=red", http.StatusUnauthorized},
+		{"latitude out of range", "lat=91&lon=0", http.StatusUnauthorized},
+		{"latitude without longitude", "lat=47.61", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := service.AuthRequest{
+				Password: keyValue,
+				IP:       "192.168.1.1",
+				Action:   "publish",
+				Path:     keyValue,
+				Protocol: "rtmp",
+				ID:       "conn-123",
+				Query:    tt.query,
+			}
+
+			resp := executeAuthRequest(t, h, req)
+			assert.Equal(t, tt.expected, resp.Code)
+		})
+	}
+}
+
+func TestAuthHandler_UserPasswordCredentials(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create broadcaster and stream key
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+	var keyID uuid.UUID
+	err := db.Pool.QueryRow(context.Background(), "SELECT id FROM stream_keys WHERE key_value = $1", keyValue).Scan(&keyID)
+	require.NoError(t, err)
+
+	// Setup handler
+	h := setupAuthHandler(t, db.Pool)
+
+	tests := []struct {
+		name     string
+		protocol string
+		user     string
+		password string
+		path     string
+		expected int
+	}{
+		{"key ID as user", "rtsp", keyID.String(), keyValue, "camera-1", http.StatusOK},
+		{"broadcaster ID as user", "rtmp", broadcasterID.String(), keyValue, "team-k9", http.StatusOK},
+		{"SRT stream ID", "srt", "", "", "publish:drone-2:" + keyID.String() + ":" + keyValue, http.StatusOK},
+		{"SRT access control stream ID", "srt", "", "", "#!::r=drone-3,m=publish,u=" + broadcasterID.String() + ",s=" + keyValue, http.StatusOK},
+		{"wrong user", "rtsp", uuid.New().String(), keyValue, "camera-1", http.StatusUnauthorized},
+		{"wrong password", "rtsp", keyID.String(), "sk_wrong", "camera-1", http.StatusUnauthorized},
+		{"malformed SRT stream ID", "srt", "", "", "publish:a:b:c:d:e", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := service.AuthRequest{
+				User:     tt.user,
+				Password: tt.password,
+				IP:       "192.168.1.1",
+				Action:   "publish",
+				Path:     tt.path,
+				Protocol: tt.protocol,
+				ID:       "conn-123",
+			}
+
+			resp := executeAuthRequest(t, h, req)
+			assert.Equal(t, tt.expected, resp.Code)
+		})
+	}
+
+	// The publish path is recorded for lifecycle webhooks
+	var publishPath string
+	err = db.Pool.QueryRow(context.Background(), "SELECT publish_path FROM stream_keys WHERE id = $1", keyID).Scan(&publishPath)
+	require.NoError(t, err)
+	assert.Equal(t, "drone-3", publishPath)
+}
+
+func TestAuthHandler_PathInUse(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Another key is already live on the path
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	otherKeyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+	var otherKeyID uuid.UUID
+	err := db.Pool.QueryRow(context.Background(), "SELECT id FROM stream_keys WHERE key_value = $1", otherKeyValue).Scan(&otherKeyID)
+	require.NoError(t, err)
+	createTestStream(t, db.Pool, otherKeyID, "team-k9", "active")
+
+	// Setup handler
+	h := setupAuthHandler(t, db.Pool)
+
+	req := service.AuthRequest{
+		User:     broadcasterID.String(),
+		Password: keyValue,
+		IP:       "192.168.1.1",
+		Action:   "publish",
+		Path:     "team-k9",
+		Protocol: "rtsp",
+		ID:       "conn-123",
+	}
+
+	// Execute
+	resp := executeAuthRequest(t, h, req)
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, resp.Code, "path in use should return 401")
+}
+
+func TestAuthHandler_ReservesKeyUntilReady(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Drone Pilot")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+	h := setupAuthHandler(t, db.Pool)
+	publish := func(connectionID string) int {
+		t.Helper()
+		req := publishRequest("10.0.0.1", keyValue)
+		req.ID = connectionID
+		return executeAuthRequest(t, h, req).Code
+	}
+	countStreams := func(status string) int {
+		t.Helper()
+		var n int
+		require.NoError(t, db.Pool.QueryRow(context.Background(),
+			"SELECT COUNT(*) FROM streams WHERE status = $1", status).Scan(&n))
+		return n
+	}
+
+	// A second encoder is rejected before the first one's ready webhook
+	assert.Equal(t, http.StatusOK, publish("conn-1"))
+	assert.Equal(t, http.StatusUnauthorized, publish("conn-2"))
+	assert.Equal(t, 1, countStreams("pending"))
+
+	// The reserving connection may authenticate again
+	assert.Equal(t, http.StatusOK, publish("conn-1"))
+
+	// The ready webhook activates the reservation
+	streamRepo := database.NewStreamRepo(db.Pool)
+	webhookHandler := handler.NewWebhookHandler(service.NewWebhookProcessor(streamRepo, database.NewStreamKeyRepo(db.Pool)), nil)
+	req := httptest.NewRequest(http.MethodPost, "/webhook/ready",
+		bytes.NewReader([]byte(`{"path":"`+keyValue+`","source_type":"rtmpConn","source_id":"conn-1"}`)))
+	recorder := httptest.NewRecorder()
+	webhookHandler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+
+	assert.Equal(t, 0, countStreams("pending"))
+	assert.Equal(t, 1, countStreams("active"))
+	assert.Equal(t, http.StatusUnauthorized, publish("conn-2"))
+
+	// An expired reservation no longer holds the key, and is swept
+	_, err := db.Pool.Exec(context.Background(), "UPDATE streams SET status = 'ended', ended_at = NOW()")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, publish("conn-3"))
+
+	_, err = db.Pool.Exec(context.Background(), "UPDATE streams SET reserved_until = NOW() - INTERVAL '1 second' WHERE status = 'pending'")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, publish("conn-4"))
+	assert.Equal(t, 1, countStreams("pending"))
+
+	_, err = db.Pool.Exec(context.Background(), "UPDATE streams SET reserved_until = NOW() - INTERVAL '1 second' WHERE status = 'pending'")
+	require.NoError(t, err)
+	require.NoError(t, service.NewReservationSweeper(streamRepo).Sweep(context.Background()))
+	assert.Equal(t, 0, countStreams("pending"))
+}
+
 // Helper functions
 
 func setupAuthHandler(t *testing.T, pool *pgxpool.Pool) *handler.AuthHandler {
 	t.Helper()
 
-	streamKeyRepo := database.NewStreamKeyRepo(pool)
-	streamRepo := database.NewStreamRepo(pool)
-	authService := service.NewAuthService(pool, streamKeyRepo, streamRepo)
+	authService := service.NewAuthService(database.NewUnitOfWork(pool))
 
 	return handler.NewAuthHandler(authService, nil)
 }
@@ -2,8 +2,13 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -31,28 +36,42 @@ func NewBroadcasterHandler(broadcasterService *service.BroadcasterService, logge
 
 // CreateBroadcasterRequest represents the request body for creating a broadcaster.
 type CreateBroadcasterRequest struct {
-	DisplayName string                 `json:"display_name"`
-	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	DisplayName string                   `json:"display_name"`
+	Metadata    map[string]interface{}   `json:"metadata,omitempty"`
+	Quotas      domain.BroadcasterQuotas `json:"quotas,omitempty"`
 }
 
 // UpdateBroadcasterRequest represents the request body for updating a broadcaster.
 type UpdateBroadcasterRequest struct {
-	DisplayName *string                `json:"display_name,omitempty"`
-	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	DisplayName *string                   `json:"display_name,omitempty"`
+	Metadata    map[string]interface{}    `json:"metadata,omitempty"`
+	Status      *domain.BroadcasterStatus `json:"status,omitempty"`
+	// Quotas replaces the broadcaster's quota overrides; omitted limits use
+	// the deployment default.
+	Quotas *domain.BroadcasterQuotas `json:"quotas,omitempty"`
 }
 
 // BroadcasterListResponse represents the response for listing broadcasters.
 type BroadcasterListResponse struct {
 	Broadcasters []domain.Broadcaster `json:"broadcasters"`
 	Count        int                  `json:"count"`
+	Total        int                  `json:"total"`
+	NextCursor   string               `json:"next_cursor,omitempty"`
 }
 
 // ServeHTTP routes broadcaster requests to the appropriate handler.
 func (h *BroadcasterHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
+	action := vars["action"]
 
 	switch {
+	case r.Method == http.MethodPost && id != "" && action == "restore":
+		h.restoreBroadcaster(w, r, id)
+	case r.Method == http.MethodPost && id != "" && action == "purge":
+		h.purgeBroadcaster(w, r, id)
+	case action != "":
+		WriteError(w, r, ErrNotFound("unknown broadcaster action"))
 	case r.Method == http.MethodGet && id == "":
 		h.listBroadcasters(w, r)
 	case r.Method == http.MethodPost && id == "":
@@ -69,13 +88,24 @@ func (h *BroadcasterHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *BroadcasterHandler) listBroadcasters(w http.ResponseWriter, r *http.Request) {
-	broadcasters, err := h.broadcasterService.List(r.Context())
+	opts, err := parseBroadcasterListOptions(r.URL.Query())
 	if err != nil {
+		WriteError(w, r, ErrInvalidRequest(err.Error()))
+		return
+	}
+
+	page, err := h.broadcasterService.List(r.Context(), opts)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCursor) {
+			WriteError(w, r, MapDomainError(err))
+			return
+		}
 		h.logger.Error("failed to list broadcasters", slog.String("error", err.Error()))
 		WriteError(w, r, ErrInternalServer("failed to list broadcasters"))
 		return
 	}
 
+	broadcasters := page.Broadcasters
 	if broadcasters == nil {
 		broadcasters = []domain.Broadcaster{}
 	}
@@ -83,11 +113,112 @@ func (h *BroadcasterHandler) listBroadcasters(w http.ResponseWriter, r *http.Req
 	resp := BroadcasterListResponse{
 		Broadcasters: broadcasters,
 		Count:        len(broadcasters),
+		Total:        page.Total,
+		NextCursor:   page.NextCursor,
 	}
 
 	WriteJSON(w, http.StatusOK, resp)
 }
 
+// parseBroadcasterListOptions parses listing query parameters:
+//
+//	q=<text>             case-insensitive search on display_name
+//	metadata.<path>=<v>  metadata containment, e.g. metadata.team=k9
+//	sort=<field>         display_name, created_at or updated_at; prefix with - for descending
+//	limit=<n>            page size
+//	cursor=<cursor>      next_cursor from the previous page
+//	status=<status>      offline, available, live or out_of_service
+//	include_archived=1   include archived broadcasters
+func parseBroadcasterListOptions(query url.Values) (domain.BroadcasterListOptions, error) {
+	opts := domain.BroadcasterListOptions{
+		Search:     strings.TrimSpace(query.Get("q")),
+		SortBy:     domain.BroadcasterSortCreatedAt,
+		Descending: true,
+		Cursor:     query.Get("cursor"),
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		opts.Descending = strings.HasPrefix(sort, "-")
+		opts.SortBy = domain.BroadcasterSortField(strings.TrimPrefix(sort, "-"))
+		if !opts.SortBy.IsValid() {
+			return opts, fmt.Errorf("invalid sort field %q", opts.SortBy)
+		}
+	}
+
+	if status := query.Get("status"); status != "" {
+		opts.Status = domain.BroadcasterStatus(status)
+		if !opts.Status.IsValid() {
+			return opts, fmt.Errorf("invalid status %q", status)
+		}
+	}
+
+	if includeStr := query.Get("include_archived"); includeStr != "" {
+		include, err := strconv.ParseBool(includeStr)
+		if err != nil {
+			return opts, errors.New("include_archived must be a boolean")
+		}
+		opts.IncludeArchived = include
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return opts, errors.New("limit must be a positive integer")
+		}
+		opts.Limit = limit
+	}
+
+	for key, values := range query {
+		if !strings.HasPrefix(key, "metadata.") {
+			continue
+		}
+
+		path := strings.Split(strings.TrimPrefix(key, "metadata."), ".")
+		if err := setMetadataFilter(&opts.Metadata, path, values[0]); err != nil {
+			return opts, fmt.Errorf("invalid metadata filter %q: %w", key, err)
+		}
+	}
+
+	return opts, nil
+}
+
+// setMetadataFilter sets value at the dotted path within the filter document,
+// so metadata.location.sector=4 becomes {"location": {"sector": "4"}}.
+func setMetadataFilter(filter *map[string]interface{}, path []string, value string) error {
+	if *filter == nil {
+		*filter = make(map[string]interface{})
+	}
+
+	node := *filter
+	for i, part := range path {
+		if part == "" {
+			return errors.New("empty path segment")
+		}
+
+		if i == len(path)-1 {
+			if _, exists := node[part]; exists {
+				return errors.New("conflicting filters")
+			}
+			node[part] = value
+			return nil
+		}
+
+		child, exists := node[part]
+		if !exists {
+			child = make(map[string]interface{})
+			node[part] = child
+		}
+
+		childMap, ok := child.(map[string]interface{})
+		if !ok {
+			return errors.New("conflicting filters")
+		}
+		node = childMap
+	}
+
+	return nil
+}
+
 func (h *BroadcasterHandler) createBroadcaster(w http.ResponseWriter, r *http.Request) {
 	var req CreateBroadcasterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -100,9 +231,15 @@ func (h *BroadcasterHandler) createBroadcaster(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	if !req.Quotas.IsValid() {
+		WriteError(w, r, ErrInvalidRequest("quotas must not be negative"))
+		return
+	}
+
 	broadcaster, err := h.broadcasterService.Create(r.Context(), service.CreateBroadcasterRequest{
 		DisplayName: req.DisplayName,
 		Metadata:    req.Metadata,
+		Quotas:      req.Quotas,
 	})
 	if err != nil {
 		httpErr := MapDomainError(err)
@@ -120,14 +257,14 @@ func (h *BroadcasterHandler) getBroadcaster(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	broadcaster, err := h.broadcasterService.GetByID(r.Context(), id)
+	detail, err := h.broadcasterService.GetDetail(r.Context(), id)
 	if err != nil {
 		httpErr := MapDomainError(err)
 		WriteError(w, r, httpErr)
 		return
 	}
 
-	WriteJSON(w, http.StatusOK, broadcaster)
+	WriteJSON(w, http.StatusOK, detail)
 }
 
 func (h *BroadcasterHandler) updateBroadcaster(w http.ResponseWriter, r *http.Request, idStr string) {
@@ -143,9 +280,21 @@ func (h *BroadcasterHandler) updateBroadcaster(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	if req.Status != nil && !req.Status.IsSettable() {
+		WriteError(w, r, ErrInvalidRequest("status must be offline, available or out_of_service"))
+		return
+	}
+
+	if req.Quotas != nil && !req.Quotas.IsValid() {
+		WriteError(w, r, ErrInvalidRequest("quotas must not be negative"))
+		return
+	}
+
 	broadcaster, err := h.broadcasterService.Update(r.Context(), id, service.UpdateBroadcasterRequest{
 		DisplayName: req.DisplayName,
 		Metadata:    req.Metadata,
+		Status:      req.Status,
+		Quotas:      req.Quotas,
 	})
 	if err != nil {
 		httpErr := MapDomainError(err)
@@ -163,7 +312,48 @@ func (h *BroadcasterHandler) deleteBroadcaster(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	if err := h.broadcasterService.Delete(r.Context(), id); err != nil {
+	if err := h.broadcasterService.Archive(r.Context(), id); err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *BroadcasterHandler) restoreBroadcaster(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid broadcaster ID"))
+		return
+	}
+
+	broadcaster, err := h.broadcasterService.Restore(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidStatus) {
+			WriteError(w, r, ErrConflict("broadcaster is not archived"))
+			return
+		}
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, broadcaster)
+}
+
+func (h *BroadcasterHandler) purgeBroadcaster(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid broadcaster ID"))
+		return
+	}
+
+	if err := h.broadcasterService.Purge(r.Context(), id); err != nil {
+		if errors.Is(err, domain.ErrInvalidStatus) {
+			WriteError(w, r, ErrConflict("broadcaster must be archived before it can be purged"))
+			return
+		}
 		httpErr := MapDomainError(err)
 		WriteError(w, r, httpErr)
 		return
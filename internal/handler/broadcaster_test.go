@@ -102,6 +102,124 @@ func TestBroadcasterHandler_List(t *testing.T) {
 	assert.Len(t, resp.Broadcasters, 2)
 }
 
+func TestBroadcasterHandler_List_Search(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create broadcasters
+	createTestBroadcaster(t, db.Pool, "K9 Team Alpha")
+	createTestBroadcaster(t, db.Pool, "Drone Unit")
+	createTestBroadcaster(t, db.Pool, "k9 team bravo")
+
+	// Setup handler
+	h := setupBroadcasterHandler(t, db.Pool)
+
+	// Execute
+	req := httptest.NewRequest(http.MethodGet, "/broadcasters?q=K9+team&sort=display_name", nil)
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp handler.BroadcasterListResponse
+	err := json.NewDecoder(recorder.Body).Decode(&resp)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, resp.Count)
+	assert.Equal(t, 2, resp.Total)
+	assert.Equal(t, "K9 Team Alpha", resp.Broadcasters[0].DisplayName)
+	assert.Equal(t, "k9 team bravo", resp.Broadcasters[1].DisplayName)
+}
+
+func TestBroadcasterHandler_List_MetadataFilter(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create broadcasters
+	createTestBroadcasterWithMetadata(t, db.Pool, "Handler 1", `{"team": "k9", "region": {"sector": "4"}}`)
+	createTestBroadcasterWithMetadata(t, db.Pool, "Handler 2", `{"team": "k9", "region": {"sector": "2"}}`)
+	createTestBroadcasterWithMetadata(t, db.Pool, "Pilot", `{"team": "drone"}`)
+
+	// Setup handler
+	h := setupBroadcasterHandler(t, db.Pool)
+
+	// Execute
+	req := httptest.NewRequest(http.MethodGet, "/broadcasters?metadata.team=k9&metadata.region.sector=4", nil)
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp handler.BroadcasterListResponse
+	err := json.NewDecoder(recorder.Body).Decode(&resp)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, resp.Count)
+	assert.Equal(t, "Handler 1", resp.Broadcasters[0].DisplayName)
+}
+
+func TestBroadcasterHandler_List_CursorPagination(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create broadcasters
+	createTestBroadcaster(t, db.Pool, "Charlie")
+	createTestBroadcaster(t, db.Pool, "Alpha")
+	createTestBroadcaster(t, db.Pool, "Bravo")
+
+	// Setup handler
+	h := setupBroadcasterHandler(t, db.Pool)
+
+	// Walk the pages
+	var names []string
+	target := "/broadcasters?sort=display_name&limit=2"
+	for page := 0; page < 3 && target != ""; page++ {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, req)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var resp handler.BroadcasterListResponse
+		err := json.NewDecoder(recorder.Body).Decode(&resp)
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, resp.Total)
+		for _, b := range resp.Broadcasters {
+			names = append(names, b.DisplayName)
+		}
+
+		target = ""
+		if resp.NextCursor != "" {
+			target = "/broadcasters?sort=display_name&limit=2&cursor=" + resp.NextCursor
+		}
+	}
+
+	assert.Equal(t, []string{"Alpha", "Bravo", "Charlie"}, names)
+}
+
+func TestBroadcasterHandler_List_InvalidParameters(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Setup handler
+	h := setupBroadcasterHandler(t, db.Pool)
+
+	for _, target := range []string{
+		"/broadcasters?sort=metadata",
+		"/broadcasters?limit=0",
+		"/broadcasters?cursor=not-a-cursor",
+	} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, target)
+	}
+}
+
 func TestBroadcasterHandler_GetByID(t *testing.T) {
 	db := testutil.SetupTestDatabase(t)
 	defer db.Cleanup(t)
@@ -131,6 +249,47 @@ func TestBroadcasterHandler_GetByID(t *testing.T) {
 	assert.Equal(t, "Test Broadcaster", resp.DisplayName)
 }
 
+func TestBroadcasterHandler_GetByID_Detail(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create broadcaster with an active key, a revoked key and a live stream
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Live Broadcaster")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+	createTestStreamKey(t, db.Pool, broadcasterID, "revoked", nil)
+
+	var keyID uuid.UUID
+	err := db.Pool.QueryRow(context.Background(), "SELECT id FROM stream_keys WHERE key_value = $1", keyValue).Scan(&keyID)
+	require.NoError(t, err)
+	createTestStream(t, db.Pool, keyID, "earlier-stream", "ended")
+	streamID := createTestStream(t, db.Pool, keyID, "live-stream", "active")
+
+	h := setupBroadcasterHandler(t, db.Pool)
+
+	router := mux.NewRouter()
+	router.Handle("/broadcasters/{id}", h)
+
+	// Execute
+	req := httptest.NewRequest(http.MethodGet, "/broadcasters/"+broadcasterID.String(), nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp domain.BroadcasterDetail
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+
+	assert.Equal(t, domain.BroadcasterStatusLive, resp.Status)
+	assert.NotNil(t, resp.LastSeenAt)
+	require.NotNil(t, resp.LiveStream)
+	assert.Equal(t, streamID, resp.LiveStream.ID)
+	require.Len(t, resp.ActiveKeys, 1)
+	assert.Equal(t, keyID, resp.ActiveKeys[0].ID)
+	assert.Empty(t, resp.ActiveKeys[0].KeyValue)
+	assert.Equal(t, 2, resp.RecentStreamCount)
+}
+
 func TestBroadcasterHandler_GetByID_NotFound(t *testing.T) {
 	db := testutil.SetupTestDatabase(t)
 	defer db.Cleanup(t)
@@ -188,12 +347,60 @@ func TestBroadcasterHandler_Update(t *testing.T) {
 	assert.Equal(t, "Updated Name", resp.DisplayName)
 }
 
+func TestBroadcasterHandler_UpdateStatus(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	availableID := createTestBroadcaster(t, db.Pool, "Available")
+	createTestBroadcaster(t, db.Pool, "Offline")
+
+	h := setupBroadcasterHandler(t, db.Pool)
+
+	router := mux.NewRouter()
+	router.Handle("/broadcasters", h)
+	router.Handle("/broadcasters/{id}", h)
+
+	// Set status
+	req := httptest.NewRequest(http.MethodPatch, "/broadcasters/"+availableID.String(), bytes.NewReader([]byte(`{"status":"available"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp domain.Broadcaster
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+	assert.Equal(t, domain.BroadcasterStatusAvailable, resp.Status)
+
+	// Live is computed and cannot be set
+	req = httptest.NewRequest(http.MethodPatch, "/broadcasters/"+availableID.String(), bytes.NewReader([]byte(`{"status":"live"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Filter by status
+	req = httptest.NewRequest(http.MethodGet, "/broadcasters?status=available", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var list handler.BroadcasterListResponse
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&list))
+	require.Equal(t, 1, list.Count)
+	assert.Equal(t, availableID, list.Broadcasters[0].ID)
+}
+
 func TestBroadcasterHandler_Delete(t *testing.T) {
 	db := testutil.SetupTestDatabase(t)
 	defer db.Cleanup(t)
 
-	// Create broadcaster
+	// Create broadcaster with an active key and a live stream
 	broadcasterID := createTestBroadcaster(t, db.Pool, "To Be Deleted")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+	var keyID uuid.UUID
+	err := db.Pool.QueryRow(context.Background(), "SELECT id FROM stream_keys WHERE key_value = $1", keyValue).Scan(&keyID)
+	require.NoError(t, err)
+	streamID := createTestStream(t, db.Pool, keyID, "to-be-deleted", "active")
 
 	// Setup handler with mux router
 	h := setupBroadcasterHandler(t, db.Pool)
@@ -209,11 +416,113 @@ func TestBroadcasterHandler_Delete(t *testing.T) {
 	// Assert
 	assert.Equal(t, http.StatusNoContent, recorder.Code)
 
-	// Verify it's deleted
+	// Verify it's archived rather than deleted
+	var archived bool
+	err = db.Pool.QueryRow(context.Background(), "SELECT archived_at IS NOT NULL FROM broadcasters WHERE id = $1", broadcasterID).Scan(&archived)
+	require.NoError(t, err)
+	assert.True(t, archived)
+
+	// Verify the key was revoked and the stream ended
+	var keyStatus, streamStatus string
+	err = db.Pool.QueryRow(context.Background(), "SELECT status FROM stream_keys WHERE id = $1", keyID).Scan(&keyStatus)
+	require.NoError(t, err)
+	assert.Equal(t, "revoked", keyStatus)
+
+	err = db.Pool.QueryRow(context.Background(), "SELECT status FROM streams WHERE id = $1", streamID).Scan(&streamStatus)
+	require.NoError(t, err)
+	assert.Equal(t, "ended", streamStatus)
+
+	// Verify it's excluded from listings by default
+	listReq := httptest.NewRequest(http.MethodGet, "/broadcasters", nil)
+	listRecorder := httptest.NewRecorder()
+	h.ServeHTTP(listRecorder, listReq)
+
+	var list handler.BroadcasterListResponse
+	require.NoError(t, json.NewDecoder(listRecorder.Body).Decode(&list))
+	assert.Equal(t, 0, list.Count)
+
+	listReq = httptest.NewRequest(http.MethodGet, "/broadcasters?include_archived=true", nil)
+	listRecorder = httptest.NewRecorder()
+	h.ServeHTTP(listRecorder, listReq)
+
+	require.NoError(t, json.NewDecoder(listRecorder.Body).Decode(&list))
+	require.Equal(t, 1, list.Count)
+	assert.NotNil(t, list.Broadcasters[0].ArchivedAt)
+}
+
+func TestBroadcasterHandler_Restore(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	broadcasterID := createTestBroadcaster(t, db.Pool, "To Be Restored")
+
+	h := setupBroadcasterHandler(t, db.Pool)
+
+	router := mux.NewRouter()
+	router.Handle("/broadcasters/{id}", h)
+	router.Handle("/broadcasters/{id}/{action}", h)
+
+	// Restoring an active broadcaster is a conflict
+	req := httptest.NewRequest(http.MethodPost, "/broadcasters/"+broadcasterID.String()+"/restore", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	// Archive, then restore
+	req = httptest.NewRequest(http.MethodDelete, "/broadcasters/"+broadcasterID.String(), nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/broadcasters/"+broadcasterID.String()+"/restore", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp domain.Broadcaster
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+	assert.Equal(t, broadcasterID, resp.ID)
+	assert.Nil(t, resp.ArchivedAt)
+}
+
+func TestBroadcasterHandler_Purge(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	broadcasterID := createTestBroadcaster(t, db.Pool, "To Be Purged")
+	createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+	h := setupBroadcasterHandler(t, db.Pool)
+
+	router := mux.NewRouter()
+	router.Handle("/broadcasters/{id}", h)
+	router.Handle("/broadcasters/{id}/{action}", h)
+
+	// Purging requires the broadcaster to be archived first
+	req := httptest.NewRequest(http.MethodPost, "/broadcasters/"+broadcasterID.String()+"/purge", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/broadcasters/"+broadcasterID.String(), nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/broadcasters/"+broadcasterID.String()+"/purge", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	// Verify the broadcaster and its keys are gone
 	var count int
 	err := db.Pool.QueryRow(context.Background(), "SELECT COUNT(*) FROM broadcasters WHERE id = $1", broadcasterID).Scan(&count)
 	require.NoError(t, err)
 	assert.Equal(t, 0, count)
+
+	err = db.Pool.QueryRow(context.Background(), "SELECT COUNT(*) FROM stream_keys WHERE broadcaster_id = $1", broadcasterID).Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
 }
 
 func TestBroadcasterHandler_Delete_NotFound(t *testing.T) {
@@ -240,7 +549,31 @@ func setupBroadcasterHandler(t *testing.T, pool *pgxpool.Pool) *handler.Broadcas
 	t.Helper()
 
 	broadcasterRepo := database.NewBroadcasterRepo(pool)
-	broadcasterService := service.NewBroadcasterService(broadcasterRepo)
+	mediaMTXClient, err := service.NewMediaMTXClient("http://localhost:9997", service.PublicEndpoints{
+		WebRTC: "http://localhost:8889",
+	})
+	require.NoError(t, err)
+	streamRepo := database.NewStreamRepo(pool)
+	streamKeyService := service.NewStreamKeyService(
+		database.NewStreamKeyRepo(pool),
+		streamRepo,
+		broadcasterRepo,
+		mediaMTXClient,
+		database.NewUnitOfWork(pool),
+	)
+	broadcasterService := service.NewBroadcasterService(broadcasterRepo, streamRepo, streamKeyService)
 
 	return handler.NewBroadcasterHandler(broadcasterService, nil)
 }
+
+func createTestBroadcasterWithMetadata(t *testing.T, pool *pgxpool.Pool, displayName, metadata string) uuid.UUID {
+	t.Helper()
+
+	id := uuid.New()
+	_, err := pool.Exec(context.Background(),
+		"INSERT INTO broadcasters (id, display_name, metadata, created_at, updated_at) VALUES ($1, $2, $3, NOW(), NOW())",
+		id, displayName, metadata)
+	require.NoError(t, err)
+
+	return id
+}
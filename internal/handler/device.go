@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+	"github.com/searchandrescuegg/rescuestream-api/internal/service"
+)
+
+// maxDeviceFieldLength is the longest device serial or model that may be set.
+const maxDeviceFieldLength = 255
+
+// DeviceHandler handles device HTTP requests.
+type DeviceHandler struct {
+	deviceService *service.DeviceService
+	logger        *slog.Logger
+}
+
+// NewDeviceHandler creates a new DeviceHandler.
+func NewDeviceHandler(deviceService *service.DeviceService, logger *slog.Logger) *DeviceHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &DeviceHandler{
+		deviceService: deviceService,
+		logger:        logger,
+	}
+}
+
+// CreateDeviceRequest represents the request body for registering a device.
+type CreateDeviceRequest struct {
+	BroadcasterID string            `json:"broadcaster_id"`
+	Type          domain.DeviceType `json:"type"`
+	Serial        *string           `json:"serial,omitempty"`
+	Model         *string           `json:"model,omitempty"`
+	Capabilities  []string          `json:"capabilities,omitempty"`
+}
+
+// UpdateDeviceRequest represents the request body for updating a device. An
+// empty serial or model clears it.
+type UpdateDeviceRequest struct {
+	Type         *domain.DeviceType `json:"type,omitempty"`
+	Serial       *string            `json:"serial,omitempty"`
+	Model        *string            `json:"model,omitempty"`
+	Capabilities *[]string          `json:"capabilities,omitempty"`
+}
+
+// DeviceListResponse represents the response for listing devices.
+type DeviceListResponse struct {
+	Devices []domain.Device `json:"devices"`
+	Count   int             `json:"count"`
+}
+
+// ServeHTTP routes device requests to the appropriate handler.
+func (h *DeviceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		h.listDevices(w, r)
+	case r.Method == http.MethodPost && id == "":
+		h.createDevice(w, r)
+	case r.Method == http.MethodGet && id != "":
+		h.getDevice(w, r, id)
+	case r.Method == http.MethodPatch && id != "":
+		h.updateDevice(w, r, id)
+	case r.Method == http.MethodDelete && id != "":
+		h.deleteDevice(w, r, id)
+	default:
+		WriteError(w, r, ErrInvalidRequest("method not allowed"))
+	}
+}
+
+func (h *DeviceHandler) createDevice(w http.ResponseWriter, r *http.Request) {
+	var req CreateDeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid request body"))
+		return
+	}
+
+	broadcasterID, err := uuid.Parse(req.BroadcasterID)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid broadcaster_id"))
+		return
+	}
+
+	if !req.Type.IsValid() {
+		WriteError(w, r, ErrInvalidRequest("type must be drone, bodycam, vehicle, handheld, fixed or other"))
+		return
+	}
+
+	if msg := validateDeviceFields(req.Serial, req.Model); msg != "" {
+		WriteError(w, r, ErrInvalidRequest(msg))
+		return
+	}
+
+	device, err := h.deviceService.Create(r.Context(), service.CreateDeviceRequest{
+		BroadcasterID: broadcasterID,
+		Type:          req.Type,
+		Serial:        req.Serial,
+		Model:         req.Model,
+		Capabilities:  req.Capabilities,
+	})
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, device)
+}
+
+// listDevices lists devices, optionally filtered with ?broadcaster_id= and ?type=.
+func (h *DeviceHandler) listDevices(w http.ResponseWriter, r *http.Request) {
+	var filter domain.DeviceFilter
+
+	if broadcasterID := r.URL.Query().Get("broadcaster_id"); broadcasterID != "" {
+		id, err := uuid.Parse(broadcasterID)
+		if err != nil {
+			WriteError(w, r, ErrInvalidRequest("invalid broadcaster_id"))
+			return
+		}
+		filter.BroadcasterID = &id
+	}
+
+	if deviceType := domain.DeviceType(r.URL.Query().Get("type")); deviceType != "" {
+		if !deviceType.IsValid() {
+			WriteError(w, r, ErrInvalidRequest("invalid type"))
+			return
+		}
+		filter.Type = deviceType
+	}
+
+	devices, err := h.deviceService.List(r.Context(), filter)
+	if err != nil {
+		h.logger.Error("failed to list devices", slog.String("error", err.Error()))
+		WriteError(w, r, ErrInternalServer("failed to list devices"))
+		return
+	}
+
+	if devices == nil {
+		devices = []domain.Device{}
+	}
+
+	WriteJSON(w, http.StatusOK, DeviceListResponse{
+		Devices: devices,
+		Count:   len(devices),
+	})
+}
+
+func (h *DeviceHandler) getDevice(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid device ID"))
+		return
+	}
+
+	device, err := h.deviceService.GetByID(r.Context(), id)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, device)
+}
+
+func (h *DeviceHandler) updateDevice(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid device ID"))
+		return
+	}
+
+	var req UpdateDeviceRequest
+	if decodeErr := json.NewDecoder(r.Body).Decode(&req); decodeErr != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid request body"))
+		return
+	}
+
+	if req.Type != nil && !req.Type.IsValid() {
+		WriteError(w, r, ErrInvalidRequest("type must be drone, bodycam, vehicle, handheld, fixed or other"))
+		return
+	}
+
+	if msg := validateDeviceFields(req.Serial, req.Model); msg != "" {
+		WriteError(w, r, ErrInvalidRequest(msg))
+		return
+	}
+
+	device, err := h.deviceService.Update(r.Context(), id, service.UpdateDeviceRequest{
+		Type:         req.Type,
+		Serial:       req.Serial,
+		Model:        req.Model,
+		Capabilities: req.Capabilities,
+	})
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, device)
+}
+
+func (h *DeviceHandler) deleteDevice(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid device ID"))
+		return
+	}
+
+	if err := h.deviceService.Delete(r.Context(), id); err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateDeviceFields returns a message describing the first invalid field,
+// or an empty string.
+func validateDeviceFields(serial, model *string) string {
+	if serial != nil && utf8.RuneCountInString(*serial) > maxDeviceFieldLength {
+		return fmt.Sprintf("serial must be at most %d characters", maxDeviceFieldLength)
+	}
+	if model != nil && utf8.RuneCountInString(*model) > maxDeviceFieldLength {
+		return fmt.Sprintf("model must be at most %d characters", maxDeviceFieldLength)
+	}
+	return ""
+}
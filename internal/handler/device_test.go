@@ -0,0 +1,205 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/database"
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+	"github.com/searchandrescuegg/rescuestream-api/internal/handler"
+	"github.com/searchandrescuegg/rescuestream-api/internal/service"
+	"github.com/searchandrescuegg/rescuestream-api/internal/testutil"
+)
+
+func TestDevices_StreamsRecordDeviceOfTheirKey(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	router := setupDeviceRouter(t, db.Pool)
+
+	pilotID := createTestBroadcaster(t, db.Pool, "Drone Pilot")
+	k9ID := createTestBroadcaster(t, db.Pool, "K9 Handler")
+
+	drone := createTestDevice(t, router, `{"broadcaster_id": "`+pilotID.String()+`", "type": "drone", "serial": "DJI-001", "capabilities": ["Thermal", "zoom"]}`)
+	assert.Equal(t, []string{"thermal", "zoom"}, drone.Capabilities)
+	bodycam := createTestDevice(t, router, `{"broadcaster_id": "`+k9ID.String()+`", "type": "bodycam"}`)
+
+	// Serials are unique within an organization
+	req := httptest.NewRequest(http.MethodPost, "/devices", strings.NewReader(`{"broadcaster_id": "`+k9ID.String()+`", "type": "drone", "serial": "DJI-001"}`))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	// A key cannot be bound to another broadcaster's device
+	body := `{"broadcaster_id": "` + pilotID.String() + `", "device_id": "` + bodycam.ID.String() + `"}`
+	req = httptest.NewRequest(http.MethodPost, "/stream-keys", strings.NewReader(body))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	droneKey := createTestDeviceKey(t, router, pilotID, drone.ID)
+	createTestDeviceKey(t, router, k9ID, bodycam.ID)
+
+	req = httptest.NewRequest(http.MethodPost, "/webhook/ready", strings.NewReader(`{"path":"`+droneKey.KeyValue+`","source_type":"rtmpConn","source_id":"conn-1"}`))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// A stream from an unbound key matches no device filter
+	k9KeyID := streamKeyIDByValue(t, db.Pool, createTestStreamKey(t, db.Pool, k9ID, "active", nil))
+	createTestStream(t, db.Pool, k9KeyID, "k9-cam", "active")
+
+	req = httptest.NewRequest(http.MethodGet, "/streams?device_type=drone", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var streams handler.StreamListResponse
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&streams))
+	require.Equal(t, 1, streams.Count)
+	require.NotNil(t, streams.Streams[0].DeviceID)
+	assert.Equal(t, drone.ID, *streams.Streams[0].DeviceID)
+
+	req = httptest.NewRequest(http.MethodGet, "/streams?device_type=satellite", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Unbinding the key keeps the device on the stream already started
+	req = httptest.NewRequest(http.MethodPatch, "/stream-keys/"+droneKey.ID.String(), strings.NewReader(`{"device_id": null}`))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var key domain.StreamKey
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&key))
+	assert.Nil(t, key.DeviceID)
+
+	req = httptest.NewRequest(http.MethodGet, "/streams?device_id="+drone.ID.String(), nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&streams))
+	assert.Equal(t, 1, streams.Count)
+}
+
+func TestDevices_ListFiltersAndDelete(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	router := setupDeviceRouter(t, db.Pool)
+
+	pilotID := createTestBroadcaster(t, db.Pool, "Drone Pilot")
+	k9ID := createTestBroadcaster(t, db.Pool, "K9 Handler")
+
+	drone := createTestDevice(t, router, `{"broadcaster_id": "`+pilotID.String()+`", "type": "drone"}`)
+	createTestDevice(t, router, `{"broadcaster_id": "`+pilotID.String()+`", "type": "handheld"}`)
+	createTestDevice(t, router, `{"broadcaster_id": "`+k9ID.String()+`", "type": "drone"}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/devices", strings.NewReader(`{"broadcaster_id": "`+k9ID.String()+`", "type": "satellite"}`))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{"", 3},
+		{"?type=drone", 2},
+		{"?broadcaster_id=" + pilotID.String(), 2},
+		{"?broadcaster_id=" + pilotID.String() + "&type=drone", 1},
+	}
+	for _, tt := range tests {
+		req = httptest.NewRequest(http.MethodGet, "/devices"+tt.query, nil)
+		recorder = httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var devices handler.DeviceListResponse
+		require.NoError(t, json.NewDecoder(recorder.Body).Decode(&devices))
+		assert.Equal(t, tt.expected, devices.Count, tt.query)
+	}
+
+	key := createTestDeviceKey(t, router, pilotID, drone.ID)
+
+	// Deleting a device unbinds its keys
+	req = httptest.NewRequest(http.MethodDelete, "/devices/"+drone.ID.String(), nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+
+	var deviceID *uuid.UUID
+	require.NoError(t, db.Pool.QueryRow(context.Background(),
+		"SELECT device_id FROM stream_keys WHERE id = $1", key.ID).Scan(&deviceID))
+	assert.Nil(t, deviceID)
+
+	req = httptest.NewRequest(http.MethodGet, "/devices/"+drone.ID.String(), nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func createTestDevice(t *testing.T, router *mux.Router, body string) domain.Device {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/devices", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var device domain.Device
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&device))
+	return device
+}
+
+func createTestDeviceKey(t *testing.T, router *mux.Router, broadcasterID, deviceID uuid.UUID) domain.StreamKey {
+	t.Helper()
+
+	body := `{"broadcaster_id": "` + broadcasterID.String() + `", "device_id": "` + deviceID.String() + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/stream-keys", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var key domain.StreamKey
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&key))
+	require.NotNil(t, key.DeviceID)
+	assert.Equal(t, deviceID, *key.DeviceID)
+	return key
+}
+
+func setupDeviceRouter(t *testing.T, pool *pgxpool.Pool) *mux.Router {
+	t.Helper()
+
+	mediaMTXClient, err := service.NewMediaMTXClient("http://localhost:9997", service.PublicEndpoints{
+		WebRTC: "http://localhost:8889",
+	})
+	require.NoError(t, err)
+	broadcasterRepo := database.NewBroadcasterRepo(pool)
+	streamRepo := database.NewStreamRepo(pool)
+	streamKeyRepo := database.NewStreamKeyRepo(pool)
+	deviceHandler := handler.NewDeviceHandler(service.NewDeviceService(database.NewDeviceRepo(pool), broadcasterRepo), nil)
+	streamKeyHandler := handler.NewStreamKeyHandler(service.NewStreamKeyService(streamKeyRepo, streamRepo, broadcasterRepo, mediaMTXClient, database.NewUnitOfWork(pool)), nil)
+	streamHandler := handler.NewStreamHandler(service.NewStreamService(streamRepo, mediaMTXClient), nil)
+	webhookHandler := handler.NewWebhookHandler(service.NewWebhookProcessor(streamRepo, streamKeyRepo), nil)
+
+	router := mux.NewRouter()
+	router.Handle("/devices", deviceHandler)
+	router.Handle("/devices/{id}", deviceHandler)
+	router.Handle("/stream-keys", streamKeyHandler)
+	router.Handle("/stream-keys/{id}", streamKeyHandler)
+	router.Handle("/streams", streamHandler)
+	router.Handle("/webhook/ready", webhookHandler)
+	return router
+}
@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+	"github.com/searchandrescuegg/rescuestream-api/internal/service"
+)
+
+// maxEnrollmentUserAgentLength bounds the User-Agent recorded per redemption.
+const maxEnrollmentUserAgentLength = 512
+
+// EnrollmentTokenHandler handles enrollment token management HTTP requests.
+type EnrollmentTokenHandler struct {
+	enrollmentService *service.EnrollmentService
+	logger            *slog.Logger
+}
+
+// NewEnrollmentTokenHandler creates a new EnrollmentTokenHandler.
+func NewEnrollmentTokenHandler(enrollmentService *service.EnrollmentService, logger *slog.Logger) *EnrollmentTokenHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &EnrollmentTokenHandler{
+		enrollmentService: enrollmentService,
+		logger:            logger,
+	}
+}
+
+// CreateEnrollmentTokenRequest represents the request body for minting an
+// enrollment token.
+type CreateEnrollmentTokenRequest struct {
+	Label           *string                `json:"label,omitempty"`
+	MaxUses         *int                   `json:"max_uses,omitempty"`
+	ExpiresAt       *string                `json:"expires_at,omitempty"`
+	DefaultMetadata map[string]interface{} `json:"default_metadata,omitempty"`
+	KeyTTLSeconds   *int                   `json:"key_ttl_seconds,omitempty"`
+}
+
+// EnrollmentTokenListResponse represents the response for listing enrollment tokens.
+type EnrollmentTokenListResponse struct {
+	EnrollmentTokens []domain.EnrollmentToken `json:"enrollment_tokens"`
+	Count            int                      `json:"count"`
+}
+
+// ServeHTTP routes enrollment token requests to the appropriate handler.
+func (h *EnrollmentTokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		h.listTokens(w, r)
+	case r.Method == http.MethodPost && id == "":
+		h.createToken(w, r)
+	case r.Method == http.MethodGet && id != "":
+		h.getToken(w, r, id)
+	case r.Method == http.MethodDelete && id != "":
+		h.revokeToken(w, r, id)
+	default:
+		WriteError(w, r, ErrInvalidRequest("method not allowed"))
+	}
+}
+
+func (h *EnrollmentTokenHandler) createToken(w http.ResponseWriter, r *http.Request) {
+	var req CreateEnrollmentTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid request body"))
+		return
+	}
+
+	if req.Label != nil && utf8.RuneCountInString(*req.Label) > service.MaxStreamKeyLabelLength {
+		WriteError(w, r, ErrInvalidRequest(fmt.Sprintf("label must be at most %d characters", service.MaxStreamKeyLabelLength)))
+		return
+	}
+
+	if req.MaxUses != nil && *req.MaxUses < 1 {
+		WriteError(w, r, ErrInvalidRequest("max_uses must be at least 1"))
+		return
+	}
+
+	createReq := service.CreateEnrollmentTokenRequest{
+		Label:           req.Label,
+		MaxUses:         req.MaxUses,
+		DefaultMetadata: req.DefaultMetadata,
+	}
+
+	if req.KeyTTLSeconds != nil {
+		if *req.KeyTTLSeconds < 1 {
+			WriteError(w, r, ErrInvalidRequest("key_ttl_seconds must be at least 1"))
+			return
+		}
+		ttl := time.Duration(*req.KeyTTLSeconds) * time.Second
+		createReq.KeyTTL = &ttl
+	}
+
+	if req.ExpiresAt != nil {
+		expiresAt, err := time.Parse(time.RFC3339, *req.ExpiresAt)
+		if err != nil {
+			WriteError(w, r, ErrInvalidRequest("invalid expires_at format, use RFC3339"))
+			return
+		}
+		if !expiresAt.After(time.Now()) {
+			WriteError(w, r, ErrInvalidRequest("expires_at must be in the future"))
+			return
+		}
+		createReq.ExpiresAt = &expiresAt
+	}
+
+	token, err := h.enrollmentService.CreateToken(r.Context(), createReq)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusCreated, token)
+}
+
+func (h *EnrollmentTokenHandler) listTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.enrollmentService.List(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list enrollment tokens", slog.String("error", err.Error()))
+		WriteError(w, r, ErrInternalServer("failed to list enrollment tokens"))
+		return
+	}
+
+	if tokens == nil {
+		tokens = []domain.EnrollmentToken{}
+	}
+
+	WriteJSON(w, http.StatusOK, EnrollmentTokenListResponse{
+		EnrollmentTokens: tokens,
+		Count:            len(tokens),
+	})
+}
+
+func (h *EnrollmentTokenHandler) getToken(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid enrollment token ID"))
+		return
+	}
+
+	detail, err := h.enrollmentService.Get(r.Context(), id)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, detail)
+}
+
+func (h *EnrollmentTokenHandler) revokeToken(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid enrollment token ID"))
+		return
+	}
+
+	if err := h.enrollmentService.Revoke(r.Context(), id); err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EnrollHandler handles public device enrollment requests.
+type EnrollHandler struct {
+	enrollmentService *service.EnrollmentService
+	logger            *slog.Logger
+}
+
+// NewEnrollHandler creates a new EnrollHandler.
+func NewEnrollHandler(enrollmentService *service.EnrollmentService, logger *slog.Logger) *EnrollHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &EnrollHandler{
+		enrollmentService: enrollmentService,
+		logger:            logger,
+	}
+}
+
+// EnrollRequest represents the request body a device sends to enroll.
+type EnrollRequest struct {
+	Token       string                 `json:"token"`
+	DisplayName string                 `json:"display_name"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ServeHTTP redeems an enrollment token, creating a broadcaster and stream key.
+func (h *EnrollHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteError(w, r, ErrInvalidRequest("method not allowed"))
+		return
+	}
+
+	var req EnrollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid request body"))
+		return
+	}
+
+	if req.Token == "" {
+		WriteError(w, r, ErrInvalidRequest("token is required"))
+		return
+	}
+
+	if req.DisplayName == "" {
+		WriteError(w, r, ErrInvalidRequest("display_name is required"))
+		return
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxEnrollmentUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxEnrollmentUserAgentLength], "")
+	}
+
+	enrollment, err := h.enrollmentService.Redeem(r.Context(), service.RedeemRequest{
+		Token:       req.Token,
+		DisplayName: req.DisplayName,
+		Metadata:    req.Metadata,
+		IP:          ip,
+		UserAgent:   userAgent,
+	})
+	if err != nil {
+		// Unknown tokens are rejected the same way as unusable ones
+		if errors.Is(err, domain.ErrNotFound) {
+			err = domain.ErrEnrollmentTokenUnusable
+		}
+		if !errors.Is(err, domain.ErrEnrollmentTokenUnusable) && !errors.Is(err, domain.ErrLockedOut) {
+			h.logger.Error("failed to redeem enrollment token", slog.String("error", err.Error()))
+		} else {
+			h.logger.Warn("enrollment rejected",
+				slog.String("ip", ip),
+				slog.String("reason", err.Error()),
+			)
+		}
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusCreated, enrollment)
+}
@@ -0,0 +1,193 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/database"
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+	"github.com/searchandrescuegg/rescuestream-api/internal/handler"
+	"github.com/searchandrescuegg/rescuestream-api/internal/service"
+	"github.com/searchandrescuegg/rescuestream-api/internal/testutil"
+)
+
+func TestEnrollment_RedeemCreatesBroadcasterAndKey(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	router := setupEnrollmentRouter(t, db.Pool)
+
+	// Mint a single-use token with default metadata and a key TTL
+	body := `{"label": "Op Ridgeline", "max_uses": 1, "default_metadata": {"team": "k9", "region": "north"}, "key_ttl_seconds": 3600}`
+	req := httptest.NewRequest(http.MethodPost, "/enrollment-tokens", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var token domain.EnrollmentToken
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&token))
+	require.True(t, strings.HasPrefix(token.Token, "et_"))
+
+	// A device redeems it
+	body = `{"token": "` + token.Token + `", "display_name": "Drone 7", "metadata": {"region": "south"}}`
+	req = httptest.NewRequest(http.MethodPost, "/enroll", strings.NewReader(body))
+	req.Header.Set("User-Agent", "larix/1.0")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var enrollment service.Enrollment
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&enrollment))
+	assert.Equal(t, "Drone 7", enrollment.Broadcaster.DisplayName)
+	assert.Equal(t, "k9", enrollment.Broadcaster.Metadata["team"])
+	assert.Equal(t, "south", enrollment.Broadcaster.Metadata["region"], "device metadata overrides defaults")
+	assert.True(t, strings.HasPrefix(enrollment.StreamKey.KeyValue, "sk_"))
+	assert.Equal(t, "rtmp://localhost:1935/"+enrollment.StreamKey.KeyValue, enrollment.StreamKey.IngestURLs.RTMP)
+	require.NotNil(t, enrollment.StreamKey.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *enrollment.StreamKey.ExpiresAt, time.Minute)
+
+	var keyBroadcaster string
+	require.NoError(t, db.Pool.QueryRow(context.Background(),
+		"SELECT broadcaster_id::text FROM stream_keys WHERE key_value = $1", enrollment.StreamKey.KeyValue,
+	).Scan(&keyBroadcaster))
+	assert.Equal(t, enrollment.Broadcaster.ID.String(), keyBroadcaster)
+
+	// The token is used up
+	body = `{"token": "` + token.Token + `", "display_name": "Drone 8"}`
+	req = httptest.NewRequest(http.MethodPost, "/enroll", strings.NewReader(body))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// The redemption is recorded
+	req = httptest.NewRequest(http.MethodGet, "/enrollment-tokens/"+token.ID.String(), nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var detail service.EnrollmentTokenDetail
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&detail))
+	assert.Empty(t, detail.Token, "token values are only returned on creation")
+	assert.Equal(t, 1, detail.UseCount)
+	require.Len(t, detail.Redemptions, 1)
+	assert.Equal(t, "Drone 7", detail.Redemptions[0].DisplayName)
+	assert.Equal(t, "larix/1.0", detail.Redemptions[0].UserAgent)
+	require.NotNil(t, detail.Redemptions[0].BroadcasterID)
+	assert.Equal(t, enrollment.Broadcaster.ID, *detail.Redemptions[0].BroadcasterID)
+}
+
+func TestEnrollment_RejectsUnusableTokens(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	router := setupEnrollmentRouter(t, db.Pool)
+
+	req := httptest.NewRequest(http.MethodPost, "/enrollment-tokens", strings.NewReader(`{}`))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var token domain.EnrollmentToken
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&token))
+
+	// Unknown tokens are rejected
+	req = httptest.NewRequest(http.MethodPost, "/enroll", strings.NewReader(`{"token": "et_unknown", "display_name": "Drone 7"}`))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// Revoked tokens are rejected
+	req = httptest.NewRequest(http.MethodDelete, "/enrollment-tokens/"+token.ID.String(), nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/enroll", strings.NewReader(`{"token": "`+token.Token+`", "display_name": "Drone 7"}`))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	var broadcasters int
+	require.NoError(t, db.Pool.QueryRow(context.Background(), "SELECT COUNT(*) FROM broadcasters").Scan(&broadcasters))
+	assert.Equal(t, 0, broadcasters)
+}
+
+func TestEnrollment_LockoutAfterRepeatedFailures(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	lockoutService := service.NewLockoutService(database.NewAuthLockoutRepo(db.Pool),
+		service.WithLockoutPolicy(service.LockoutPolicy{
+			MaxFailures:  3,
+			Window:       time.Minute,
+			BaseDuration: time.Minute,
+			MaxDuration:  time.Hour,
+		}),
+	)
+	router := setupEnrollmentRouter(t, db.Pool, service.WithEnrollmentLockoutService(lockoutService))
+
+	req := httptest.NewRequest(http.MethodPost, "/enrollment-tokens", strings.NewReader(`{}`))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var token domain.EnrollmentToken
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&token))
+
+	enroll := func(ip, tokenValue string) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/enroll", strings.NewReader(`{"token": "`+tokenValue+`", "display_name": "Drone 7"}`))
+		req.RemoteAddr = ip + ":5000"
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	// Guessing tokens from one IP locks it out
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusUnauthorized, enroll("203.0.113.7", "et_guess"))
+	}
+
+	// Even a valid token is refused from the locked out IP
+	assert.Equal(t, http.StatusTooManyRequests, enroll("203.0.113.7", token.Token))
+
+	// Other IPs are not affected
+	assert.Equal(t, http.StatusCreated, enroll("203.0.113.8", token.Token))
+}
+
+func setupEnrollmentRouter(t *testing.T, pool *pgxpool.Pool, opts ...service.EnrollmentServiceOption) *mux.Router {
+	t.Helper()
+
+	mediaMTXClient, err := service.NewMediaMTXClient("http://localhost:9997", service.PublicEndpoints{
+		RTMP: "rtmp://localhost:1935",
+	})
+	require.NoError(t, err)
+	streamKeyService := service.NewStreamKeyService(
+		database.NewStreamKeyRepo(pool),
+		database.NewStreamRepo(pool),
+		database.NewBroadcasterRepo(pool),
+		mediaMTXClient,
+		database.NewUnitOfWork(pool),
+	)
+	enrollmentService := service.NewEnrollmentService(database.NewEnrollmentRepo(pool), streamKeyService, opts...)
+	tokenHandler := handler.NewEnrollmentTokenHandler(enrollmentService, nil)
+
+	router := mux.NewRouter()
+	router.Handle("/enrollment-tokens", tokenHandler)
+	router.Handle("/enrollment-tokens/{id}", tokenHandler)
+	router.Handle("/enroll", handler.NewEnrollHandler(enrollmentService, nil))
+	return router
+}
@@ -34,15 +34,21 @@ func (e *HTTPError) Error() string {
 
 // Error type URIs
 const (
-	ErrorTypeNotFound         = "/errors/not-found"
-	ErrorTypeInvalidRequest   = "/errors/invalid-request"
-	ErrorTypeUnauthorized     = "/errors/unauthorized"
-	ErrorTypeConflict         = "/errors/conflict"
-	ErrorTypeInternalError    = "/errors/internal-error"
-	ErrorTypeInvalidStreamKey = "/errors/invalid-stream-key"
-	ErrorTypeStreamKeyInUse   = "/errors/stream-key-in-use"
-	ErrorTypeStreamKeyRevoked = "/errors/stream-key-revoked"
-	ErrorTypeStreamKeyExpired = "/errors/stream-key-expired"
+	ErrorTypeNotFound            = "/errors/not-found"
+	ErrorTypeInvalidRequest      = "/errors/invalid-request"
+	ErrorTypeUnauthorized        = "/errors/unauthorized"
+	ErrorTypeForbidden           = "/errors/forbidden"
+	ErrorTypeConflict            = "/errors/conflict"
+	ErrorTypeInternalError       = "/errors/internal-error"
+	ErrorTypeInvalidStreamKey    = "/errors/invalid-stream-key"
+	ErrorTypeStreamKeyInUse      = "/errors/stream-key-in-use"
+	ErrorTypeStreamKeyRevoked    = "/errors/stream-key-revoked"
+	ErrorTypeStreamKeyExpired    = "/errors/stream-key-expired"
+	ErrorTypeBroadcasterArchived = "/errors/broadcaster-archived"
+	ErrorTypeQuotaExceeded       = "/errors/quota-exceeded"
+	ErrorTypeExportUnavailable   = "/errors/export-unavailable"
+	ErrorTypeInvalidEnrollment   = "/errors/invalid-enrollment-token"
+	ErrorTypeLockedOut           = "/errors/locked-out"
 )
 
 // ErrNotFound creates a not found error.
@@ -75,6 +81,16 @@ func ErrUnauthorized(detail string) *HTTPError {
 	}
 }
 
+// ErrForbidden creates a forbidden error.
+func ErrForbidden(detail string) *HTTPError {
+	return &HTTPError{
+		Status: http.StatusForbidden,
+		Type:   ErrorTypeForbidden,
+		Title:  "Forbidden",
+		Detail: detail,
+	}
+}
+
 // ErrConflict creates a conflict error.
 func ErrConflict(detail string) *HTTPError {
 	return &HTTPError{
@@ -115,6 +131,10 @@ func MapDomainError(err error) *HTTPError {
 		return ErrConflict("A resource with the same identifier already exists")
 	case errors.Is(err, domain.ErrInvalidStatus):
 		return ErrInvalidRequest("Invalid status transition")
+	case errors.Is(err, domain.ErrInvalidCursor):
+		return ErrInvalidRequest("Invalid pagination cursor")
+	case errors.Is(err, domain.ErrInvalidExpiry):
+		return ErrInvalidRequest("expires_at must be in the future")
 	case errors.Is(err, domain.ErrStreamKeyInUse):
 		return &HTTPError{
 			Status: http.StatusConflict,
@@ -143,8 +163,49 @@ func MapDomainError(err error) *HTTPError {
 			Title:  "Invalid Stream Key",
 			Detail: "The provided stream key is invalid",
 		}
+	case errors.Is(err, domain.ErrBroadcasterArchived):
+		return &HTTPError{
+			Status: http.StatusConflict,
+			Type:   ErrorTypeBroadcasterArchived,
+			Title:  "Broadcaster Archived",
+			Detail: "This broadcaster has been archived",
+		}
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		return &HTTPError{
+			Status: http.StatusConflict,
+			Type:   ErrorTypeQuotaExceeded,
+			Title:  "Quota Exceeded",
+			Detail: err.Error(),
+		}
+	case errors.Is(err, domain.ErrExportUnavailable):
+		return &HTTPError{
+			Status: http.StatusGone,
+			Type:   ErrorTypeExportUnavailable,
+			Title:  "Export Unavailable",
+			Detail: "This export has already been retrieved or is not ready yet",
+		}
+	case errors.Is(err, domain.ErrEnrollmentTokenUnusable):
+		return &HTTPError{
+			Status: http.StatusUnauthorized,
+			Type:   ErrorTypeInvalidEnrollment,
+			Title:  "Invalid Enrollment Token",
+			Detail: err.Error(),
+		}
+	case errors.Is(err, domain.ErrLockedOut):
+		return &HTTPError{
+			Status: http.StatusTooManyRequests,
+			Type:   ErrorTypeLockedOut,
+			Title:  "Too Many Requests",
+			Detail: "Too many failed attempts, try again later",
+		}
+	case errors.Is(err, domain.ErrInvalidShare):
+		return ErrInvalidRequest(err.Error())
+	case errors.Is(err, domain.ErrInvalidDevice):
+		return ErrInvalidRequest("The device must belong to the stream key's broadcaster")
 	case errors.Is(err, domain.ErrUnauthorized):
 		return ErrUnauthorized("Unauthorized")
+	case errors.Is(err, domain.ErrForbidden):
+		return ErrForbidden("This action is not permitted for your organization")
 	default:
 		return ErrInternalServer("An unexpected error occurred")
 	}
@@ -0,0 +1,468 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+	"github.com/searchandrescuegg/rescuestream-api/internal/service"
+)
+
+// maxGroupNameLength is the longest broadcaster group name that may be set.
+const maxGroupNameLength = 255
+
+// BroadcasterGroupHandler handles broadcaster group HTTP requests.
+type BroadcasterGroupHandler struct {
+	groupService *service.BroadcasterGroupService
+	logger       *slog.Logger
+}
+
+// NewBroadcasterGroupHandler creates a new BroadcasterGroupHandler.
+func NewBroadcasterGroupHandler(groupService *service.BroadcasterGroupService, logger *slog.Logger) *BroadcasterGroupHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &BroadcasterGroupHandler{
+		groupService: groupService,
+		logger:       logger,
+	}
+}
+
+// CreateBroadcasterGroupRequest represents the request body for creating a broadcaster group.
+type CreateBroadcasterGroupRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+}
+
+// UpdateBroadcasterGroupRequest represents the request body for updating a
+// broadcaster group. An empty description clears it.
+type UpdateBroadcasterGroupRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// AddGroupMembersRequest represents the request body for adding broadcasters to a group.
+type AddGroupMembersRequest struct {
+	BroadcasterIDs []string `json:"broadcaster_ids"`
+}
+
+// CreateGroupJobRequest represents the request body for running a bulk
+// action on a group. For set_expiry, "expires_at": null clears the expiry.
+type CreateGroupJobRequest struct {
+	Action    domain.GroupJobAction `json:"action"`
+	Label     *string               `json:"label,omitempty"`
+	ExpiresAt json.RawMessage       `json:"expires_at,omitempty"`
+}
+
+// BroadcasterGroupListResponse represents the response for listing broadcaster groups.
+type BroadcasterGroupListResponse struct {
+	Groups []domain.BroadcasterGroup `json:"groups"`
+	Count  int                       `json:"count"`
+}
+
+// GroupMemberListResponse represents the response for listing group members.
+type GroupMemberListResponse struct {
+	Broadcasters []domain.Broadcaster `json:"broadcasters"`
+	Count        int                  `json:"count"`
+}
+
+// GroupJobListResponse represents the response for listing group jobs.
+type GroupJobListResponse struct {
+	Jobs  []domain.GroupJob `json:"jobs"`
+	Count int               `json:"count"`
+}
+
+// ServeHTTP routes broadcaster group requests to the appropriate handler.
+func (h *BroadcasterGroupHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	action := vars["action"]
+	subID := vars["sub_id"]
+
+	switch {
+	case action == "members" && r.Method == http.MethodGet && subID == "":
+		h.listMembers(w, r, id)
+	case action == "members" && r.Method == http.MethodPost && subID == "":
+		h.addMembers(w, r, id)
+	case action == "members" && r.Method == http.MethodDelete && subID != "":
+		h.removeMember(w, r, id, subID)
+	case action == "streams" && r.Method == http.MethodGet && subID == "":
+		h.listStreams(w, r, id)
+	case action == "stream-keys" && r.Method == http.MethodGet && subID == "":
+		h.listStreamKeys(w, r, id)
+	case action == "jobs" && r.Method == http.MethodGet && subID == "":
+		h.listJobs(w, r, id)
+	case action == "jobs" && r.Method == http.MethodPost && subID == "":
+		h.createJob(w, r, id)
+	case action == "jobs" && r.Method == http.MethodGet && subID != "":
+		h.getJob(w, r, id, subID)
+	case action != "":
+		WriteError(w, r, ErrNotFound("unknown broadcaster group action"))
+	case r.Method == http.MethodGet && id == "":
+		h.listGroups(w, r)
+	case r.Method == http.MethodPost && id == "":
+		h.createGroup(w, r)
+	case r.Method == http.MethodGet && id != "":
+		h.getGroup(w, r, id)
+	case r.Method == http.MethodPatch && id != "":
+		h.updateGroup(w, r, id)
+	case r.Method == http.MethodDelete && id != "":
+		h.deleteGroup(w, r, id)
+	default:
+		WriteError(w, r, ErrInvalidRequest("method not allowed"))
+	}
+}
+
+func (h *BroadcasterGroupHandler) createGroup(w http.ResponseWriter, r *http.Request) {
+	var req CreateBroadcasterGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid request body"))
+		return
+	}
+
+	if req.Name == "" {
+		WriteError(w, r, ErrInvalidRequest("name is required"))
+		return
+	}
+
+	if utf8.RuneCountInString(req.Name) > maxGroupNameLength {
+		WriteError(w, r, ErrInvalidRequest(fmt.Sprintf("name must be at most %d characters", maxGroupNameLength)))
+		return
+	}
+
+	group, err := h.groupService.Create(r.Context(), service.CreateGroupRequest{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, group)
+}
+
+func (h *BroadcasterGroupHandler) listGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.groupService.List(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list broadcaster groups", slog.String("error", err.Error()))
+		WriteError(w, r, ErrInternalServer("failed to list broadcaster groups"))
+		return
+	}
+
+	if groups == nil {
+		groups = []domain.BroadcasterGroup{}
+	}
+
+	WriteJSON(w, http.StatusOK, BroadcasterGroupListResponse{
+		Groups: groups,
+		Count:  len(groups),
+	})
+}
+
+func (h *BroadcasterGroupHandler) getGroup(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid broadcaster group ID"))
+		return
+	}
+
+	group, err := h.groupService.GetByID(r.Context(), id)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, group)
+}
+
+func (h *BroadcasterGroupHandler) updateGroup(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid broadcaster group ID"))
+		return
+	}
+
+	var req UpdateBroadcasterGroupRequest
+	if decodeErr := json.NewDecoder(r.Body).Decode(&req); decodeErr != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid request body"))
+		return
+	}
+
+	if req.Name != nil {
+		if *req.Name == "" {
+			WriteError(w, r, ErrInvalidRequest("name cannot be empty"))
+			return
+		}
+		if utf8.RuneCountInString(*req.Name) > maxGroupNameLength {
+			WriteError(w, r, ErrInvalidRequest(fmt.Sprintf("name must be at most %d characters", maxGroupNameLength)))
+			return
+		}
+	}
+
+	group, err := h.groupService.Update(r.Context(), id, service.UpdateGroupRequest{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, group)
+}
+
+func (h *BroadcasterGroupHandler) deleteGroup(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid broadcaster group ID"))
+		return
+	}
+
+	if err := h.groupService.Delete(r.Context(), id); err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *BroadcasterGroupHandler) addMembers(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid broadcaster group ID"))
+		return
+	}
+
+	var req AddGroupMembersRequest
+	if decodeErr := json.NewDecoder(r.Body).Decode(&req); decodeErr != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid request body"))
+		return
+	}
+
+	if len(req.BroadcasterIDs) == 0 {
+		WriteError(w, r, ErrInvalidRequest("broadcaster_ids is required"))
+		return
+	}
+	if len(req.BroadcasterIDs) > service.MaxGroupMembersPerRequest {
+		WriteError(w, r, ErrInvalidRequest(fmt.Sprintf("at most %d broadcasters may be added at once", service.MaxGroupMembersPerRequest)))
+		return
+	}
+
+	broadcasterIDs := make([]uuid.UUID, 0, len(req.BroadcasterIDs))
+	for _, s := range req.BroadcasterIDs {
+		broadcasterID, parseErr := uuid.Parse(s)
+		if parseErr != nil {
+			WriteError(w, r, ErrInvalidRequest("invalid broadcaster_id: "+s))
+			return
+		}
+		broadcasterIDs = append(broadcasterIDs, broadcasterID)
+	}
+
+	if err := h.groupService.AddMembers(r.Context(), id, broadcasterIDs); err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	h.listMembers(w, r, idStr)
+}
+
+func (h *BroadcasterGroupHandler) removeMember(w http.ResponseWriter, r *http.Request, idStr, broadcasterIDStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid broadcaster group ID"))
+		return
+	}
+
+	broadcasterID, err := uuid.Parse(broadcasterIDStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid broadcaster ID"))
+		return
+	}
+
+	if err := h.groupService.RemoveMember(r.Context(), id, broadcasterID); err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *BroadcasterGroupHandler) listMembers(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid broadcaster group ID"))
+		return
+	}
+
+	members, err := h.groupService.ListMembers(r.Context(), id)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, GroupMemberListResponse{
+		Broadcasters: members,
+		Count:        len(members),
+	})
+}
+
+func (h *BroadcasterGroupHandler) listStreams(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid broadcaster group ID"))
+		return
+	}
+
+	streams, err := h.groupService.ListStreams(r.Context(), id)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	if streams == nil {
+		streams = []domain.StreamWithURLs{}
+	}
+
+	WriteJSON(w, http.StatusOK, StreamListResponse{
+		Streams: streams,
+		Count:   len(streams),
+	})
+}
+
+func (h *BroadcasterGroupHandler) listStreamKeys(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid broadcaster group ID"))
+		return
+	}
+
+	keys, err := h.groupService.ListStreamKeys(r.Context(), id)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, StreamKeyListResponse{
+		StreamKeys: keys,
+		Count:      len(keys),
+	})
+}
+
+// createJob queues a bulk action on the group's current members. The job
+// runs in the background; its progress is available from getJob.
+func (h *BroadcasterGroupHandler) createJob(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid broadcaster group ID"))
+		return
+	}
+
+	var req CreateGroupJobRequest
+	if decodeErr := json.NewDecoder(r.Body).Decode(&req); decodeErr != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid request body"))
+		return
+	}
+
+	if !req.Action.IsValid() {
+		WriteError(w, r, ErrInvalidRequest("action must be issue_keys, revoke_keys or set_expiry"))
+		return
+	}
+
+	if req.Label != nil && utf8.RuneCountInString(*req.Label) > service.MaxStreamKeyLabelLength {
+		WriteError(w, r, ErrInvalidRequest(fmt.Sprintf("label must be at most %d characters", service.MaxStreamKeyLabelLength)))
+		return
+	}
+
+	jobReq := service.CreateGroupJobRequest{
+		Action: req.Action,
+		Label:  req.Label,
+	}
+
+	if req.Action == domain.GroupJobSetExpiry && len(req.ExpiresAt) == 0 {
+		WriteError(w, r, ErrInvalidRequest("expires_at is required for set_expiry, use null to clear the expiry"))
+		return
+	}
+
+	if len(req.ExpiresAt) > 0 && !bytes.Equal(req.ExpiresAt, []byte("null")) {
+		var expiresAt time.Time
+		if parseErr := json.Unmarshal(req.ExpiresAt, &expiresAt); parseErr != nil {
+			WriteError(w, r, ErrInvalidRequest("invalid expires_at format, use RFC3339"))
+			return
+		}
+		if !expiresAt.After(time.Now()) {
+			WriteError(w, r, ErrInvalidRequest("expires_at must be in the future"))
+			return
+		}
+		jobReq.ExpiresAt = &expiresAt
+	}
+
+	job, err := h.groupService.CreateJob(r.Context(), id, jobReq)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusAccepted, job)
+}
+
+func (h *BroadcasterGroupHandler) listJobs(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid broadcaster group ID"))
+		return
+	}
+
+	jobs, err := h.groupService.ListJobs(r.Context(), id)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, GroupJobListResponse{
+		Jobs:  jobs,
+		Count: len(jobs),
+	})
+}
+
+func (h *BroadcasterGroupHandler) getJob(w http.ResponseWriter, r *http.Request, idStr, jobIDStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid broadcaster group ID"))
+		return
+	}
+
+	jobID, err := uuid.Parse(jobIDStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid job ID"))
+		return
+	}
+
+	detail, err := h.groupService.GetJob(r.Context(), id, jobID)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, detail)
+}
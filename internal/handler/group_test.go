@@ -0,0 +1,297 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/database"
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+	"github.com/searchandrescuegg/rescuestream-api/internal/handler"
+	"github.com/searchandrescuegg/rescuestream-api/internal/service"
+	"github.com/searchandrescuegg/rescuestream-api/internal/testutil"
+)
+
+func TestBroadcasterGroups_MembersStreamsAndKeys(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	router, _ := setupBroadcasterGroupRouter(t, db.Pool)
+
+	k9ID := createTestBroadcaster(t, db.Pool, "K9 Handler")
+	droneID := createTestBroadcaster(t, db.Pool, "Drone Pilot")
+	outsiderID := createTestBroadcaster(t, db.Pool, "Swiftwater")
+
+	// The K9 handler is live, the outsider is live too but not in the group
+	k9KeyID := streamKeyIDByValue(t, db.Pool, createTestStreamKey(t, db.Pool, k9ID, "active", nil))
+	k9StreamID := createTestStream(t, db.Pool, k9KeyID, "k9-cam", "active")
+	outsiderKeyID := streamKeyIDByValue(t, db.Pool, createTestStreamKey(t, db.Pool, outsiderID, "active", nil))
+	createTestStream(t, db.Pool, outsiderKeyID, "swiftwater-cam", "active")
+	createTestStreamKey(t, db.Pool, droneID, "active", nil)
+
+	groupID := createTestGroup(t, router, "Ground Team")
+
+	body := `{"broadcaster_ids": ["` + k9ID.String() + `", "` + droneID.String() + `"]}`
+	req := httptest.NewRequest(http.MethodPost, "/broadcaster-groups/"+groupID.String()+"/members", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var members handler.GroupMemberListResponse
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&members))
+	assert.Equal(t, 2, members.Count)
+
+	// Duplicate group names are rejected
+	req = httptest.NewRequest(http.MethodPost, "/broadcaster-groups", strings.NewReader(`{"name": "ground team"}`))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/broadcaster-groups/"+groupID.String()+"/streams", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var streams handler.StreamListResponse
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&streams))
+	require.Equal(t, 1, streams.Count)
+	assert.Equal(t, k9StreamID, streams.Streams[0].ID)
+
+	req = httptest.NewRequest(http.MethodGet, "/broadcaster-groups/"+groupID.String()+"/stream-keys", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var keys handler.StreamKeyListResponse
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&keys))
+	require.Equal(t, 2, keys.Count)
+	for _, key := range keys.StreamKeys {
+		assert.Empty(t, key.KeyValue)
+		assert.NotEqual(t, outsiderID, key.BroadcasterID)
+	}
+
+	// Removing a member drops it from the group's listings
+	req = httptest.NewRequest(http.MethodDelete, "/broadcaster-groups/"+groupID.String()+"/members/"+k9ID.String(), nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/broadcaster-groups/"+groupID.String()+"/streams", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&streams))
+	assert.Equal(t, 0, streams.Count)
+}
+
+func TestBroadcasterGroups_JobsRecordPerMemberResults(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	router, runner := setupBroadcasterGroupRouter(t, db.Pool)
+
+	activeID := createTestBroadcaster(t, db.Pool, "K9 Handler")
+	archivedID := createTestBroadcaster(t, db.Pool, "Retired Drone")
+	_, err := db.Pool.Exec(context.Background(), "UPDATE broadcasters SET archived_at = NOW() WHERE id = $1", archivedID)
+	require.NoError(t, err)
+
+	groupID := createTestGroup(t, router, "Ground Team")
+	body := `{"broadcaster_ids": ["` + activeID.String() + `", "` + archivedID.String() + `"]}`
+	req := httptest.NewRequest(http.MethodPost, "/broadcaster-groups/"+groupID.String()+"/members", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// Issue keys: the archived broadcaster fails without failing the job
+	job := createTestGroupJob(t, router, groupID, `{"action": "issue_keys", "label": "Op Ridgeline"}`)
+	assert.Equal(t, domain.GroupJobStatusPending, job.Status)
+	assert.Equal(t, 2, job.Counts.Pending)
+	require.NotNil(t, job.BatchID)
+
+	require.NoError(t, runner.ProcessPending(context.Background()))
+
+	detail := getTestGroupJob(t, router, groupID, job.ID)
+	assert.Equal(t, domain.GroupJobStatusCompleted, detail.Status)
+	assert.Equal(t, 1, detail.Counts.Succeeded)
+	assert.Equal(t, 1, detail.Counts.Failed)
+	require.Len(t, detail.Results, 2)
+
+	var issuedKeyID uuid.UUID
+	for _, result := range detail.Results {
+		switch result.BroadcasterID {
+		case activeID:
+			assert.Equal(t, domain.GroupJobResultSucceeded, result.Status)
+			require.Len(t, result.StreamKeyIDs, 1)
+			issuedKeyID = result.StreamKeyIDs[0]
+		case archivedID:
+			assert.Equal(t, domain.GroupJobResultFailed, result.Status)
+			require.NotNil(t, result.Error)
+			assert.Contains(t, *result.Error, "archived")
+		}
+	}
+
+	var batchID uuid.UUID
+	require.NoError(t, db.Pool.QueryRow(context.Background(),
+		"SELECT batch_id FROM stream_keys WHERE id = $1", issuedKeyID).Scan(&batchID))
+	assert.Equal(t, *job.BatchID, batchID, "issued keys are exported through the job's batch")
+
+	// Revoke keys: the live stream is ended, the member without keys is skipped
+	streamID := createTestStream(t, db.Pool, issuedKeyID, "k9-cam", "active")
+
+	job = createTestGroupJob(t, router, groupID, `{"action": "revoke_keys"}`)
+	require.NoError(t, runner.ProcessPending(context.Background()))
+
+	detail = getTestGroupJob(t, router, groupID, job.ID)
+	assert.Equal(t, domain.GroupJobStatusCompleted, detail.Status)
+	assert.Equal(t, 1, detail.Counts.Succeeded)
+	assert.Equal(t, 1, detail.Counts.Skipped)
+
+	var keyStatus, streamStatus string
+	require.NoError(t, db.Pool.QueryRow(context.Background(),
+		"SELECT status FROM stream_keys WHERE id = $1", issuedKeyID).Scan(&keyStatus))
+	require.NoError(t, db.Pool.QueryRow(context.Background(),
+		"SELECT status FROM streams WHERE id = $1", streamID).Scan(&streamStatus))
+	assert.Equal(t, "revoked", keyStatus)
+	assert.Equal(t, "ended", streamStatus)
+
+	// set_expiry requires an explicit expires_at
+	req = httptest.NewRequest(http.MethodPost, "/broadcaster-groups/"+groupID.String()+"/jobs", strings.NewReader(`{"action": "set_expiry"}`))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestBroadcasterGroups_AbandonedJobsAreResumed(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	router, runner := setupBroadcasterGroupRouter(t, db.Pool)
+
+	broadcasterID := createTestBroadcaster(t, db.Pool, "K9 Handler")
+	groupID := createTestGroup(t, router, "Ground Team")
+	req := httptest.NewRequest(http.MethodPost, "/broadcaster-groups/"+groupID.String()+"/members", strings.NewReader(`{"broadcaster_ids": ["`+broadcasterID.String()+`"]}`))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// A runner claimed the job and stopped before finishing it
+	job := createTestGroupJob(t, router, groupID, `{"action": "issue_keys"}`)
+	_, err := db.Pool.Exec(context.Background(),
+		"UPDATE group_jobs SET status = 'running', started_at = NOW(), lease_expires_at = NOW() + INTERVAL '1 minute' WHERE id = $1", job.ID)
+	require.NoError(t, err)
+
+	// The job is left alone while its lease holds
+	require.NoError(t, runner.ProcessPending(context.Background()))
+	assert.Equal(t, domain.GroupJobStatusRunning, getTestGroupJob(t, router, groupID, job.ID).Status)
+
+	// Its keys cannot be exported before the job completes
+	require.NotNil(t, job.BatchID)
+	batchRepo := database.NewStreamKeyBatchRepo(db.Pool)
+	_, err = batchRepo.ClaimExport(context.Background(), *job.BatchID)
+	assert.ErrorIs(t, err, domain.ErrExportUnavailable)
+
+	// The runner had issued the member's key without recording it
+	keyID := streamKeyIDByValue(t, db.Pool, createTestStreamKey(t, db.Pool, broadcasterID, "active", nil))
+	_, err = db.Pool.Exec(context.Background(), "UPDATE stream_keys SET batch_id = $1 WHERE id = $2", *job.BatchID, keyID)
+	require.NoError(t, err)
+
+	// Once the lease runs out the job is claimed again and completed
+	_, err = db.Pool.Exec(context.Background(), "UPDATE group_jobs SET lease_expires_at = NOW() WHERE id = $1", job.ID)
+	require.NoError(t, err)
+	require.NoError(t, runner.ProcessPending(context.Background()))
+
+	detail := getTestGroupJob(t, router, groupID, job.ID)
+	assert.Equal(t, domain.GroupJobStatusCompleted, detail.Status)
+	assert.Equal(t, 1, detail.Counts.Succeeded)
+
+	// The member was not issued a second key
+	entries, err := batchRepo.ClaimExport(context.Background(), *job.BatchID)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, keyID, entries[0].ID)
+}
+
+func createTestGroup(t *testing.T, router *mux.Router, name string) uuid.UUID {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/broadcaster-groups", strings.NewReader(`{"name": "`+name+`"}`))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var group domain.BroadcasterGroup
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&group))
+	return group.ID
+}
+
+func createTestGroupJob(t *testing.T, router *mux.Router, groupID uuid.UUID, body string) domain.GroupJob {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/broadcaster-groups/"+groupID.String()+"/jobs", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+
+	var job domain.GroupJob
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&job))
+	return job
+}
+
+func getTestGroupJob(t *testing.T, router *mux.Router, groupID, jobID uuid.UUID) service.GroupJobDetail {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/broadcaster-groups/"+groupID.String()+"/jobs/"+jobID.String(), nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var detail service.GroupJobDetail
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&detail))
+	return detail
+}
+
+func streamKeyIDByValue(t *testing.T, pool *pgxpool.Pool, keyValue string) uuid.UUID {
+	t.Helper()
+
+	var id uuid.UUID
+	require.NoError(t, pool.QueryRow(context.Background(), "SELECT id FROM stream_keys WHERE key_value = $1", keyValue).Scan(&id))
+	return id
+}
+
+func setupBroadcasterGroupRouter(t *testing.T, pool *pgxpool.Pool) (*mux.Router, *service.GroupJobRunner) {
+	t.Helper()
+
+	mediaMTXClient, err := service.NewMediaMTXClient("http://localhost:9997", service.PublicEndpoints{
+		WebRTC: "http://localhost:8889",
+	})
+	require.NoError(t, err)
+	broadcasterRepo := database.NewBroadcasterRepo(pool)
+	streamRepo := database.NewStreamRepo(pool)
+	streamKeyService := service.NewStreamKeyService(database.NewStreamKeyRepo(pool), streamRepo, broadcasterRepo, mediaMTXClient, database.NewUnitOfWork(pool))
+	groupJobRepo := database.NewGroupJobRepo(pool)
+	groupService := service.NewBroadcasterGroupService(
+		database.NewBroadcasterGroupRepo(pool),
+		groupJobRepo,
+		broadcasterRepo,
+		database.NewStreamKeyBatchRepo(pool),
+		service.NewStreamService(streamRepo, mediaMTXClient),
+		streamKeyService,
+	)
+	h := handler.NewBroadcasterGroupHandler(groupService, nil)
+
+	router := mux.NewRouter()
+	router.Handle("/broadcaster-groups", h)
+	router.Handle("/broadcaster-groups/{id}", h)
+	router.Handle("/broadcaster-groups/{id}/{action}", h)
+	router.Handle("/broadcaster-groups/{id}/{action}/{sub_id}", h)
+	return router, service.NewGroupJobRunner(groupJobRepo, streamKeyService)
+}
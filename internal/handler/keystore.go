@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"context"
 	"errors"
 
+	"github.com/google/uuid"
+
 	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+	"github.com/searchandrescuegg/rescuestream-api/internal/service"
 )
 
 // StaticKeyStore is a simple key store that uses a single API key/secret pair.
@@ -49,3 +53,46 @@ func (s *EnvKeyStore) GetSecret(apiKey string) (string, error) {
 	}
 	return s.secret, nil
 }
+
+// APIClientKeyStore authenticates organization API clients, falling back to
+// a deployment-wide key store for keys that are not API clients.
+type APIClientKeyStore struct {
+	orgService *service.OrganizationService
+	fallback   KeyStore
+}
+
+// NewAPIClientKeyStore creates a key store for organization API clients.
+// Keys that are not API clients are checked against fallback, which may be
+// nil, and are not scoped to an organization.
+func NewAPIClientKeyStore(orgService *service.OrganizationService, fallback KeyStore) *APIClientKeyStore {
+	return &APIClientKeyStore{
+		orgService: orgService,
+		fallback:   fallback,
+	}
+}
+
+// GetSecret returns the secret for the given API key.
+func (s *APIClientKeyStore) GetSecret(apiKey string) (string, error) {
+	secret, _, err := s.GetClient(context.Background(), apiKey)
+	return secret, err
+}
+
+// GetClient returns the secret for the given API key and the organization
+// it is scoped to. Revoked API clients are rejected.
+func (s *APIClientKeyStore) GetClient(ctx context.Context, apiKey string) (string, *uuid.UUID, error) {
+	client, err := s.orgService.GetAPIClient(ctx, apiKey)
+	switch {
+	case err == nil:
+		if client.IsRevoked() {
+			return "", nil, domain.ErrUnauthorized
+		}
+		return client.Secret, &client.OrganizationID, nil
+	case !errors.Is(err, domain.ErrNotFound):
+		return "", nil, err
+	case s.fallback == nil:
+		return "", nil, domain.ErrUnauthorized
+	}
+
+	secret, err := s.fallback.GetSecret(apiKey)
+	return secret, nil, err
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+	"github.com/searchandrescuegg/rescuestream-api/internal/service"
+)
+
+// LockoutHandler handles authentication lockout administration HTTP requests.
+type LockoutHandler struct {
+	lockoutService *service.LockoutService
+	logger         *slog.Logger
+}
+
+// NewLockoutHandler creates a new LockoutHandler.
+func NewLockoutHandler(lockoutService *service.LockoutService, logger *slog.Logger) *LockoutHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &LockoutHandler{
+		lockoutService: lockoutService,
+		logger:         logger,
+	}
+}
+
+// LockoutResponse is a tracked lockout state with its current effect.
+type LockoutResponse struct {
+	domain.AuthLockout
+	Locked bool `json:"locked"`
+}
+
+// LockoutListResponse represents the response for listing lockouts.
+type LockoutListResponse struct {
+	Lockouts []LockoutResponse `json:"lockouts"`
+	Count    int               `json:"count"`
+}
+
+// ServeHTTP routes lockout requests to the appropriate handler.
+func (h *LockoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	scope := vars["scope"]
+	subject := vars["subject"]
+
+	switch {
+	case r.Method == http.MethodGet && scope == "":
+		h.listLockouts(w, r)
+	case r.Method == http.MethodDelete && scope != "" && subject != "":
+		h.clearLockout(w, r, scope, subject)
+	default:
+		WriteError(w, r, ErrInvalidRequest("method not allowed"))
+	}
+}
+
+func (h *LockoutHandler) listLockouts(w http.ResponseWriter, r *http.Request) {
+	lockouts, err := h.lockoutService.List(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list lockouts", slog.String("error", err.Error()))
+		WriteError(w, r, ErrInternalServer("failed to list lockouts"))
+		return
+	}
+
+	lockedOnly := r.URL.Query().Get("locked") == "true"
+	now := time.Now()
+
+	resp := LockoutListResponse{
+		Lockouts: []LockoutResponse{},
+	}
+	for _, l := range lockouts {
+		locked := l.IsLocked(now)
+		if lockedOnly && !locked {
+			continue
+		}
+		resp.Lockouts = append(resp.Lockouts, LockoutResponse{AuthLockout: l, Locked: locked})
+	}
+	resp.Count = len(resp.Lockouts)
+
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *LockoutHandler) clearLockout(w http.ResponseWriter, r *http.Request, scopeStr, subject string) {
+	scope := domain.LockoutScope(scopeStr)
+	if !scope.IsValid() {
+		WriteError(w, r, ErrInvalidRequest("scope must be one of: ip, path"))
+		return
+	}
+
+	if err := h.lockoutService.Clear(r.Context(), scope, subject); err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,290 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/database"
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+	"github.com/searchandrescuegg/rescuestream-api/internal/handler"
+	"github.com/searchandrescuegg/rescuestream-api/internal/service"
+	"github.com/searchandrescuegg/rescuestream-api/internal/testutil"
+)
+
+func TestAuthHandler_LockoutAfterRepeatedFailures(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create broadcaster and stream key
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+	// Setup handler with a low failure threshold
+	events := &recordingEventPublisher{}
+	h, _ := setupLockoutHandlers(t, db.Pool, events)
+
+	// Fail three times from the same IP
+	for i := 0; i < 3; i++ {
+		resp := executeAuthRequest(t, h, publishRequest("10.0.0.1", "guess"))
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	}
+
+	// A valid key from the locked out IP is rejected
+	resp := executeAuthRequest(t, h, publishRequest("10.0.0.1", keyValue))
+	assert.Equal(t, http.StatusUnauthorized, resp.Code, "locked out IP should be rejected")
+
+	// The same key from another IP is accepted
+	resp = executeAuthRequest(t, h, publishRequest("10.0.0.2", keyValue))
+	assert.Equal(t, http.StatusOK, resp.Code, "other IPs should not be affected")
+
+	// A lockout event was emitted for the IP
+	require.NotEmpty(t, events.Events())
+	assert.Equal(t, domain.EventAuthLockout, events.Events()[0].Type)
+	assert.Equal(t, domain.LockoutScopeIP, events.Events()[0].Data["scope"])
+}
+
+func TestLockoutHandler_ListAndClear(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create broadcaster and stream key
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+	// Setup handlers and trigger a lockout
+	authHandler, lockoutHandler := setupLockoutHandlers(t, db.Pool, nil)
+	for i := 0; i < 3; i++ {
+		executeAuthRequest(t, authHandler, publishRequest("10.0.0.1", "guess"))
+	}
+
+	router := mux.NewRouter()
+	router.Handle("/admin/lockouts", lockoutHandler)
+	router.Handle("/admin/lockouts/{scope}/{subject:.+}", lockoutHandler)
+
+	// List locked subjects
+	req := httptest.NewRequest(http.MethodGet, "/admin/lockouts?locked=true", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp handler.LockoutListResponse
+	err := json.NewDecoder(recorder.Body).Decode(&resp)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, resp.Count, "both the IP and the claimed path should be locked")
+	for _, l := range resp.Lockouts {
+		assert.True(t, l.Locked)
+		assert.Equal(t, 1, l.LockoutCount)
+	}
+
+	// Clear the IP lockout
+	req = httptest.NewRequest(http.MethodDelete, "/admin/lockouts/ip/10.0.0.1", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	// The valid key is accepted again from that IP
+	authResp := executeAuthRequest(t, authHandler, publishRequest("10.0.0.1", keyValue))
+	assert.Equal(t, http.StatusOK, authResp.Code)
+}
+
+func TestLockoutHandler_Clear_NotFound(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	_, lockoutHandler := setupLockoutHandlers(t, db.Pool, nil)
+
+	router := mux.NewRouter()
+	router.Handle("/admin/lockouts/{scope}/{subject:.+}", lockoutHandler)
+
+	req := httptest.NewRequest(http.MethodDelete, "/admin/lockouts/ip/10.9.9.9", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestLockoutHandler_Clear_InvalidScope(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	_, lockoutHandler := setupLockoutHandlers(t, db.Pool, nil)
+
+	router := mux.NewRouter()
+	router.Handle("/admin/lockouts/{scope}/{subject:.+}", lockoutHandler)
+
+	req := httptest.NewRequest(http.MethodDelete, "/admin/lockouts/user/alice", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestAuthHandler_RTSPCredentialChallenge(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	h, _ := setupLockoutHandlers(t, db.Pool, nil)
+
+	// RTSP clients first connect without credentials and only send them
+	// once challenged
+	for i := 0; i < 10; i++ {
+		req := service.AuthRequest{
+			IP:       "203.0.113.20",
+			Action:   "publish",
+			Path:     "camera-1",
+			Protocol: "rtsp",
+			ID:       "conn-123",
+		}
+
+		resp := executeAuthRequest(t, h, req)
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	}
+
+	// The challenges don't count towards a lockout
+	var count int
+	err := db.Pool.QueryRow(context.Background(), "SELECT COUNT(*) FROM auth_lockouts").Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestAuthHandler_RTSPKeyGuessesCountTowardsLockout(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+	h, _ := setupLockoutHandlers(t, db.Pool, nil)
+
+	// Anonymous RTSP publishes to paths shaped like keys are guesses
+	for i := 0; i < 3; i++ {
+		resp := executeAuthRequest(t, h, service.AuthRequest{
+			IP:       "203.0.113.20",
+			Action:   "publish",
+			Path:     "sk_" + strings.Repeat(strconv.Itoa(i), 43),
+			Protocol: "rtsp",
+			ID:       "conn-123",
+		})
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	}
+
+	// The guessing IP is locked out, even with the right key
+	resp := executeAuthRequest(t, h, service.AuthRequest{
+		IP:       "203.0.113.20",
+		Action:   "publish",
+		Path:     keyValue,
+		Protocol: "rtsp",
+		ID:       "conn-123",
+	})
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
+
+func TestLockoutService_PathLockoutsAreOptional(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	ctx := context.Background()
+	lockoutService := service.NewLockoutService(database.NewAuthLockoutRepo(db.Pool),
+		service.WithLockoutPolicy(service.LockoutPolicy{
+			MaxFailures:  3,
+			Window:       time.Minute,
+			BaseDuration: time.Minute,
+			MaxDuration:  time.Hour,
+		}),
+	)
+
+	// Failures against a path from many IPs do not lock the path
+	path := strings.Repeat("é", 200)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, lockoutService.RecordFailure(ctx, "10.0.0."+strconv.Itoa(i+1), path))
+	}
+
+	lockout, err := lockoutService.Check(ctx, "10.0.0.9", path)
+	require.NoError(t, err)
+	assert.Nil(t, lockout)
+
+	// Long multi-byte paths are cut on a rune boundary
+	pathLockouts := service.NewLockoutService(database.NewAuthLockoutRepo(db.Pool), service.WithLockoutPolicy(service.DefaultLockoutPolicy()))
+	require.NoError(t, pathLockouts.RecordFailure(ctx, "10.0.0.9", path))
+
+	lockouts, err := pathLockouts.List(ctx)
+	require.NoError(t, err)
+	var subjects []string
+	for _, l := range lockouts {
+		if l.Scope == domain.LockoutScopePath {
+			subjects = append(subjects, l.Subject)
+		}
+	}
+	require.Len(t, subjects, 1)
+	assert.True(t, utf8.ValidString(subjects[0]))
+	assert.Equal(t, strings.Repeat("é", 127), subjects[0])
+}
+
+func setupLockoutHandlers(t *testing.T, pool *pgxpool.Pool, events domain.EventPublisher) (*handler.AuthHandler, *handler.LockoutHandler) {
+	t.Helper()
+
+	opts := []service.LockoutServiceOption{
+		service.WithLockoutPolicy(service.LockoutPolicy{
+			MaxFailures:  3,
+			Window:       time.Minute,
+			BaseDuration: time.Minute,
+			MaxDuration:  time.Hour,
+			LockPaths:    true,
+		}),
+	}
+	if events != nil {
+		opts = append(opts, service.WithLockoutEventPublisher(events))
+	}
+	lockoutService := service.NewLockoutService(database.NewAuthLockoutRepo(pool), opts...)
+
+	authService := service.NewAuthService(database.NewUnitOfWork(pool),
+		service.WithLockoutService(lockoutService),
+	)
+
+	return handler.NewAuthHandler(authService, nil), handler.NewLockoutHandler(lockoutService, nil)
+}
+
+func publishRequest(ip, keyValue string) service.AuthRequest {
+	return service.AuthRequest{
+		Password: keyValue,
+		IP:       ip,
+		Action:   "publish",
+		Path:     keyValue,
+		Protocol: "rtmp",
+		ID:       "conn-123",
+	}
+}
+
+// recordingEventPublisher collects published events for assertions.
+type recordingEventPublisher struct {
+	mu     sync.Mutex
+	events []domain.Event
+}
+
+func (p *recordingEventPublisher) Publish(_ context.Context, event domain.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *recordingEventPublisher) Events() []domain.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]domain.Event(nil), p.events...)
+}
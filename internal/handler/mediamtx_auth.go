@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"alpineworks.io/rfc9457"
+)
+
+// MediaMTXMiddleware restricts the MediaMTX callback endpoints (/auth and
+// /webhook/*) to MediaMTX nodes. Callers are identified by a shared token,
+// a source address allowlist, or both.
+type MediaMTXMiddleware struct {
+	token    string
+	prefixes []netip.Prefix
+	logger   *slog.Logger
+}
+
+// NewMediaMTXMiddleware creates a new MediaMTX callback middleware.
+// allowedCIDRs accepts CIDR prefixes or bare IP addresses. At least a token
+// or an allowed CIDR is required, so callbacks are never left open.
+func NewMediaMTXMiddleware(token string, allowedCIDRs []string, logger *slog.Logger) (*MediaMTXMiddleware, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	m := &MediaMTXMiddleware{
+		token:  token,
+		logger: logger,
+	}
+
+	for _, cidr := range allowedCIDRs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+
+		prefix, err := parsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MediaMTX allowed CIDR %q: %w", cidr, err)
+		}
+		m.prefixes = append(m.prefixes, prefix)
+	}
+
+	if m.token == "" && len(m.prefixes) == 0 {
+		return nil, errors.New("a MediaMTX token or allowed CIDR is required")
+	}
+
+	return m, nil
+}
+
+// Authenticate wraps an HTTP handler with MediaMTX callback authentication.
+func (m *MediaMTXMiddleware) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(m.prefixes) > 0 && !m.sourceAllowed(r) {
+			m.logger.Warn("MediaMTX callback from disallowed source",
+				slog.String("remote_addr", r.RemoteAddr),
+				slog.String("path", r.URL.Path),
+			)
+			rfc9457.NewRFC9457(
+				rfc9457.WithStatus(http.StatusForbidden),
+				rfc9457.WithTitle("Forbidden"),
+				rfc9457.WithDetail("Source address is not allowed"),
+				rfc9457.WithInstance(r.URL.Path),
+			).ServeHTTP(w, r)
+			return
+		}
+
+		if m.token != "" && !m.tokenValid(r) {
+			m.logger.Warn("MediaMTX callback with invalid token",
+				slog.String("remote_addr", r.RemoteAddr),
+				slog.String("path", r.URL.Path),
+			)
+			rfc9457.NewRFC9457(
+				rfc9457.WithStatus(http.StatusUnauthorized),
+				rfc9457.WithTitle("Unauthorized"),
+				rfc9457.WithDetail("Missing or invalid MediaMTX token"),
+				rfc9457.WithInstance(r.URL.Path),
+			).ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sourceAllowed checks the connecting address against the allowlist.
+// Forwarding headers are deliberately ignored since they can be forged.
+func (m *MediaMTXMiddleware) sourceAllowed(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range m.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenValid checks the shared token. MediaMTX's HTTP auth hook cannot set
+// custom headers, so besides a bearer token the token is also accepted as
+// the basic auth password, set through the userinfo of authHTTPAddress.
+func (m *MediaMTXMiddleware) tokenValid(r *http.Request) bool {
+	var candidate string
+
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		candidate = strings.TrimPrefix(auth, "Bearer ")
+	} else if _, password, ok := r.BasicAuth(); ok {
+		candidate = password
+	}
+
+	return candidate != "" && subtle.ConstantTimeCompare([]byte(candidate), []byte(m.token)) == 1
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,129 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/handler"
+)
+
+func TestMediaMTXMiddleware_Token(t *testing.T) {
+	m, err := handler.NewMediaMTXMiddleware("s3cret", nil, nil)
+	require.NoError(t, err)
+
+	h := m.Authenticate(okHandler())
+
+	tests := []struct {
+		name   string
+		setup  func(r *http.Request)
+		target string
+		want   int
+	}{
+		{name: "missing token", target: "/webhook/ready", want: http.StatusUnauthorized},
+		{name: "wrong bearer token", target: "/webhook/ready", want: http.StatusUnauthorized, setup: func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer nope")
+		}},
+		{name: "bearer token", target: "/webhook/ready", want: http.StatusNoContent, setup: func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer s3cret")
+		}},
+		{name: "basic auth password", target: "/auth", want: http.StatusNoContent, setup: func(r *http.Request) {
+			r.SetBasicAuth("mediamtx", "s3cret")
+		}},
+		{name: "query parameter", target: "/auth?token=s3cret", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			if tt.setup != nil {
+				tt.setup(req)
+			}
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.want, recorder.Code)
+		})
+	}
+}
+
+func TestMediaMTXMiddleware_AllowedCIDRs(t *testing.T) {
+	m, err := handler.NewMediaMTXMiddleware("", []string{"10.0.0.0/24", "192.168.1.7"}, nil)
+	require.NoError(t, err)
+
+	h := m.Authenticate(okHandler())
+
+	tests := []struct {
+		remoteAddr string
+		want       int
+	}{
+		{remoteAddr: "10.0.0.42:5000", want: http.StatusNoContent},
+		{remoteAddr: "192.168.1.7:5000", want: http.StatusNoContent},
+		{remoteAddr: "[::ffff:10.0.0.5]:5000", want: http.StatusNoContent},
+		{remoteAddr: "10.0.1.1:5000", want: http.StatusForbidden},
+		{remoteAddr: "192.168.1.8:5000", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.remoteAddr, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhook/not-ready", nil)
+			req.RemoteAddr = tt.remoteAddr
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.want, recorder.Code)
+		})
+	}
+}
+
+func TestMediaMTXMiddleware_TokenAndCIDR(t *testing.T) {
+	m, err := handler.NewMediaMTXMiddleware("s3cret", []string{"10.0.0.0/8"}, nil)
+	require.NoError(t, err)
+
+	h := m.Authenticate(okHandler())
+
+	// Allowed source without a token is still rejected
+	req := httptest.NewRequest(http.MethodPost, "/auth", nil)
+	req.RemoteAddr = "10.1.2.3:5000"
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// Valid token from a disallowed source is rejected
+	req = httptest.NewRequest(http.MethodPost, "/auth", nil)
+	req.SetBasicAuth("mediamtx", "s3cret")
+	req.RemoteAddr = "203.0.113.9:5000"
+	recorder = httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// Both satisfied
+	req = httptest.NewRequest(http.MethodPost, "/auth", nil)
+	req.SetBasicAuth("mediamtx", "s3cret")
+	req.RemoteAddr = "10.1.2.3:5000"
+	recorder = httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestNewMediaMTXMiddleware_RequiresTokenOrCIDR(t *testing.T) {
+	_, err := handler.NewMediaMTXMiddleware("", nil, nil)
+	assert.Error(t, err)
+
+	_, err = handler.NewMediaMTXMiddleware("", []string{" "}, nil)
+	assert.Error(t, err)
+}
+
+func TestNewMediaMTXMiddleware_InvalidCIDR(t *testing.T) {
+	_, err := handler.NewMediaMTXMiddleware("", []string{"not-a-cidr"}, nil)
+	assert.Error(t, err)
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -16,6 +16,8 @@ import (
 
 	"alpineworks.io/rfc9457"
 	"github.com/google/uuid"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
 )
 
 const (
@@ -89,6 +91,14 @@ type KeyStore interface {
 	GetSecret(apiKey string) (string, error)
 }
 
+// TenantKeyStore is a KeyStore whose API keys may be scoped to an organization.
+type TenantKeyStore interface {
+	KeyStore
+	// GetClient returns the secret for an API key and the organization it
+	// is scoped to, or nil for deployment-wide keys.
+	GetClient(ctx context.Context, apiKey string) (string, *uuid.UUID, error)
+}
+
 // AuthMiddleware provides HMAC authentication middleware
 type AuthMiddleware struct {
 	keyStore KeyStore
@@ -161,8 +171,8 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		// Get secret for this API key
-		secret, err := m.keyStore.GetSecret(apiKey)
+		// Get secret and organization for this API key
+		secret, organizationID, err := m.getClient(r.Context(), apiKey)
 		if err != nil {
 			m.logger.Warn("unknown API key",
 				slog.String("api_key", apiKey),
@@ -231,10 +241,33 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 		)
 
 		ctx := context.WithValue(r.Context(), apiKeyKey, apiKey)
+		if organizationID != nil {
+			ctx = domain.ContextWithOrganization(ctx, *organizationID)
+		}
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+func (m *AuthMiddleware) getClient(ctx context.Context, apiKey string) (string, *uuid.UUID, error) {
+	if ts, ok := m.keyStore.(TenantKeyStore); ok {
+		return ts.GetClient(ctx, apiKey)
+	}
+	secret, err := m.keyStore.GetSecret(apiKey)
+	return secret, nil, err
+}
+
+// RequireUnscoped rejects requests from API clients scoped to an
+// organization, for deployment-wide administration.
+func RequireUnscoped(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, scoped := domain.OrganizationFromContext(r.Context()); scoped {
+			WriteError(w, r, ErrForbidden("This endpoint requires a deployment-wide API key"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // responseWriter wraps http.ResponseWriter to capture the status code.
 type responseWriter struct {
 	http.ResponseWriter
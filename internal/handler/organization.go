@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+	"github.com/searchandrescuegg/rescuestream-api/internal/service"
+)
+
+// organizationSlugPattern matches valid organization slugs.
+var organizationSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// OrganizationHandler handles organization and API client management HTTP
+// requests. It is intended for deployment-wide API keys only.
+type OrganizationHandler struct {
+	orgService *service.OrganizationService
+	logger     *slog.Logger
+}
+
+// NewOrganizationHandler creates a new OrganizationHandler.
+func NewOrganizationHandler(orgService *service.OrganizationService, logger *slog.Logger) *OrganizationHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &OrganizationHandler{
+		orgService: orgService,
+		logger:     logger,
+	}
+}
+
+// CreateOrganizationRequest represents the request body for creating an organization.
+type CreateOrganizationRequest struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// CreateAPIClientRequest represents the request body for creating an API client.
+type CreateAPIClientRequest struct {
+	Name string `json:"name"`
+}
+
+// OrganizationListResponse represents the response for listing organizations.
+type OrganizationListResponse struct {
+	Organizations []domain.Organization `json:"organizations"`
+	Count         int                   `json:"count"`
+}
+
+// APIClientListResponse represents the response for listing API clients.
+type APIClientListResponse struct {
+	APIClients []domain.APIClient `json:"api_clients"`
+	Count      int                `json:"count"`
+}
+
+// ServeHTTP routes organization requests to the appropriate handler.
+func (h *OrganizationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	action := vars["action"]
+	clientID := vars["client_id"]
+
+	switch {
+	case action == "api-clients" && r.Method == http.MethodGet && clientID == "":
+		h.listAPIClients(w, r, id)
+	case action == "api-clients" && r.Method == http.MethodPost && clientID == "":
+		h.createAPIClient(w, r, id)
+	case action == "api-clients" && r.Method == http.MethodDelete && clientID != "":
+		h.revokeAPIClient(w, r, id, clientID)
+	case action != "":
+		WriteError(w, r, ErrNotFound("unknown organization action"))
+	case r.Method == http.MethodGet && id == "":
+		h.listOrganizations(w, r)
+	case r.Method == http.MethodPost && id == "":
+		h.createOrganization(w, r)
+	case r.Method == http.MethodGet && id != "":
+		h.getOrganization(w, r, id)
+	default:
+		WriteError(w, r, ErrInvalidRequest("method not allowed"))
+	}
+}
+
+func (h *OrganizationHandler) createOrganization(w http.ResponseWriter, r *http.Request) {
+	var req CreateOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid request body"))
+		return
+	}
+
+	if !organizationSlugPattern.MatchString(req.Slug) {
+		WriteError(w, r, ErrInvalidRequest("slug must be 1-63 lowercase letters, digits or hyphens"))
+		return
+	}
+
+	if req.Name == "" {
+		WriteError(w, r, ErrInvalidRequest("name is required"))
+		return
+	}
+
+	org, err := h.orgService.Create(r.Context(), req.Slug, req.Name)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, org)
+}
+
+func (h *OrganizationHandler) listOrganizations(w http.ResponseWriter, r *http.Request) {
+	orgs, err := h.orgService.List(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list organizations", slog.String("error", err.Error()))
+		WriteError(w, r, ErrInternalServer("failed to list organizations"))
+		return
+	}
+
+	if orgs == nil {
+		orgs = []domain.Organization{}
+	}
+
+	WriteJSON(w, http.StatusOK, OrganizationListResponse{
+		Organizations: orgs,
+		Count:         len(orgs),
+	})
+}
+
+func (h *OrganizationHandler) getOrganization(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid organization ID"))
+		return
+	}
+
+	org, err := h.orgService.GetByID(r.Context(), id)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, org)
+}
+
+// createAPIClient issues an API key and secret scoped to the organization.
+// The secret is only returned in this response.
+func (h *OrganizationHandler) createAPIClient(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid organization ID"))
+		return
+	}
+
+	var req CreateAPIClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid request body"))
+		return
+	}
+
+	if req.Name == "" {
+		WriteError(w, r, ErrInvalidRequest("name is required"))
+		return
+	}
+
+	client, err := h.orgService.CreateAPIClient(r.Context(), id, req.Name)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusCreated, client)
+}
+
+func (h *OrganizationHandler) listAPIClients(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid organization ID"))
+		return
+	}
+
+	clients, err := h.orgService.ListAPIClients(r.Context(), id)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, APIClientListResponse{
+		APIClients: clients,
+		Count:      len(clients),
+	})
+}
+
+func (h *OrganizationHandler) revokeAPIClient(w http.ResponseWriter, r *http.Request, idStr, clientIDStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid organization ID"))
+		return
+	}
+
+	clientID, err := uuid.Parse(clientIDStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid API client ID"))
+		return
+	}
+
+	if err := h.orgService.RevokeAPIClient(r.Context(), id, clientID); err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,246 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/database"
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+	"github.com/searchandrescuegg/rescuestream-api/internal/handler"
+	"github.com/searchandrescuegg/rescuestream-api/internal/service"
+	"github.com/searchandrescuegg/rescuestream-api/internal/testutil"
+)
+
+func TestOrganizations_APIClientSecretOnlyReturnedOnCreation(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	router := setupOrganizationRouter(t, db.Pool)
+	orgID := createOrganization(t, router, "alpha")
+
+	req := httptest.NewRequest(http.MethodPost, "/organizations/"+orgID.String()+"/api-clients", strings.NewReader(`{"name": "dispatch"}`))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+
+	var client domain.APIClient
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&client))
+	assert.True(t, strings.HasPrefix(client.APIKey, "ak_"))
+	assert.NotEmpty(t, client.Secret)
+	assert.Equal(t, orgID, client.OrganizationID)
+
+	req = httptest.NewRequest(http.MethodGet, "/organizations/"+orgID.String()+"/api-clients", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp handler.APIClientListResponse
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+	require.Equal(t, 1, resp.Count)
+	assert.Empty(t, resp.APIClients[0].Secret)
+
+	// Duplicate slugs are rejected
+	req = httptest.NewRequest(http.MethodPost, "/organizations", strings.NewReader(`{"slug": "alpha", "name": "Alpha again"}`))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+}
+
+func TestOrganizations_ScopedRequestsAreIsolated(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	router := setupOrganizationRouter(t, db.Pool)
+	alphaID := createOrganization(t, router, "alpha")
+	bravoID := createOrganization(t, router, "bravo")
+
+	// A broadcaster created by an alpha client belongs to alpha
+	req := scopedRequest(http.MethodPost, "/broadcasters", `{"display_name": "Alpha Drone"}`, alphaID)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var broadcaster domain.Broadcaster
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&broadcaster))
+	assert.Equal(t, alphaID, broadcaster.OrganizationID)
+
+	req = scopedRequest(http.MethodGet, "/broadcasters/"+broadcaster.ID.String(), "", bravoID)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	req = scopedRequest(http.MethodGet, "/broadcasters/"+broadcaster.ID.String(), "", alphaID)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	streamID := createTestOrganizationStream(t, db.Pool, broadcaster.ID, alphaID)
+
+	req = scopedRequest(http.MethodGet, "/streams/"+streamID.String(), "", bravoID)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// Unscoped requests see every organization
+	req = httptest.NewRequest(http.MethodGet, "/streams/"+streamID.String(), nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestOrganizations_SharedStreamsAreReadOnly(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	router := setupOrganizationRouter(t, db.Pool)
+	alphaID := createOrganization(t, router, "alpha")
+	bravoID := createOrganization(t, router, "bravo")
+
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Alpha Drone")
+	_, err := db.Pool.Exec(context.Background(), "UPDATE broadcasters SET organization_id = $1 WHERE id = $2", alphaID, broadcasterID)
+	require.NoError(t, err)
+	streamID := createTestOrganizationStream(t, db.Pool, broadcasterID, alphaID)
+
+	// A stream cannot be shared with its own organization
+	req := scopedRequest(http.MethodPost, "/streams/"+streamID.String()+"/shares", `{"organization_id": "`+alphaID.String()+`"}`, alphaID)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	req = scopedRequest(http.MethodPost, "/streams/"+streamID.String()+"/shares", `{"organization_id": "`+bravoID.String()+`"}`, alphaID)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	// Bravo can now read the stream, including it in its active list
+	req = scopedRequest(http.MethodGet, "/streams/"+streamID.String(), "", bravoID)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	req = scopedRequest(http.MethodGet, "/streams", "", bravoID)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var list handler.StreamListResponse
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&list))
+	require.Equal(t, 1, list.Count)
+	assert.Equal(t, streamID, list.Streams[0].ID)
+
+	// ...but cannot modify it or reshare it
+	req = scopedRequest(http.MethodPatch, "/streams/"+streamID.String(), `{"title": "Bravo's now"}`, bravoID)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	req = scopedRequest(http.MethodGet, "/streams/"+streamID.String()+"/shares", "", bravoID)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// Unsharing revokes access
+	req = scopedRequest(http.MethodDelete, "/streams/"+streamID.String()+"/shares/"+bravoID.String(), "", alphaID)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+
+	req = scopedRequest(http.MethodGet, "/streams/"+streamID.String(), "", bravoID)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestRequireUnscoped_RejectsScopedClients(t *testing.T) {
+	h := handler.RequireUnscoped(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, scopedRequest(http.MethodGet, "/organizations", "", uuid.New()))
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/organizations", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func scopedRequest(method, target, body string, organizationID uuid.UUID) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	return req.WithContext(domain.ContextWithOrganization(req.Context(), organizationID))
+}
+
+func createOrganization(t *testing.T, router *mux.Router, slug string) uuid.UUID {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/organizations", strings.NewReader(`{"slug": "`+slug+`", "name": "`+slug+`"}`))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var org domain.Organization
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&org))
+	return org.ID
+}
+
+func createTestOrganizationStream(t *testing.T, pool *pgxpool.Pool, broadcasterID, organizationID uuid.UUID) uuid.UUID {
+	t.Helper()
+
+	keyID := uuid.New()
+	keyValue := "sk_test_" + keyID.String()[:8]
+	_, err := pool.Exec(context.Background(),
+		"INSERT INTO stream_keys (id, key_value, broadcaster_id, status, created_at, organization_id) VALUES ($1, $2, $3, 'active', NOW(), $4)",
+		keyID, keyValue, broadcasterID, organizationID)
+	require.NoError(t, err)
+
+	streamID := uuid.New()
+	_, err = pool.Exec(context.Background(),
+		"INSERT INTO streams (id, stream_key_id, path, status, started_at, organization_id) VALUES ($1, $2, $3, 'active', NOW(), $4)",
+		streamID, keyID, keyValue, organizationID)
+	require.NoError(t, err)
+
+	return streamID
+}
+
+func setupOrganizationRouter(t *testing.T, pool *pgxpool.Pool) *mux.Router {
+	t.Helper()
+
+	orgService := service.NewOrganizationService(database.NewOrganizationRepo(pool), database.NewAPIClientRepo(pool))
+	orgHandler := handler.NewOrganizationHandler(orgService, nil)
+
+	mediaMTXClient, err := service.NewMediaMTXClient("http://localhost:9997", service.PublicEndpoints{
+		WebRTC: "http://localhost:8889",
+	})
+	require.NoError(t, err)
+	broadcasterRepo := database.NewBroadcasterRepo(pool)
+	streamRepo := database.NewStreamRepo(pool)
+	streamKeyService := service.NewStreamKeyService(database.NewStreamKeyRepo(pool), streamRepo, broadcasterRepo, mediaMTXClient, database.NewUnitOfWork(pool))
+	broadcasterHandler := handler.NewBroadcasterHandler(service.NewBroadcasterService(broadcasterRepo, streamRepo, streamKeyService), nil)
+	streamHandler := handler.NewStreamHandler(service.NewStreamService(streamRepo, mediaMTXClient), nil)
+
+	router := mux.NewRouter()
+	router.Handle("/organizations", orgHandler)
+	router.Handle("/organizations/{id}", orgHandler)
+	router.Handle("/organizations/{id}/{action:api-clients}", orgHandler)
+	router.Handle("/organizations/{id}/{action:api-clients}/{client_id}", orgHandler)
+	router.Handle("/broadcasters", broadcasterHandler)
+	router.Handle("/broadcasters/{id}", broadcasterHandler)
+	router.Handle("/streams", streamHandler)
+	router.Handle("/streams/{id}", streamHandler)
+	router.Handle("/streams/{id}/{action:shares}", streamHandler)
+	router.Handle("/streams/{id}/{action:shares}/{organization_id}", streamHandler)
+	return router
+}
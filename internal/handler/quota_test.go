@@ -0,0 +1,347 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/database"
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+	"github.com/searchandrescuegg/rescuestream-api/internal/handler"
+	"github.com/searchandrescuegg/rescuestream-api/internal/service"
+	"github.com/searchandrescuegg/rescuestream-api/internal/testutil"
+)
+
+func TestStreamKeyHandler_Create_ActiveKeyQuota(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create a broadcaster limited to one active key
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	_, err := db.Pool.Exec(context.Background(), "UPDATE broadcasters SET max_active_keys = 1 WHERE id = $1", broadcasterID)
+	require.NoError(t, err)
+
+	// A default of two would otherwise apply
+	h := setupStreamKeyHandler(t, db.Pool, service.WithStreamKeyQuotaPolicy(service.QuotaPolicy{MaxActiveKeys: 2}))
+
+	body, err := json.Marshal(handler.CreateStreamKeyRequest{BroadcasterID: broadcasterID.String()})
+	require.NoError(t, err)
+
+	// The first key is issued
+	req := httptest.NewRequest(http.MethodPost, "/stream-keys", bytes.NewReader(body))
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	// The second exceeds the quota
+	req = httptest.NewRequest(http.MethodPost, "/stream-keys", bytes.NewReader(body))
+	recorder = httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), handler.ErrorTypeQuotaExceeded)
+	assert.Contains(t, recorder.Body.String(), "1 of 1 active stream keys")
+}
+
+func TestStreamKeyHandler_Create_ConcurrentActiveKeyQuota(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	h := setupStreamKeyHandler(t, db.Pool, service.WithStreamKeyQuotaPolicy(service.QuotaPolicy{MaxActiveKeys: 1}))
+
+	body, err := json.Marshal(handler.CreateStreamKeyRequest{BroadcasterID: broadcasterID.String()})
+	require.NoError(t, err)
+
+	// Simultaneous requests cannot both fit under the quota
+	codes := make([]int, 5)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/stream-keys", bytes.NewReader(body))
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, req)
+			codes[i] = recorder.Code
+		}(i)
+	}
+	wg.Wait()
+
+	var created int
+	for _, code := range codes {
+		if code == http.StatusCreated {
+			created++
+		} else {
+			assert.Equal(t, http.StatusConflict, code)
+		}
+	}
+	assert.Equal(t, 1, created)
+}
+
+func TestStreamKeyService_Update_ExtendingExpiredKeyChecksQuota(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// A broadcaster limited to one active key has a lapsed key and a live one
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	_, err := db.Pool.Exec(context.Background(), "UPDATE broadcasters SET max_active_keys = 1 WHERE id = $1", broadcasterID)
+	require.NoError(t, err)
+
+	lapsedAt := time.Now().Add(-time.Minute)
+	lapsedID := streamKeyIDByValue(t, db.Pool, createTestStreamKey(t, db.Pool, broadcasterID, "active", &lapsedAt))
+	liveID := streamKeyIDByValue(t, db.Pool, createTestStreamKey(t, db.Pool, broadcasterID, "active", nil))
+
+	streamKeyService := service.NewStreamKeyService(
+		database.NewStreamKeyRepo(db.Pool),
+		database.NewStreamRepo(db.Pool),
+		database.NewBroadcasterRepo(db.Pool),
+		nil,
+		database.NewUnitOfWork(db.Pool),
+	)
+	ctx := context.Background()
+	future := time.Now().Add(time.Hour)
+
+	// A past expiry is rejected
+	past := time.Now().Add(-time.Hour)
+	_, err = streamKeyService.Update(ctx, liveID, service.UpdateStreamKeyRequest{ExpiresAt: &past})
+	assert.ErrorIs(t, err, domain.ErrInvalidExpiry)
+
+	// Extending the lapsed key would exceed the quota
+	_, err = streamKeyService.Update(ctx, lapsedID, service.UpdateStreamKeyRequest{ExpiresAt: &future})
+	assert.ErrorIs(t, err, domain.ErrQuotaExceeded)
+
+	// Keys that already count as active can be extended
+	_, err = streamKeyService.Update(ctx, liveID, service.UpdateStreamKeyRequest{ExpiresAt: &future})
+	require.NoError(t, err)
+
+	// Once the live key is revoked the lapsed key can be extended
+	require.NoError(t, streamKeyService.Revoke(ctx, liveID))
+	key, err := streamKeyService.Update(ctx, lapsedID, service.UpdateStreamKeyRequest{ExpiresAt: &future})
+	require.NoError(t, err)
+	assert.True(t, key.IsValid())
+}
+
+func TestAuthHandler_ConcurrentStreamQuota(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create a broadcaster with two keys, one of them live
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	liveValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+	var liveID uuid.UUID
+	err := db.Pool.QueryRow(context.Background(), "SELECT id FROM stream_keys WHERE key_value = $1", liveValue).Scan(&liveID)
+	require.NoError(t, err)
+	createTestStream(t, db.Pool, liveID, liveValue, "active")
+
+	authService := service.NewAuthService(database.NewUnitOfWork(db.Pool),
+		service.WithAuthQuotaPolicy(service.QuotaPolicy{MaxConcurrentStreams: 1}),
+	)
+	h := handler.NewAuthHandler(authService, nil)
+
+	// The second key is rejected while the first is live
+	resp := executeAuthRequest(t, h, publishRequest("10.0.0.1", keyValue))
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	// Raising the broadcaster's limit lets it publish
+	_, err = db.Pool.Exec(context.Background(), "UPDATE broadcasters SET max_concurrent_streams = 2 WHERE id = $1", broadcasterID)
+	require.NoError(t, err)
+
+	resp = executeAuthRequest(t, h, publishRequest("10.0.0.1", keyValue))
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestBroadcasterHandler_QuotasAndUsage(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create broadcaster with a live key
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+	var keyID uuid.UUID
+	err := db.Pool.QueryRow(context.Background(), "SELECT id FROM stream_keys WHERE key_value = $1", keyValue).Scan(&keyID)
+	require.NoError(t, err)
+	createTestStream(t, db.Pool, keyID, keyValue, "active")
+
+	router := mux.NewRouter()
+	router.Handle("/broadcasters/{id}", setupBroadcasterHandler(t, db.Pool))
+
+	// Override the concurrent stream limit
+	body := `{"quotas":{"max_concurrent_streams":3}}`
+	req := httptest.NewRequest(http.MethodPatch, "/broadcasters/"+broadcasterID.String(), strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// Negative quotas are rejected
+	body = `{"quotas":{"max_active_keys":-1}}`
+	req = httptest.NewRequest(http.MethodPatch, "/broadcasters/"+broadcasterID.String(), strings.NewReader(body))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Usage is reported against the effective limits
+	req = httptest.NewRequest(http.MethodGet, "/broadcasters/"+broadcasterID.String(), nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp domain.BroadcasterDetail
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+
+	require.NotNil(t, resp.Quotas.MaxConcurrentStreams)
+	assert.Equal(t, 3, *resp.Quotas.MaxConcurrentStreams)
+	assert.Nil(t, resp.Quotas.MaxActiveKeys)
+	assert.Equal(t, domain.BroadcasterUsage{
+		ActiveKeys:           1,
+		LiveStreams:          1,
+		MaxConcurrentStreams: 3,
+	}, resp.Usage)
+}
+
+func TestDurationEnforcer_EndsStreamsOverLimit(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// One broadcaster limited to an hour, one using the two hour default
+	limitedID := createTestBroadcaster(t, db.Pool, "Limited")
+	_, err := db.Pool.Exec(context.Background(), "UPDATE broadcasters SET max_stream_duration_seconds = 3600 WHERE id = $1", limitedID)
+	require.NoError(t, err)
+	defaultID := createTestBroadcaster(t, db.Pool, "Default")
+
+	var streamIDs []uuid.UUID
+	for _, broadcasterID := range []uuid.UUID{limitedID, defaultID} {
+		keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+		var keyID uuid.UUID
+		err = db.Pool.QueryRow(context.Background(), "SELECT id FROM stream_keys WHERE key_value = $1", keyValue).Scan(&keyID)
+		require.NoError(t, err)
+
+		streamID := createTestStream(t, db.Pool, keyID, keyValue, "active")
+		_, err = db.Pool.Exec(context.Background(), "UPDATE streams SET started_at = NOW() - INTERVAL '90 minutes' WHERE id = $1", streamID)
+		require.NoError(t, err)
+		streamIDs = append(streamIDs, streamID)
+	}
+
+	mediaMTXClient, err := service.NewMediaMTXClient("http://localhost:9997", service.PublicEndpoints{})
+	require.NoError(t, err)
+	enforcer := service.NewDurationEnforcer(database.NewStreamRepo(db.Pool), mediaMTXClient,
+		service.WithDurationEnforcerQuotaPolicy(service.QuotaPolicy{MaxStreamDuration: 2 * time.Hour}),
+	)
+
+	require.NoError(t, enforcer.Sweep(context.Background()))
+
+	// Only the stream over its broadcaster's limit is ended
+	var status string
+	err = db.Pool.QueryRow(context.Background(), "SELECT status FROM streams WHERE id = $1", streamIDs[0]).Scan(&status)
+	require.NoError(t, err)
+	assert.Equal(t, "ended", status)
+
+	err = db.Pool.QueryRow(context.Background(), "SELECT status FROM streams WHERE id = $1", streamIDs[1]).Scan(&status)
+	require.NoError(t, err)
+	assert.Equal(t, "active", status)
+}
+
+func TestDurationEnforcer_WarnsBeforeKeyLimit(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// The broadcaster is limited to two hours; one key tightens that to an
+	// hour and another lifts it
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Limited")
+	_, err := db.Pool.Exec(context.Background(), "UPDATE broadcasters SET max_stream_duration_seconds = 7200 WHERE id = $1", broadcasterID)
+	require.NoError(t, err)
+
+	var keyIDs, streamIDs []uuid.UUID
+	for _, maxSeconds := range []int{3600, 0} {
+		keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+		keyID := streamKeyIDByValue(t, db.Pool, keyValue)
+		keyIDs = append(keyIDs, keyID)
+		_, err = db.Pool.Exec(context.Background(), "UPDATE stream_keys SET max_stream_duration_seconds = $2 WHERE id = $1", keyID, maxSeconds)
+		require.NoError(t, err)
+
+		streamID := createTestStream(t, db.Pool, keyID, keyValue, "active")
+		_, err = db.Pool.Exec(context.Background(), "UPDATE streams SET started_at = NOW() - INTERVAL '58 minutes' WHERE id = $1", streamID)
+		require.NoError(t, err)
+		streamIDs = append(streamIDs, streamID)
+	}
+
+	mediaMTXClient, err := service.NewMediaMTXClient("http://localhost:9997", service.PublicEndpoints{})
+	require.NoError(t, err)
+
+	// A warning that cannot be published is not recorded
+	failing := service.NewDurationEnforcer(database.NewStreamRepo(db.Pool), mediaMTXClient,
+		service.WithDurationWarningLeadTime(5*time.Minute),
+		service.WithDurationEnforcerEventPublisher(failingEventPublisher{}),
+	)
+	require.NoError(t, failing.Sweep(context.Background()))
+
+	var warnings int
+	err = db.Pool.QueryRow(context.Background(), "SELECT COUNT(*) FROM stream_events WHERE type = 'duration_warning'").Scan(&warnings)
+	require.NoError(t, err)
+	assert.Equal(t, 0, warnings)
+
+	events := &recordingEventPublisher{}
+	enforcer := service.NewDurationEnforcer(database.NewStreamRepo(db.Pool), mediaMTXClient,
+		service.WithDurationWarningLeadTime(5*time.Minute),
+		service.WithDurationEnforcerEventPublisher(events),
+	)
+
+	// The stream two minutes from its key's limit is warned once
+	require.NoError(t, enforcer.Sweep(context.Background()))
+	require.NoError(t, enforcer.Sweep(context.Background()))
+
+	published := events.Events()
+	require.Len(t, published, 1)
+	assert.Equal(t, domain.EventStreamDurationWarning, published[0].Type)
+	assert.Equal(t, streamIDs[0], published[0].Data["stream_id"])
+	assert.InDelta(t, 120, published[0].Data["remaining_seconds"], 5)
+
+	err = db.Pool.QueryRow(context.Background(), "SELECT COUNT(*) FROM stream_events WHERE stream_id = $1 AND type = 'duration_warning'", streamIDs[0]).Scan(&warnings)
+	require.NoError(t, err)
+	assert.Equal(t, 1, warnings)
+
+	// Raising the key's limit warns the stream again about the new one
+	streamKeyService := service.NewStreamKeyService(database.NewStreamKeyRepo(db.Pool), database.NewStreamRepo(db.Pool), database.NewBroadcasterRepo(db.Pool), mediaMTXClient, database.NewUnitOfWork(db.Pool))
+	raised := 3700
+	_, err = streamKeyService.Update(context.Background(), keyIDs[0], service.UpdateStreamKeyRequest{MaxStreamDurationSeconds: &raised})
+	require.NoError(t, err)
+	require.NoError(t, enforcer.Sweep(context.Background()))
+	assert.Len(t, events.Events(), 2)
+
+	// Once over the limit the stream is ended with the reason recorded
+	_, err = db.Pool.Exec(context.Background(), "UPDATE streams SET started_at = NOW() - INTERVAL '62 minutes' WHERE id = $1", streamIDs[0])
+	require.NoError(t, err)
+	require.NoError(t, enforcer.Sweep(context.Background()))
+
+	var status, endReason string
+	err = db.Pool.QueryRow(context.Background(), "SELECT status, end_reason FROM streams WHERE id = $1", streamIDs[0]).Scan(&status, &endReason)
+	require.NoError(t, err)
+	assert.Equal(t, "ended", status)
+	assert.Equal(t, string(domain.StreamEndDurationExceeded), endReason)
+
+	// The unlimited key's stream is untouched
+	err = db.Pool.QueryRow(context.Background(), "SELECT status FROM streams WHERE id = $1", streamIDs[1]).Scan(&status)
+	require.NoError(t, err)
+	assert.Equal(t, "active", status)
+}
+
+// failingEventPublisher fails to publish every event.
+type failingEventPublisher struct{}
+
+func (failingEventPublisher) Publish(context.Context, domain.Event) error {
+	return errors.New("event webhook unreachable")
+}
@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -34,23 +38,104 @@ type StreamListResponse struct {
 	Count   int                     `json:"count"`
 }
 
+// UpdateStreamRequest represents the request body for updating a stream.
+// Metadata is a JSON merge patch: null values remove keys.
+type UpdateStreamRequest struct {
+	Title    *string                `json:"title,omitempty"`
+	Notes    *string                `json:"notes,omitempty"`
+	Tags     *[]string              `json:"tags,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ShareStreamRequest represents the request body for sharing a stream with
+// another organization.
+type ShareStreamRequest struct {
+	OrganizationID string `json:"organization_id"`
+}
+
+// StreamShareListResponse represents the response for listing stream shares.
+type StreamShareListResponse struct {
+	Shares []domain.StreamShare `json:"shares"`
+	Count  int                  `json:"count"`
+}
+
+// StreamSegmentListResponse represents the response for listing stream segments.
+type StreamSegmentListResponse struct {
+	Segments []domain.StreamSegment `json:"segments"`
+	Count    int                    `json:"count"`
+}
+
+// StreamTimelineResponse represents the response for a stream's lifecycle timeline.
+type StreamTimelineResponse struct {
+	Events []domain.StreamEvent `json:"events"`
+	Count  int                  `json:"count"`
+}
+
 // ServeHTTP routes stream requests to the appropriate handler.
 func (h *StreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
+	action := vars["action"]
+	orgID := vars["organization_id"]
 
 	switch {
+	case action == "shares" && r.Method == http.MethodGet && orgID == "":
+		h.listShares(w, r, id)
+	case action == "shares" && r.Method == http.MethodPost && orgID == "":
+		h.shareStream(w, r, id)
+	case action == "shares" && r.Method == http.MethodDelete && orgID != "":
+		h.unshareStream(w, r, id, orgID)
+	case action == "segments" && r.Method == http.MethodGet:
+		h.listSegments(w, r, id)
+	case action == "timeline" && r.Method == http.MethodGet:
+		h.getTimeline(w, r, id)
+	case action != "":
+		WriteError(w, r, ErrNotFound("unknown stream action"))
 	case r.Method == http.MethodGet && id == "":
 		h.listStreams(w, r)
 	case r.Method == http.MethodGet && id != "":
 		h.getStream(w, r, id)
+	case r.Method == http.MethodPatch && id != "":
+		h.updateStream(w, r, id)
 	default:
 		WriteError(w, r, ErrInvalidRequest("method not allowed"))
 	}
 }
 
 func (h *StreamHandler) listStreams(w http.ResponseWriter, r *http.Request) {
-	streams, err := h.streamService.ListActive(r.Context())
+	// Tags may be repeated or comma separated: ?tag=k9&tag=sector-4 or ?tag=k9,sector-4
+	var filter domain.StreamFilter
+	for _, value := range r.URL.Query()["tag"] {
+		filter.Tags = append(filter.Tags, strings.Split(value, ",")...)
+	}
+
+	if groupID := r.URL.Query().Get("group_id"); groupID != "" {
+		id, err := uuid.Parse(groupID)
+		if err != nil {
+			WriteError(w, r, ErrInvalidRequest("invalid group_id"))
+			return
+		}
+		filter.GroupID = &id
+	}
+
+	if deviceID := r.URL.Query().Get("device_id"); deviceID != "" {
+		id, err := uuid.Parse(deviceID)
+		if err != nil {
+			WriteError(w, r, ErrInvalidRequest("invalid device_id"))
+			return
+		}
+		filter.DeviceID = &id
+	}
+
+	if deviceType := domain.DeviceType(r.URL.Query().Get("device_type")); deviceType != "" {
+		if !deviceType.IsValid() {
+			WriteError(w, r, ErrInvalidRequest("invalid device_type"))
+			return
+		}
+		filter.DeviceType = deviceType
+	}
+
+	streams, err := h.streamService.ListActive(r.Context(), filter)
 	if err != nil {
 		h.logger.Error("failed to list streams", slog.String("error", err.Error()))
 		WriteError(w, r, ErrInternalServer("failed to list streams"))
@@ -85,3 +170,148 @@ func (h *StreamHandler) getStream(w http.ResponseWriter, r *http.Request, idStr
 
 	WriteJSON(w, http.StatusOK, stream)
 }
+
+func (h *StreamHandler) updateStream(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid stream ID"))
+		return
+	}
+
+	var req UpdateStreamRequest
+	if decodeErr := json.NewDecoder(r.Body).Decode(&req); decodeErr != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid request body"))
+		return
+	}
+
+	if req.Title != nil && utf8.RuneCountInString(*req.Title) > service.MaxStreamTitleLength {
+		WriteError(w, r, ErrInvalidRequest(fmt.Sprintf("title must be at most %d characters", service.MaxStreamTitleLength)))
+		return
+	}
+
+	stream, err := h.streamService.Update(r.Context(), id, service.UpdateStreamRequest{
+		Title:         req.Title,
+		Notes:         req.Notes,
+		Tags:          req.Tags,
+		MetadataPatch: req.Metadata,
+	})
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, stream)
+}
+
+// shareStream grants another organization read access to a stream.
+func (h *StreamHandler) shareStream(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid stream ID"))
+		return
+	}
+
+	var req ShareStreamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid request body"))
+		return
+	}
+
+	orgID, err := uuid.Parse(req.OrganizationID)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid organization_id"))
+		return
+	}
+
+	share, err := h.streamService.Share(r.Context(), id, orgID)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, share)
+}
+
+func (h *StreamHandler) listShares(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid stream ID"))
+		return
+	}
+
+	shares, err := h.streamService.ListShares(r.Context(), id)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, StreamShareListResponse{
+		Shares: shares,
+		Count:  len(shares),
+	})
+}
+
+func (h *StreamHandler) unshareStream(w http.ResponseWriter, r *http.Request, idStr, orgIDStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid stream ID"))
+		return
+	}
+
+	orgID, err := uuid.Parse(orgIDStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid organization ID"))
+		return
+	}
+
+	if err := h.streamService.Unshare(r.Context(), id, orgID); err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *StreamHandler) listSegments(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid stream ID"))
+		return
+	}
+
+	segments, err := h.streamService.ListSegments(r.Context(), id)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, StreamSegmentListResponse{
+		Segments: segments,
+		Count:    len(segments),
+	})
+}
+
+func (h *StreamHandler) getTimeline(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid stream ID"))
+		return
+	}
+
+	events, err := h.streamService.Timeline(r.Context(), id)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, StreamTimelineResponse{
+		Events: events,
+		Count:  len(events),
+	})
+}
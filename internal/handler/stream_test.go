@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/google/uuid"
@@ -150,7 +152,8 @@ func TestStreamHandler_GetStream_ReturnsDetails(t *testing.T) {
 
 	assert.Equal(t, streamID, resp.ID)
 	assert.Equal(t, keyValue, resp.Path)
-	assert.NotEmpty(t, resp.URLs.WebRTC)
+	assert.Equal(t, "http://localhost:8889/"+keyValue+"/whep", resp.URLs.WebRTC)
+	assert.Empty(t, resp.URLs.HLS, "HLS is not enabled")
 }
 
 func TestStreamHandler_GetStream_NotFound(t *testing.T) {
@@ -192,11 +195,282 @@ func TestStreamHandler_GetStream_InvalidID(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, recorder.Code)
 }
 
+func TestStreamHandler_UpdateStream_LabelsAndMetadata(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create broadcaster, stream key and stream with metadata
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+	var keyID uuid.UUID
+	err := db.Pool.QueryRow(context.Background(), "SELECT id FROM stream_keys WHERE key_value = $1", keyValue).Scan(&keyID)
+	require.NoError(t, err)
+
+	streamID := createTestStream(t, db.Pool, keyID, keyValue, "active")
+	_, err = db.Pool.Exec(context.Background(),
+		`UPDATE streams SET metadata = '{"team": "k9", "location": {"sector": "3", "grid": "B2"}}' WHERE id = $1`, streamID)
+	require.NoError(t, err)
+
+	// Setup handler with mux router
+	h := setupStreamHandler(t, db.Pool)
+
+	router := mux.NewRouter()
+	router.Handle("/streams/{id}", h)
+
+	// Execute
+	body := `{
+		"title": "Sector 4 ridge, K9 team",
+		"notes": "Handler reports weak signal",
+		"tags": ["K9", "sector-4", "k9", " "],
+		"metadata": {"team": null, "location": {"sector": "4"}}
+	}`
+	req := httptest.NewRequest(http.MethodPatch, "/streams/"+streamID.String(), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp domain.StreamWithURLs
+	err = json.NewDecoder(recorder.Body).Decode(&resp)
+	require.NoError(t, err)
+
+	require.NotNil(t, resp.Title)
+	assert.Equal(t, "Sector 4 ridge, K9 team", *resp.Title)
+	require.NotNil(t, resp.Notes)
+	assert.Equal(t, "Handler reports weak signal", *resp.Notes)
+	assert.Equal(t, []string{"k9", "sector-4"}, resp.Tags)
+	assert.NotContains(t, resp.Metadata, "team")
+	assert.Equal(t, map[string]interface{}{"sector": "4", "grid": "B2"}, resp.Metadata["location"])
+	assert.NotEmpty(t, resp.URLs.WebRTC)
+}
+
+func TestStreamHandler_UpdateStream_NotFound(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Setup handler with mux router
+	h := setupStreamHandler(t, db.Pool)
+
+	router := mux.NewRouter()
+	router.Handle("/streams/{id}", h)
+
+	// Execute with non-existent ID
+	req := httptest.NewRequest(http.MethodPatch, "/streams/"+uuid.New().String(), strings.NewReader(`{"title": "Test"}`))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestStreamHandler_ListStreams_FilterByTags(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create two active streams with different tags
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+
+	var streamIDs []uuid.UUID
+	for _, tags := range [][]string{{"k9", "sector-4"}, {"drone", "sector-4"}} {
+		keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+		var keyID uuid.UUID
+		err := db.Pool.QueryRow(context.Background(), "SELECT id FROM stream_keys WHERE key_value = $1", keyValue).Scan(&keyID)
+		require.NoError(t, err)
+
+		streamID := createTestStream(t, db.Pool, keyID, keyValue, "active")
+		_, err = db.Pool.Exec(context.Background(), "UPDATE streams SET tags = $2 WHERE id = $1", streamID, tags)
+		require.NoError(t, err)
+		streamIDs = append(streamIDs, streamID)
+	}
+
+	// Setup handler
+	h := setupStreamHandler(t, db.Pool)
+
+	tests := []struct {
+		query    string
+		expected []uuid.UUID
+	}{
+		{"?tag=sector-4", streamIDs},
+		{"?tag=K9", streamIDs[:1]},
+		{"?tag=drone,sector-4", streamIDs[1:]},
+		{"?tag=k9&tag=drone", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/streams"+tt.query, nil)
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, req)
+
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			var resp handler.StreamListResponse
+			require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+
+			var ids []uuid.UUID
+			for _, stream := range resp.Streams {
+				ids = append(ids, stream.ID)
+			}
+			assert.ElementsMatch(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestStreamHandler_TimelineRecordsLifecycleAndEndReason(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Drone Pilot")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+	keyID := streamKeyIDByValue(t, db.Pool, keyValue)
+
+	webhookHandler := handler.NewWebhookHandler(service.NewWebhookProcessor(database.NewStreamRepo(db.Pool), database.NewStreamKeyRepo(db.Pool)), nil)
+	router := mux.NewRouter()
+	router.Handle("/webhook/ready", webhookHandler)
+	router.Handle("/stream-keys/{id}", setupStreamKeyHandler(t, db.Pool))
+	router.Handle("/streams/{id}/{action}", setupStreamHandler(t, db.Pool))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook/ready", strings.NewReader(`{"path":"`+keyValue+`","source_type":"rtmpConn","source_id":"conn-1"}`))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+
+	var streamID uuid.UUID
+	require.NoError(t, db.Pool.QueryRow(context.Background(),
+		"SELECT id FROM streams WHERE stream_key_id = $1", keyID).Scan(&streamID))
+
+	// Revoking the key kicks the publisher and ends the stream
+	req = httptest.NewRequest(http.MethodDelete, "/stream-keys/"+keyID.String(), nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+
+	var endReason string
+	require.NoError(t, db.Pool.QueryRow(context.Background(),
+		"SELECT end_reason FROM streams WHERE id = $1", streamID).Scan(&endReason))
+	assert.Equal(t, string(domain.StreamEndKeyRevoked), endReason)
+
+	req = httptest.NewRequest(http.MethodGet, "/streams/"+streamID.String()+"/timeline", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var timeline handler.StreamTimelineResponse
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&timeline))
+	require.Equal(t, 3, timeline.Count)
+	assert.Equal(t, domain.StreamEventReady, timeline.Events[0].Type)
+	assert.Equal(t, "conn-1", timeline.Events[0].Details["source_id"])
+	assert.Equal(t, domain.StreamEventKicked, timeline.Events[1].Type)
+	assert.Equal(t, domain.StreamEventEnded, timeline.Events[2].Type)
+	assert.Equal(t, string(domain.StreamEndKeyRevoked), timeline.Events[2].Details["reason"])
+}
+
+func TestStreamHealthMonitor_FlagsStalledAndDegradedStreams(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Drone Pilot")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+	keyID := streamKeyIDByValue(t, db.Pool, keyValue)
+	streamID := createTestStream(t, db.Pool, keyID, keyValue, "active")
+
+	// MediaMTX reports the bytes received on the stream's path
+	var bytesReceived atomic.Int64
+	bytesReceived.Store(1000)
+	mediaMTX := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/paths/list" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"pageCount": 1,
+			"itemCount": 1,
+			"items": []map[string]interface{}{
+				{"name": keyValue, "ready": true, "bytesReceived": bytesReceived.Load()},
+			},
+		})
+	}))
+	defer mediaMTX.Close()
+
+	mediaMTXClient, err := service.NewMediaMTXClient(mediaMTX.URL, service.PublicEndpoints{})
+	require.NoError(t, err)
+	events := &recordingEventPublisher{}
+	monitor := service.NewStreamHealthMonitor(database.NewStreamRepo(db.Pool), mediaMTXClient,
+		service.WithStreamHealthEventPublisher(events),
+	)
+
+	health := func() *string {
+		var h *string
+		require.NoError(t, db.Pool.QueryRow(context.Background(), "SELECT health FROM streams WHERE id = $1", streamID).Scan(&h))
+		return h
+	}
+
+	// The first sample is the baseline
+	require.NoError(t, monitor.Sample(context.Background()))
+	assert.Nil(t, health())
+
+	// Nothing received since is a stall
+	require.NoError(t, monitor.Sample(context.Background()))
+	require.NotNil(t, health())
+	assert.Equal(t, "stalled", *health())
+
+	// Data flowing again recovers it
+	bytesReceived.Add(500_000)
+	require.NoError(t, monitor.Sample(context.Background()))
+	assert.Equal(t, "healthy", *health())
+
+	// Below the key's minimum bitrate the stream is degraded
+	_, err = db.Pool.Exec(context.Background(), "UPDATE stream_keys SET min_bitrate_kbps = 1000000000 WHERE id = $1", keyID)
+	require.NoError(t, err)
+	bytesReceived.Add(1)
+	require.NoError(t, monitor.Sample(context.Background()))
+	assert.Equal(t, "degraded", *health())
+
+	// Each change is on the timeline and published
+	published := events.Events()
+	require.Len(t, published, 3)
+	assert.Equal(t, domain.EventStreamHealthChanged, published[0].Type)
+	assert.Equal(t, "stalled", published[0].Data["health"])
+	assert.Equal(t, "healthy", published[1].Data["health"])
+	assert.Equal(t, "stalled", published[1].Data["previous_health"])
+	assert.Equal(t, "degraded", published[2].Data["health"])
+
+	var changes int
+	require.NoError(t, db.Pool.QueryRow(context.Background(),
+		"SELECT COUNT(*) FROM stream_events WHERE stream_id = $1 AND type = 'health_changed'", streamID).Scan(&changes))
+	assert.Equal(t, 3, changes)
+
+	// The stream's health is returned with it
+	router := mux.NewRouter()
+	router.Handle("/streams/{id}", setupStreamHandler(t, db.Pool))
+	req := httptest.NewRequest(http.MethodGet, "/streams/"+streamID.String(), nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp domain.StreamWithURLs
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+	require.NotNil(t, resp.Health)
+	assert.Equal(t, domain.StreamHealthDegraded, *resp.Health)
+
+	// Ended streams have no health
+	require.NoError(t, database.NewStreamRepo(db.Pool).EndStream(context.Background(), streamID, domain.StreamEndPublisherDisconnected))
+	assert.Nil(t, health())
+}
+
 func setupStreamHandler(t *testing.T, pool *pgxpool.Pool) *handler.StreamHandler {
 	t.Helper()
 
 	streamRepo := database.NewStreamRepo(pool)
-	mediaMTXClient, err := service.NewMediaMTXClient("http://localhost:9997", "http://localhost:8889")
+	mediaMTXClient, err := service.NewMediaMTXClient("http://localhost:9997", service.PublicEndpoints{
+		WebRTC: "http://localhost:8889",
+	})
 	require.NoError(t, err)
 	streamService := service.NewStreamService(streamRepo, mediaMTXClient)
 
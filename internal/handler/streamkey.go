@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -33,7 +37,25 @@ func NewStreamKeyHandler(streamKeyService *service.StreamKeyService, logger *slo
 // CreateStreamKeyRequest represents the request body for creating a stream key.
 type CreateStreamKeyRequest struct {
 	BroadcasterID string  `json:"broadcaster_id"`
+	Label         *string `json:"label,omitempty"`
+	Description   *string `json:"description,omitempty"`
 	ExpiresAt     *string `json:"expires_at,omitempty"`
+	DeviceID      *string `json:"device_id,omitempty"`
+}
+
+// UpdateStreamKeyRequest represents the request body for updating a stream key.
+// An empty label or description clears it; "expires_at": null removes the
+// expiry, "device_id": null unbinds the key from its device,
+// "min_bitrate_kbps": null restores the deployment-wide minimum bitrate and
+// "max_stream_duration_seconds": null restores the broadcaster's maximum
+// stream duration.
+type UpdateStreamKeyRequest struct {
+	Label                    *string         `json:"label,omitempty"`
+	Description              *string         `json:"description,omitempty"`
+	ExpiresAt                json.RawMessage `json:"expires_at,omitempty"`
+	DeviceID                 json.RawMessage `json:"device_id,omitempty"`
+	MinBitrateKbps           json.RawMessage `json:"min_bitrate_kbps,omitempty"`
+	MaxStreamDurationSeconds json.RawMessage `json:"max_stream_duration_seconds,omitempty"`
 }
 
 // StreamKeyListResponse represents the response for listing stream keys.
@@ -46,14 +68,25 @@ type StreamKeyListResponse struct {
 func (h *StreamKeyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
+	action := vars["action"]
 
 	switch {
+	case r.Method == http.MethodGet && id != "" && action == "provisioning":
+		h.getProvisioning(w, r, id)
+	case r.Method == http.MethodPost && id != "" && action == "suspend":
+		h.suspendStreamKey(w, r, id)
+	case r.Method == http.MethodPost && id != "" && action == "resume":
+		h.resumeStreamKey(w, r, id)
+	case action != "":
+		WriteError(w, r, ErrNotFound("unknown stream key action"))
 	case r.Method == http.MethodGet && id == "":
 		h.listStreamKeys(w, r)
 	case r.Method == http.MethodPost && id == "":
 		h.createStreamKey(w, r)
 	case r.Method == http.MethodGet && id != "":
 		h.getStreamKey(w, r, id)
+	case r.Method == http.MethodPatch && id != "":
+		h.updateStreamKey(w, r, id)
 	case r.Method == http.MethodDelete && id != "":
 		h.revokeStreamKey(w, r, id)
 	default:
@@ -99,8 +132,15 @@ func (h *StreamKeyHandler) createStreamKey(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if req.Label != nil && utf8.RuneCountInString(*req.Label) > service.MaxStreamKeyLabelLength {
+		WriteError(w, r, ErrInvalidRequest(fmt.Sprintf("label must be at most %d characters", service.MaxStreamKeyLabelLength)))
+		return
+	}
+
 	createReq := service.CreateRequest{
 		BroadcasterID: broadcasterID,
+		Label:         req.Label,
+		Description:   req.Description,
 	}
 
 	if req.ExpiresAt != nil {
@@ -112,6 +152,15 @@ func (h *StreamKeyHandler) createStreamKey(w http.ResponseWriter, r *http.Reques
 		createReq.ExpiresAt = &expiresAt
 	}
 
+	if req.DeviceID != nil {
+		deviceID, parseErr := uuid.Parse(*req.DeviceID)
+		if parseErr != nil {
+			WriteError(w, r, ErrInvalidRequest("invalid device_id"))
+			return
+		}
+		createReq.DeviceID = &deviceID
+	}
+
 	key, err := h.streamKeyService.Create(r.Context(), createReq)
 	if err != nil {
 		httpErr := MapDomainError(err)
@@ -119,7 +168,10 @@ func (h *StreamKeyHandler) createStreamKey(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	WriteJSON(w, http.StatusCreated, key)
+	WriteJSON(w, http.StatusCreated, domain.StreamKeyWithURLs{
+		StreamKey:  *key,
+		IngestURLs: h.streamKeyService.IngestURLs(key.KeyValue),
+	})
 }
 
 func (h *StreamKeyHandler) getStreamKey(w http.ResponseWriter, r *http.Request, idStr string) {
@@ -142,6 +194,141 @@ func (h *StreamKeyHandler) getStreamKey(w http.ResponseWriter, r *http.Request,
 	WriteJSON(w, http.StatusOK, key)
 }
 
+func (h *StreamKeyHandler) updateStreamKey(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid stream key ID"))
+		return
+	}
+
+	var req UpdateStreamKeyRequest
+	if decodeErr := json.NewDecoder(r.Body).Decode(&req); decodeErr != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid request body"))
+		return
+	}
+
+	if req.Label != nil && utf8.RuneCountInString(*req.Label) > service.MaxStreamKeyLabelLength {
+		WriteError(w, r, ErrInvalidRequest(fmt.Sprintf("label must be at most %d characters", service.MaxStreamKeyLabelLength)))
+		return
+	}
+
+	updateReq := service.UpdateStreamKeyRequest{
+		Label:       req.Label,
+		Description: req.Description,
+	}
+
+	if len(req.ExpiresAt) > 0 {
+		if bytes.Equal(req.ExpiresAt, []byte("null")) {
+			updateReq.ClearExpiresAt = true
+		} else {
+			var expiresAt time.Time
+			if parseErr := json.Unmarshal(req.ExpiresAt, &expiresAt); parseErr != nil {
+				WriteError(w, r, ErrInvalidRequest("invalid expires_at format, use RFC3339"))
+				return
+			}
+			if !expiresAt.After(time.Now()) {
+				WriteError(w, r, ErrInvalidRequest("expires_at must be in the future"))
+				return
+			}
+			updateReq.ExpiresAt = &expiresAt
+		}
+	}
+
+	if len(req.DeviceID) > 0 {
+		if bytes.Equal(req.DeviceID, []byte("null")) {
+			updateReq.ClearDeviceID = true
+		} else {
+			var deviceID uuid.UUID
+			if parseErr := json.Unmarshal(req.DeviceID, &deviceID); parseErr != nil {
+				WriteError(w, r, ErrInvalidRequest("invalid device_id"))
+				return
+			}
+			updateReq.DeviceID = &deviceID
+		}
+	}
+
+	if len(req.MinBitrateKbps) > 0 {
+		if bytes.Equal(req.MinBitrateKbps, []byte("null")) {
+			updateReq.ClearMinBitrate = true
+		} else {
+			var minBitrate int
+			if parseErr := json.Unmarshal(req.MinBitrateKbps, &minBitrate); parseErr != nil || minBitrate < 0 {
+				WriteError(w, r, ErrInvalidRequest("min_bitrate_kbps must be a non-negative integer"))
+				return
+			}
+			updateReq.MinBitrateKbps = &minBitrate
+		}
+	}
+
+	if len(req.MaxStreamDurationSeconds) > 0 {
+		if bytes.Equal(req.MaxStreamDurationSeconds, []byte("null")) {
+			updateReq.ClearMaxStreamDuration = true
+		} else {
+			var maxDuration int
+			if parseErr := json.Unmarshal(req.MaxStreamDurationSeconds, &maxDuration); parseErr != nil || maxDuration < 0 {
+				WriteError(w, r, ErrInvalidRequest("max_stream_duration_seconds must be a non-negative integer"))
+				return
+			}
+			updateReq.MaxStreamDurationSeconds = &maxDuration
+		}
+	}
+
+	key, err := h.streamKeyService.Update(r.Context(), id, updateReq)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidStatus) {
+			WriteError(w, r, ErrConflict("the expiry of a revoked or expired stream key cannot be changed"))
+			return
+		}
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, key)
+}
+
+func (h *StreamKeyHandler) suspendStreamKey(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid stream key ID"))
+		return
+	}
+
+	key, err := h.streamKeyService.Suspend(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidStatus) {
+			WriteError(w, r, ErrConflict("only active stream keys can be suspended"))
+			return
+		}
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, key)
+}
+
+func (h *StreamKeyHandler) resumeStreamKey(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid stream key ID"))
+		return
+	}
+
+	key, err := h.streamKeyService.Resume(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidStatus) {
+			WriteError(w, r, ErrConflict("stream key is not suspended"))
+			return
+		}
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, key)
+}
+
 func (h *StreamKeyHandler) revokeStreamKey(w http.ResponseWriter, r *http.Request, idStr string) {
 	id, err := uuid.Parse(idStr)
 	if err != nil {
@@ -157,3 +344,45 @@ func (h *StreamKeyHandler) revokeStreamKey(w http.ResponseWriter, r *http.Reques
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// getProvisioning returns a provisioning bundle for an active stream key.
+// With ?format=png only the QR code image is returned; ?qr= selects what the
+// QR code encodes (larix, rtmp, srt, rtsp or whip).
+func (h *StreamKeyHandler) getProvisioning(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid stream key ID"))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "png" {
+		WriteError(w, r, ErrInvalidRequest("format must be json or png"))
+		return
+	}
+
+	bundle, err := h.streamKeyService.Provisioning(r.Context(), id, service.QRTarget(r.URL.Query().Get("qr")))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidStatus):
+			WriteError(w, r, ErrConflict("stream key is not active"))
+		case errors.Is(err, service.ErrQRTargetUnavailable):
+			WriteError(w, r, ErrInvalidRequest("qr target is unknown or its protocol is disabled"))
+		default:
+			WriteError(w, r, MapDomainError(err))
+		}
+		return
+	}
+
+	// The bundle contains the key value
+	w.Header().Set("Cache-Control", "no-store")
+
+	if format == "png" {
+		w.Header().Set("Content-Type", "image/png")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(bundle.QRCodePNG)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, bundle)
+}
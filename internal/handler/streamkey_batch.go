@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+	"github.com/searchandrescuegg/rescuestream-api/internal/service"
+)
+
+// StreamKeyBatchHandler handles bulk stream key issuance HTTP requests.
+type StreamKeyBatchHandler struct {
+	batchService *service.StreamKeyBatchService
+	logger       *slog.Logger
+}
+
+// NewStreamKeyBatchHandler creates a new StreamKeyBatchHandler.
+func NewStreamKeyBatchHandler(batchService *service.StreamKeyBatchService, logger *slog.Logger) *StreamKeyBatchHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &StreamKeyBatchHandler{
+		batchService: batchService,
+		logger:       logger,
+	}
+}
+
+// CreateStreamKeyBatchRequest represents the request body for issuing a batch
+// of stream keys. One key is issued per listed broadcaster.
+type CreateStreamKeyBatchRequest struct {
+	BroadcasterIDs []string                   `json:"broadcaster_ids,omitempty"`
+	Broadcasters   []CreateBroadcasterRequest `json:"broadcasters,omitempty"`
+	Label          *string                    `json:"label,omitempty"`
+	ExpiresAt      *string                    `json:"expires_at,omitempty"`
+}
+
+// StreamKeyExportResponse represents a JSON stream key batch export.
+type StreamKeyExportResponse struct {
+	BatchID    uuid.UUID                     `json:"batch_id"`
+	StreamKeys []domain.StreamKeyExportEntry `json:"stream_keys"`
+	Count      int                           `json:"count"`
+}
+
+// ServeHTTP routes stream key batch requests to the appropriate handler.
+func (h *StreamKeyBatchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	action := vars["action"]
+
+	switch {
+	case r.Method == http.MethodGet && id != "" && action == "export":
+		h.exportBatch(w, r, id)
+	case action != "":
+		WriteError(w, r, ErrNotFound("unknown stream key batch action"))
+	case r.Method == http.MethodPost && id == "":
+		h.createBatch(w, r)
+	case r.Method == http.MethodGet && id != "":
+		h.getBatch(w, r, id)
+	default:
+		WriteError(w, r, ErrInvalidRequest("method not allowed"))
+	}
+}
+
+func (h *StreamKeyBatchHandler) createBatch(w http.ResponseWriter, r *http.Request) {
+	var req CreateStreamKeyBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid request body"))
+		return
+	}
+
+	total := len(req.BroadcasterIDs) + len(req.Broadcasters)
+	if total == 0 {
+		WriteError(w, r, ErrInvalidRequest("broadcaster_ids or broadcasters is required"))
+		return
+	}
+	if total > service.MaxStreamKeyBatchSize {
+		WriteError(w, r, ErrInvalidRequest(fmt.Sprintf("a batch may issue at most %d keys", service.MaxStreamKeyBatchSize)))
+		return
+	}
+
+	createReq := service.CreateBatchRequest{Label: req.Label}
+
+	seen := make(map[uuid.UUID]bool, len(req.BroadcasterIDs))
+	for _, idStr := range req.BroadcasterIDs {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			WriteError(w, r, ErrInvalidRequest("invalid broadcaster_id: "+idStr))
+			return
+		}
+		if seen[id] {
+			WriteError(w, r, ErrInvalidRequest("duplicate broadcaster_id: "+idStr))
+			return
+		}
+		seen[id] = true
+		createReq.BroadcasterIDs = append(createReq.BroadcasterIDs, id)
+	}
+
+	for _, b := range req.Broadcasters {
+		if b.DisplayName == "" {
+			WriteError(w, r, ErrInvalidRequest("display_name is required for new broadcasters"))
+			return
+		}
+		if !b.Quotas.IsValid() {
+			WriteError(w, r, ErrInvalidRequest("quotas must not be negative"))
+			return
+		}
+		createReq.NewBroadcasters = append(createReq.NewBroadcasters, service.CreateBroadcasterRequest{
+			DisplayName: b.DisplayName,
+			Metadata:    b.Metadata,
+			Quotas:      b.Quotas,
+		})
+	}
+
+	if req.Label != nil && utf8.RuneCountInString(*req.Label) > service.MaxStreamKeyLabelLength {
+		WriteError(w, r, ErrInvalidRequest(fmt.Sprintf("label must be at most %d characters", service.MaxStreamKeyLabelLength)))
+		return
+	}
+
+	if req.ExpiresAt != nil {
+		expiresAt, err := time.Parse(time.RFC3339, *req.ExpiresAt)
+		if err != nil {
+			WriteError(w, r, ErrInvalidRequest("invalid expires_at format, use RFC3339"))
+			return
+		}
+		if !expiresAt.After(time.Now()) {
+			WriteError(w, r, ErrInvalidRequest("expires_at must be in the future"))
+			return
+		}
+		createReq.ExpiresAt = &expiresAt
+	}
+
+	batch, err := h.batchService.Create(r.Context(), createReq)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, batch)
+}
+
+func (h *StreamKeyBatchHandler) getBatch(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid batch ID"))
+		return
+	}
+
+	batch, err := h.batchService.Get(r.Context(), id)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, batch)
+}
+
+// exportBatch returns the key values and ingest URLs of a batch as JSON or,
+// with ?format=csv, as CSV. The export can only be retrieved once.
+func (h *StreamKeyBatchHandler) exportBatch(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid batch ID"))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		WriteError(w, r, ErrInvalidRequest("format must be json or csv"))
+		return
+	}
+
+	export, err := h.batchService.Export(r.Context(), id)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	// The export contains key values
+	w.Header().Set("Cache-Control", "no-store")
+
+	if format == "csv" {
+		h.writeCSV(w, id, export)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, StreamKeyExportResponse{
+		BatchID:    id,
+		StreamKeys: export,
+		Count:      len(export),
+	})
+}
+
+// csvText prefixes free text that spreadsheets would evaluate as a formula
+// with a quote, so it is shown as text.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func (h *StreamKeyBatchHandler) writeCSV(w http.ResponseWriter, id uuid.UUID, export []domain.StreamKeyExportEntry) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="stream-keys-%s.csv"`, id))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{
+		"broadcaster_id", "display_name", "stream_key_id", "key_value", "expires_at",
+		"rtmp_url", "rtsp_url", "srt_url", "whip_url", "label",
+	})
+
+	for _, entry := range export {
+		expiresAt := ""
+		if entry.ExpiresAt != nil {
+			expiresAt = entry.ExpiresAt.UTC().Format(time.RFC3339)
+		}
+		label := ""
+		if entry.Label != nil {
+			label = *entry.Label
+		}
+		_ = cw.Write([]string{
+			entry.BroadcasterID.String(),
+			csvText(entry.DisplayName),
+			entry.StreamKeyID.String(),
+			entry.KeyValue,
+			expiresAt,
+			entry.IngestURLs.RTMP,
+			entry.IngestURLs.RTSP,
+			entry.IngestURLs.SRT,
+			entry.IngestURLs.WHIP,
+			csvText(label),
+		})
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		h.logger.Error("failed to write stream key export", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,228 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/database"
+	"github.com/searchandrescuegg/rescuestream-api/internal/handler"
+	"github.com/searchandrescuegg/rescuestream-api/internal/service"
+	"github.com/searchandrescuegg/rescuestream-api/internal/testutil"
+)
+
+func TestStreamKeyBatchHandler_CreateAndExportOnce(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	existingID := createTestBroadcaster(t, db.Pool, "Team Alpha")
+	router := setupStreamKeyBatchRouter(t, db.Pool)
+
+	// Issue keys for an existing and a new broadcaster
+	expiresAt := time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)
+	body := `{
+		"broadcaster_ids": ["` + existingID.String() + `"],
+		"broadcasters": [{"display_name": "Team Bravo"}],
+		"label": "Op Ridgeline",
+		"expires_at": "` + expiresAt + `"
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/stream-key-batches", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var batch service.StreamKeyBatchResult
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&batch))
+	require.Len(t, batch.Keys, 2)
+	for _, key := range batch.Keys {
+		assert.Empty(t, key.KeyValue, "key values are only in the export")
+		require.NotNil(t, key.Label)
+		assert.Equal(t, "Op Ridgeline", *key.Label)
+		require.NotNil(t, key.BatchID)
+		assert.Equal(t, batch.ID, *key.BatchID)
+	}
+	assert.Equal(t, "Team Alpha", batch.Keys[0].DisplayName)
+	assert.Equal(t, "Team Bravo", batch.Keys[1].DisplayName)
+
+	// The CSV export contains key values and ingest URLs
+	req = httptest.NewRequest(http.MethodGet, "/stream-key-batches/"+batch.ID.String()+"/export?format=csv", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+
+	records, err := csv.NewReader(recorder.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "key_value", records[0][3])
+	assert.True(t, strings.HasPrefix(records[1][3], "sk_"))
+	assert.Equal(t, "rtmp://localhost:1935/"+records[1][3], records[1][5])
+
+	// The export can only be retrieved once
+	req = httptest.NewRequest(http.MethodGet, "/stream-key-batches/"+batch.ID.String()+"/export", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusGone, recorder.Code)
+}
+
+func TestStreamKeyBatchHandler_ExportEscapesFormulas(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	router := setupStreamKeyBatchRouter(t, db.Pool)
+
+	body := `{
+		"broadcasters": [{"display_name": "=HYPERLINK(\"http://example.com\")"}],
+		"label": "@Op Ridgeline"
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/stream-key-batches", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var batch service.StreamKeyBatchResult
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&batch))
+
+	req = httptest.NewRequest(http.MethodGet, "/stream-key-batches/"+batch.ID.String()+"/export?format=csv", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	records, err := csv.NewReader(recorder.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "label", records[0][9])
+	assert.Equal(t, `'=HYPERLINK("http://example.com")`, records[1][1])
+	assert.Equal(t, "'@Op Ridgeline", records[1][9])
+}
+
+func TestStreamKeyBatchHandler_Create_IsAtomic(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	existingID := createTestBroadcaster(t, db.Pool, "Team Alpha")
+	archivedID := createTestBroadcaster(t, db.Pool, "Archived Team")
+	_, err := db.Pool.Exec(context.Background(), "UPDATE broadcasters SET archived_at = NOW() WHERE id = $1", archivedID)
+	require.NoError(t, err)
+
+	router := setupStreamKeyBatchRouter(t, db.Pool)
+
+	// An archived broadcaster fails the whole batch
+	body := `{
+		"broadcaster_ids": ["` + existingID.String() + `", "` + archivedID.String() + `"],
+		"broadcasters": [{"display_name": "Team Bravo"}]
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/stream-key-batches", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	var keys, broadcasters int
+	require.NoError(t, db.Pool.QueryRow(context.Background(), "SELECT COUNT(*) FROM stream_keys").Scan(&keys))
+	require.NoError(t, db.Pool.QueryRow(context.Background(), "SELECT COUNT(*) FROM broadcasters").Scan(&broadcasters))
+	assert.Equal(t, 0, keys)
+	assert.Equal(t, 2, broadcasters)
+
+	// Duplicate broadcaster IDs are rejected
+	body = `{"broadcaster_ids": ["` + existingID.String() + `", "` + existingID.String() + `"]}`
+	req = httptest.NewRequest(http.MethodPost, "/stream-key-batches", strings.NewReader(body))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestStreamKeyBatchHandler_Create_NewBroadcasterQuotas(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	router := setupStreamKeyBatchRouter(t, db.Pool)
+
+	body := `{"broadcasters": [{"display_name": "Team Bravo", "quotas": {"max_active_keys": 2}}]}`
+	req := httptest.NewRequest(http.MethodPost, "/stream-key-batches", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var maxActiveKeys *int
+	require.NoError(t, db.Pool.QueryRow(context.Background(),
+		"SELECT max_active_keys FROM broadcasters WHERE display_name = 'Team Bravo'").Scan(&maxActiveKeys))
+	require.NotNil(t, maxActiveKeys)
+	assert.Equal(t, 2, *maxActiveKeys)
+
+	// Negative quotas are rejected
+	body = `{"broadcasters": [{"display_name": "Team Charlie", "quotas": {"max_active_keys": -1}}]}`
+	req = httptest.NewRequest(http.MethodPost, "/stream-key-batches", strings.NewReader(body))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestStreamKeyBatchService_Create_RepeatedBroadcasterIDs(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Team Alpha")
+	batchService := service.NewStreamKeyBatchService(
+		database.NewStreamKeyBatchRepo(db.Pool),
+		database.NewUnitOfWork(db.Pool),
+		service.NewStreamKeyService(
+			database.NewStreamKeyRepo(db.Pool),
+			database.NewStreamRepo(db.Pool),
+			database.NewBroadcasterRepo(db.Pool),
+			nil,
+			database.NewUnitOfWork(db.Pool),
+			service.WithStreamKeyQuotaPolicy(service.QuotaPolicy{MaxActiveKeys: 1}),
+		),
+	)
+
+	// A repeated ID is issued a single key rather than bypassing the quota
+	batch, err := batchService.Create(context.Background(), service.CreateBatchRequest{
+		BroadcasterIDs: []uuid.UUID{broadcasterID, broadcasterID, broadcasterID},
+	})
+	require.NoError(t, err)
+	assert.Len(t, batch.Keys, 1)
+}
+
+func setupStreamKeyBatchRouter(t *testing.T, pool *pgxpool.Pool) *mux.Router {
+	t.Helper()
+
+	streamKeyRepo := database.NewStreamKeyRepo(pool)
+	broadcasterRepo := database.NewBroadcasterRepo(pool)
+	mediaMTXClient, err := service.NewMediaMTXClient("http://localhost:9997", service.PublicEndpoints{
+		RTMP: "rtmp://localhost:1935",
+	})
+	require.NoError(t, err)
+	streamKeyService := service.NewStreamKeyService(
+		streamKeyRepo,
+		database.NewStreamRepo(pool),
+		broadcasterRepo,
+		mediaMTXClient,
+		database.NewUnitOfWork(pool),
+	)
+	batchService := service.NewStreamKeyBatchService(
+		database.NewStreamKeyBatchRepo(pool),
+		database.NewUnitOfWork(pool),
+		streamKeyService,
+	)
+	h := handler.NewStreamKeyBatchHandler(batchService, nil)
+
+	router := mux.NewRouter()
+	router.Handle("/stream-key-batches", h)
+	router.Handle("/stream-key-batches/{id}", h)
+	router.Handle("/stream-key-batches/{id}/{action}", h)
+	return router
+}
@@ -6,7 +6,10 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -56,6 +59,36 @@ func TestStreamKeyHandler_Create_ReturnsKeyValue(t *testing.T) {
 	assert.Equal(t, domain.StreamKeyStatusActive, resp.Status)
 }
 
+func TestStreamKeyHandler_Create_ReturnsIngestURLs(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create broadcaster
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+
+	// Setup handler
+	h := setupStreamKeyHandler(t, db.Pool)
+
+	// Execute
+	body := `{"broadcaster_id": "` + broadcasterID.String() + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/stream-keys", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	var resp domain.StreamKeyWithURLs
+	err := json.NewDecoder(recorder.Body).Decode(&resp)
+	require.NoError(t, err)
+
+	assert.Equal(t, "rtmp://localhost:1935/"+resp.KeyValue, resp.IngestURLs.RTMP)
+	assert.Equal(t, "srt://localhost:8890?streamid=publish%3A"+resp.KeyValue, resp.IngestURLs.SRT)
+	assert.Equal(t, "http://localhost:8889/"+resp.KeyValue+"/whip", resp.IngestURLs.WHIP)
+	assert.Empty(t, resp.IngestURLs.RTSP, "RTSP is not enabled")
+}
+
 func TestStreamKeyHandler_List_OmitsKeyValue(t *testing.T) {
 	db := testutil.SetupTestDatabase(t)
 	defer db.Cleanup(t)
@@ -168,6 +201,61 @@ func TestStreamKeyHandler_Revoke_InvalidatesKey(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, authResp.Code, "revoked key should fail auth")
 }
 
+func TestStreamKeyHandler_Revoke_ConcurrentRevokesEndStreamOnce(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create a key with a live stream
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+	keyID := streamKeyIDByValue(t, db.Pool, keyValue)
+	streamID := createTestStream(t, db.Pool, keyID, keyValue, "active")
+
+	streamKeyHandler := setupStreamKeyHandler(t, db.Pool)
+
+	router := mux.NewRouter()
+	router.Handle("/stream-keys/{id}", streamKeyHandler)
+
+	// Revoke the key several times at once
+	const revokes = 5
+	codes := make(chan int, revokes)
+	var wg sync.WaitGroup
+	for i := 0; i < revokes; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodDelete, "/stream-keys/"+keyID.String(), nil)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			codes <- recorder.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	// Exactly one revoke succeeds; the others see a revoked key
+	var succeeded int
+	for code := range codes {
+		if code == http.StatusNoContent {
+			succeeded++
+		} else {
+			assert.Equal(t, http.StatusBadRequest, code)
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+
+	// The stream was ended once, as revoked
+	var endReason string
+	err := db.Pool.QueryRow(context.Background(), "SELECT end_reason FROM streams WHERE id = $1", streamID).Scan(&endReason)
+	require.NoError(t, err)
+	assert.Equal(t, "key_revoked", endReason)
+
+	var ended int
+	err = db.Pool.QueryRow(context.Background(), "SELECT COUNT(*) FROM stream_events WHERE stream_id = $1 AND type = 'ended'", streamID).Scan(&ended)
+	require.NoError(t, err)
+	assert.Equal(t, 1, ended)
+}
+
 func TestStreamKeyHandler_Revoke_NotFound(t *testing.T) {
 	db := testutil.SetupTestDatabase(t)
 	defer db.Cleanup(t)
@@ -230,20 +318,306 @@ func TestStreamKeyHandler_Create_InvalidBroadcasterID(t *testing.T) {
 	recorder := httptest.NewRecorder()
 	h.ServeHTTP(recorder, req)
 
-	// Assert - returns 500 because FK constraint violation is not mapped to ErrNotFound
-	// Future improvement: service could validate broadcaster exists first
-	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	// Assert
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestStreamKeyHandler_Create_ArchivedBroadcaster(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create an archived broadcaster
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Archived Broadcaster")
+	_, err := db.Pool.Exec(context.Background(), "UPDATE broadcasters SET archived_at = NOW() WHERE id = $1", broadcasterID)
+	require.NoError(t, err)
+
+	// Setup handler
+	h := setupStreamKeyHandler(t, db.Pool)
+
+	reqBody := handler.CreateStreamKeyRequest{
+		BroadcasterID: broadcasterID.String(),
+	}
+	body, err := json.Marshal(reqBody)
+	require.NoError(t, err)
+
+	// Execute
+	req := httptest.NewRequest(http.MethodPost, "/stream-keys", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+}
+
+func TestStreamKeyHandler_Update(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create broadcaster and a key with an expiry
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	expiresAt := time.Now().Add(time.Hour)
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", &expiresAt)
+
+	var keyID uuid.UUID
+	err := db.Pool.QueryRow(context.Background(), "SELECT id FROM stream_keys WHERE key_value = $1", keyValue).Scan(&keyID)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.Handle("/stream-keys/{id}", setupStreamKeyHandler(t, db.Pool))
+
+	// Set a label and description and remove the expiry
+	body := `{"label":"Drone 2","description":"Mavic thermal","expires_at":null}`
+	req := httptest.NewRequest(http.MethodPatch, "/stream-keys/"+keyID.String(), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp domain.StreamKey
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+	require.NotNil(t, resp.Label)
+	assert.Equal(t, "Drone 2", *resp.Label)
+	require.NotNil(t, resp.Description)
+	assert.Equal(t, "Mavic thermal", *resp.Description)
+	assert.Nil(t, resp.ExpiresAt)
+	assert.Empty(t, resp.KeyValue)
+
+	// A past expiry is rejected
+	body = `{"expires_at":"2020-01-01T00:00:00Z"}`
+	req = httptest.NewRequest(http.MethodPatch, "/stream-keys/"+keyID.String(), strings.NewReader(body))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// The expiry of a revoked key cannot be changed
+	_, err = db.Pool.Exec(context.Background(), "UPDATE stream_keys SET status = 'revoked' WHERE id = $1", keyID)
+	require.NoError(t, err)
+
+	body = `{"expires_at":"` + time.Now().Add(24*time.Hour).Format(time.RFC3339) + `"}`
+	req = httptest.NewRequest(http.MethodPatch, "/stream-keys/"+keyID.String(), strings.NewReader(body))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
 }
 
-func setupStreamKeyHandler(t *testing.T, pool *pgxpool.Pool) *handler.StreamKeyHandler {
+func TestStreamKeyHandler_SuspendAndResume(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create broadcaster and stream key
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+	var keyID uuid.UUID
+	err := db.Pool.QueryRow(context.Background(), "SELECT id FROM stream_keys WHERE key_value = $1", keyValue).Scan(&keyID)
+	require.NoError(t, err)
+
+	authHandler := setupAuthHandler(t, db.Pool)
+	router := mux.NewRouter()
+	router.Handle("/stream-keys/{id}/{action}", setupStreamKeyHandler(t, db.Pool))
+
+	authReq := service.AuthRequest{
+		Password: keyValue,
+		IP:       "192.168.1.1",
+		Action:   "publish",
+		Path:     keyValue,
+		Protocol: "rtmp",
+		ID:       "conn-123",
+	}
+
+	// Suspend the key
+	req := httptest.NewRequest(http.MethodPost, "/stream-keys/"+keyID.String()+"/suspend", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp domain.StreamKey
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+	assert.Equal(t, domain.StreamKeyStatusSuspended, resp.Status)
+	assert.NotNil(t, resp.SuspendedAt)
+
+	// Suspended keys fail auth
+	authResp := executeAuthRequest(t, authHandler, authReq)
+	assert.Equal(t, http.StatusUnauthorized, authResp.Code, "suspended key should fail auth")
+
+	// Suspending again is a conflict
+	req = httptest.NewRequest(http.MethodPost, "/stream-keys/"+keyID.String()+"/suspend", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	// Resume the key
+	req = httptest.NewRequest(http.MethodPost, "/stream-keys/"+keyID.String()+"/resume", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	resp = domain.StreamKey{}
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+	assert.Equal(t, domain.StreamKeyStatusActive, resp.Status)
+	assert.Nil(t, resp.SuspendedAt)
+
+	// Resumed keys pass auth
+	authResp = executeAuthRequest(t, authHandler, authReq)
+	assert.Equal(t, http.StatusOK, authResp.Code, "resumed key should pass auth")
+
+	// Resuming an active key is a conflict
+	req = httptest.NewRequest(http.MethodPost, "/stream-keys/"+keyID.String()+"/resume", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+}
+
+func TestStreamKeyHandler_Provisioning(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create broadcaster and stream key
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+	var keyID uuid.UUID
+	err := db.Pool.QueryRow(context.Background(), "SELECT id FROM stream_keys WHERE key_value = $1", keyValue).Scan(&keyID)
+	require.NoError(t, err)
+
+	// Setup handler with mux router
+	h := setupStreamKeyHandler(t, db.Pool)
+
+	router := mux.NewRouter()
+	router.Handle("/stream-keys/{id}/{action}", h)
+
+	// JSON bundle
+	req := httptest.NewRequest(http.MethodGet, "/stream-keys/"+keyID.String()+"/provisioning", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+
+	var bundle domain.ProvisioningBundle
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&bundle))
+
+	assert.Equal(t, keyID, bundle.StreamKeyID)
+	assert.Equal(t, "rtmp://localhost:1935/"+keyValue, bundle.IngestURLs.RTMP)
+	require.NotNil(t, bundle.OBS)
+	assert.Equal(t, "rtmp://localhost:1935", bundle.OBS.Settings.Server)
+	assert.Equal(t, keyValue, bundle.OBS.Settings.Key)
+	assert.True(t, strings.HasPrefix(bundle.LarixURL, "larix://set/v1?"))
+	assert.Equal(t, bundle.LarixURL, bundle.QRContent)
+	assert.NotEmpty(t, bundle.QRCodePNG)
+
+	// QR code image for a selected target
+	req = httptest.NewRequest(http.MethodGet, "/stream-keys/"+keyID.String()+"/provisioning?format=png&qr=srt", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "image/png", recorder.Header().Get("Content-Type"))
+	assert.True(t, bytes.HasPrefix(recorder.Body.Bytes(), []byte("\x89PNG")))
+
+	// Disabled protocol
+	req = httptest.NewRequest(http.MethodGet, "/stream-keys/"+keyID.String()+"/provisioning?qr=rtsp", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Revoked keys can't be provisioned
+	_, err = db.Pool.Exec(context.Background(), "UPDATE stream_keys SET status = 'revoked' WHERE id = $1", keyID)
+	require.NoError(t, err)
+
+	req = httptest.NewRequest(http.MethodGet, "/stream-keys/"+keyID.String()+"/provisioning", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+}
+
+func TestExpirySweeper_ExpiresKeysAndNotifies(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create one key past expiry with a live stream, and one expiring soon
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	expiredAt := time.Now().Add(-time.Minute)
+	expiredValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", &expiredAt)
+	expiringAt := time.Now().Add(30 * time.Minute)
+	expiringValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", &expiringAt)
+
+	var expiredID, expiringID uuid.UUID
+	err := db.Pool.QueryRow(context.Background(), "SELECT id FROM stream_keys WHERE key_value = $1", expiredValue).Scan(&expiredID)
+	require.NoError(t, err)
+	err = db.Pool.QueryRow(context.Background(), "SELECT id FROM stream_keys WHERE key_value = $1", expiringValue).Scan(&expiringID)
+	require.NoError(t, err)
+
+	streamID := createTestStream(t, db.Pool, expiredID, expiredValue, "active")
+
+	// Setup sweeper
+	streamKeyRepo := database.NewStreamKeyRepo(db.Pool)
+	mediaMTXClient, err := service.NewMediaMTXClient("http://localhost:9997", service.PublicEndpoints{})
+	require.NoError(t, err)
+	streamKeyService := service.NewStreamKeyService(
+		streamKeyRepo,
+		database.NewStreamRepo(db.Pool),
+		database.NewBroadcasterRepo(db.Pool),
+		mediaMTXClient,
+		database.NewUnitOfWork(db.Pool),
+	)
+	events := &recordingEventPublisher{}
+	sweeper := service.NewExpirySweeper(streamKeyRepo, streamKeyService,
+		service.WithExpiryNoticeLeadTimes([]time.Duration{24 * time.Hour, time.Hour, 15 * time.Minute}),
+		service.WithExpiryEventPublisher(events),
+	)
+
+	// Sweep twice; notices are only emitted once
+	require.NoError(t, sweeper.Sweep(context.Background()))
+	require.NoError(t, sweeper.Sweep(context.Background()))
+
+	// The expired key is marked expired and its stream ended
+	var status string
+	err = db.Pool.QueryRow(context.Background(), "SELECT status FROM stream_keys WHERE id = $1", expiredID).Scan(&status)
+	require.NoError(t, err)
+	assert.Equal(t, "expired", status)
+
+	err = db.Pool.QueryRow(context.Background(), "SELECT status FROM streams WHERE id = $1", streamID).Scan(&status)
+	require.NoError(t, err)
+	assert.Equal(t, "ended", status)
+
+	// The other key is still active
+	err = db.Pool.QueryRow(context.Background(), "SELECT status FROM stream_keys WHERE id = $1", expiringID).Scan(&status)
+	require.NoError(t, err)
+	assert.Equal(t, "active", status)
+
+	// One expired event and one expiring-soon event at the 1h lead time
+	require.Len(t, events.Events(), 2)
+	assert.Equal(t, domain.EventStreamKeyExpired, events.Events()[0].Type)
+	assert.Equal(t, expiredID, events.Events()[0].Data["stream_key_id"])
+	assert.Equal(t, domain.EventStreamKeyExpiringSoon, events.Events()[1].Type)
+	assert.Equal(t, expiringID, events.Events()[1].Data["stream_key_id"])
+	assert.Equal(t, 3600, events.Events()[1].Data["lead_time_seconds"])
+}
+
+func setupStreamKeyHandler(t *testing.T, pool *pgxpool.Pool, opts ...service.StreamKeyServiceOption) *handler.StreamKeyHandler {
 	t.Helper()
 
 	streamKeyRepo := database.NewStreamKeyRepo(pool)
 	streamRepo := database.NewStreamRepo(pool)
+	broadcasterRepo := database.NewBroadcasterRepo(pool)
+	mediaMTXClient, err := service.NewMediaMTXClient("http://localhost:9997", service.PublicEndpoints{
+		RTMP:   "rtmp://localhost:1935",
+		SRT:    "srt://localhost:8890",
+		WebRTC: "http://localhost:8889",
+	})
+	require.NoError(t, err)
 	streamKeyService := service.NewStreamKeyService(
 		streamKeyRepo,
 		streamRepo,
-		nil, // mediamtx client not needed for these tests
+		broadcasterRepo,
+		mediaMTXClient,
+		database.NewUnitOfWork(pool),
+		opts...,
 	)
 
 	return handler.NewStreamKeyHandler(streamKeyService, nil)
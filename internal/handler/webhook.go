@@ -7,30 +7,52 @@ import (
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 
 	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+	"github.com/searchandrescuegg/rescuestream-api/internal/service"
 )
 
-// WebhookHandler handles MediaMTX lifecycle webhooks.
+// WebhookHandler handles MediaMTX lifecycle webhooks. With an inbox they are
+// persisted and processed in the background; otherwise they are processed
+// before responding.
 type WebhookHandler struct {
-	streamRepo    domain.StreamRepository
-	streamKeyRepo domain.StreamKeyRepository
-	logger        *slog.Logger
+	processor *service.WebhookProcessor
+	inbox     *service.WebhookInbox
+	logger    *slog.Logger
 }
 
-// NewWebhookHandler creates a new WebhookHandler.
+// WebhookHandlerOption is a functional option for configuring WebhookHandler.
+type WebhookHandlerOption func(*WebhookHandler)
+
+// WithWebhookInbox persists webhooks in the inbox, which processes them in
+// the background, instead of processing them before responding.
+func WithWebhookInbox(inbox *service.WebhookInbox) WebhookHandlerOption {
+	return func(h *WebhookHandler) {
+		h.inbox = inbox
+	}
+}
+
+// NewWebhookHandler creates a new WebhookHandler. The processor applies
+// webhooks processed without an inbox.
 func NewWebhookHandler(
-	streamRepo domain.StreamRepository,
-	streamKeyRepo domain.StreamKeyRepository,
+	processor *service.WebhookProcessor,
 	logger *slog.Logger,
+	opts ...WebhookHandlerOption,
 ) *WebhookHandler {
-	return &WebhookHandler{
-		streamRepo:    streamRepo,
-		streamKeyRepo: streamKeyRepo,
-		logger:        logger,
+	if logger == nil {
+		logger = slog.Default()
+	}
+	h := &WebhookHandler{
+		processor: processor,
+		logger:    logger,
+	}
+
+	for _, opt := range opts {
+		opt(h)
 	}
+
+	return h
 }
 
 // WebhookReadyRequest represents the request body for stream ready webhook.
@@ -38,11 +60,15 @@ type WebhookReadyRequest struct {
 	Path       string `json:"path"`
 	SourceType string `json:"source_type"`
 	SourceID   string `json:"source_id"`
+	// Query is the query string of the ingest URL, carrying publish metadata.
+	Query string `json:"query"`
 }
 
 // WebhookNotReadyRequest represents the request body for stream not ready webhook.
 type WebhookNotReadyRequest struct {
-	Path string `json:"path"`
+	Path       string `json:"path"`
+	SourceType string `json:"source_type"`
+	SourceID   string `json:"source_id"`
 }
 
 // ServeHTTP routes webhook requests to the appropriate handler.
@@ -101,53 +127,31 @@ func (h *WebhookHandler) handleReady(w http.ResponseWriter, r *http.Request) {
 		slog.String("source_id", req.SourceID),
 	)
 
-	// The path is the stream key value in our system
-	// Look up the stream key by its value
-	streamKey, err := h.streamKeyRepo.GetByKeyValue(r.Context(), req.Path)
-	if err != nil {
-		h.logger.Warn("stream key not found for path",
-			slog.String("path", req.Path),
-			slog.String("error", err.Error()),
-		)
-		// Don't fail the webhook - MediaMTX needs 2xx to continue
-		w.WriteHeader(http.StatusNoContent)
-		return
-	}
-
-	// Create stream record
-	stream := &domain.Stream{
-		ID:          uuid.New(),
-		StreamKeyID: streamKey.ID,
-		Path:        req.Path,
-		Status:      domain.StreamStatusActive,
-		StartedAt:   time.Now(),
-		Metadata:    make(map[string]interface{}),
-	}
-
-	if req.SourceType != "" {
-		stream.SourceType = &req.SourceType
-	}
-	if req.SourceID != "" {
-		stream.SourceID = &req.SourceID
+	event := &domain.WebhookEvent{
+		Type:       domain.WebhookEventReady,
+		Path:       req.Path,
+		SourceType: optionalWebhookField(req.SourceType),
+		SourceID:   optionalWebhookField(req.SourceID),
+		Metadata:   make(map[string]interface{}),
+		ReceivedAt: time.Now(),
 	}
 
-	if err := h.streamRepo.Create(r.Context(), stream); err != nil {
-		h.logger.Error("failed to create stream record",
-			slog.String("error", err.Error()),
-			slog.String("path", req.Path),
-		)
-		// Don't fail the webhook - MediaMTX needs 2xx to continue
-		w.WriteHeader(http.StatusNoContent)
-		return
+	// Publish metadata was validated at auth time, so a parse failure here
+	// only means the query changed in between and is dropped. Only the parsed
+	// metadata is kept, never the credentials in the query.
+	if req.Query != "" {
+		metadata, err := service.ParsePublishQuery(req.Query)
+		if err != nil {
+			h.logger.Warn("ignoring invalid publish metadata",
+				slog.String("path", req.Path),
+				slog.String("error", err.Error()),
+			)
+		} else {
+			event.Metadata = metadata
+		}
 	}
 
-	h.logger.Info("stream started",
-		slog.String("stream_id", stream.ID.String()),
-		slog.String("stream_key_id", streamKey.ID.String()),
-		slog.String("path", req.Path),
-	)
-
-	w.WriteHeader(http.StatusNoContent)
+	h.handle(w, r, event)
 }
 
 func (h *WebhookHandler) handleNotReady(w http.ResponseWriter, r *http.Request) {
@@ -167,25 +171,51 @@ func (h *WebhookHandler) handleNotReady(w http.ResponseWriter, r *http.Request)
 
 	h.logger.Info("stream not-ready webhook received",
 		slog.String("path", req.Path),
+		slog.String("source_id", req.SourceID),
 	)
 
-	// End the stream by path
-	if err := h.streamRepo.EndStreamByPath(r.Context(), req.Path); err != nil {
-		if err != domain.ErrNotFound {
-			h.logger.Error("failed to end stream",
+	h.handle(w, r, &domain.WebhookEvent{
+		Type:       domain.WebhookEventNotReady,
+		Path:       req.Path,
+		SourceType: optionalWebhookField(req.SourceType),
+		SourceID:   optionalWebhookField(req.SourceID),
+		ReceivedAt: time.Now(),
+	})
+}
+
+// handle persists a webhook in the inbox, or processes it right away without
+// one.
+func (h *WebhookHandler) handle(w http.ResponseWriter, r *http.Request, event *domain.WebhookEvent) {
+	if h.inbox != nil {
+		if err := h.inbox.Enqueue(r.Context(), event); err != nil {
+			h.logger.Error("failed to persist webhook",
 				slog.String("error", err.Error()),
-				slog.String("path", req.Path),
-			)
-		} else {
-			h.logger.Warn("no active stream found for path",
-				slog.String("path", req.Path),
+				slog.String("type", string(event.Type)),
+				slog.String("path", event.Path),
 			)
+			WriteError(w, r, ErrInternalServer("failed to persist webhook"))
+			return
 		}
-	} else {
-		h.logger.Info("stream ended",
-			slog.String("path", req.Path),
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := h.processor.Process(r.Context(), event); err != nil {
+		h.logger.Error("failed to process webhook",
+			slog.String("error", err.Error()),
+			slog.String("type", string(event.Type)),
+			slog.String("path", event.Path),
 		)
 	}
 
+	// Don't fail the webhook - MediaMTX needs 2xx to continue
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// optionalWebhookField returns nil for an empty webhook field.
+func optionalWebhookField(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+	"github.com/searchandrescuegg/rescuestream-api/internal/service"
+)
+
+// WebhookInboxHandler handles webhook inbox administration HTTP requests.
+type WebhookInboxHandler struct {
+	inbox  *service.WebhookInbox
+	logger *slog.Logger
+}
+
+// NewWebhookInboxHandler creates a new WebhookInboxHandler.
+func NewWebhookInboxHandler(inbox *service.WebhookInbox, logger *slog.Logger) *WebhookInboxHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &WebhookInboxHandler{
+		inbox:  inbox,
+		logger: logger,
+	}
+}
+
+// WebhookEventListResponse represents the response for listing inbox events.
+type WebhookEventListResponse struct {
+	Events []domain.WebhookEvent `json:"events"`
+	Count  int                   `json:"count"`
+}
+
+// ServeHTTP routes webhook inbox requests to the appropriate handler.
+func (h *WebhookInboxHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	action := vars["action"]
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		h.listEvents(w, r)
+	case r.Method == http.MethodGet && id != "" && action == "":
+		h.getEvent(w, r, id)
+	case r.Method == http.MethodPost && id != "" && action == "replay":
+		h.replayEvent(w, r, id)
+	default:
+		WriteError(w, r, ErrInvalidRequest("method not allowed"))
+	}
+}
+
+// listEvents lists inbox events, most recent first, optionally filtered with
+// ?status= and ?path= and limited with ?limit=.
+func (h *WebhookInboxHandler) listEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := domain.WebhookEventFilter{
+		Status: domain.WebhookEventStatus(query.Get("status")),
+		Path:   query.Get("path"),
+	}
+
+	if filter.Status != "" && !filter.Status.IsValid() {
+		WriteError(w, r, ErrInvalidRequest("status must be pending, processed or failed"))
+		return
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			WriteError(w, r, ErrInvalidRequest("limit must be a positive integer"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	events, err := h.inbox.List(r.Context(), filter)
+	if err != nil {
+		h.logger.Error("failed to list webhook events", slog.String("error", err.Error()))
+		WriteError(w, r, ErrInternalServer("failed to list webhook events"))
+		return
+	}
+
+	if events == nil {
+		events = []domain.WebhookEvent{}
+	}
+
+	WriteJSON(w, http.StatusOK, WebhookEventListResponse{
+		Events: events,
+		Count:  len(events),
+	})
+}
+
+func (h *WebhookInboxHandler) getEvent(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid webhook event ID"))
+		return
+	}
+
+	event, err := h.inbox.GetByID(r.Context(), id)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, event)
+}
+
+// replayEvent queues a failed event to be processed again.
+func (h *WebhookInboxHandler) replayEvent(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		WriteError(w, r, ErrInvalidRequest("invalid webhook event ID"))
+		return
+	}
+
+	event, err := h.inbox.Replay(r.Context(), id)
+	if err != nil {
+		httpErr := MapDomainError(err)
+		WriteError(w, r, httpErr)
+		return
+	}
+
+	WriteJSON(w, http.StatusAccepted, event)
+}
@@ -0,0 +1,400 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/database"
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+	"github.com/searchandrescuegg/rescuestream-api/internal/handler"
+	"github.com/searchandrescuegg/rescuestream-api/internal/service"
+	"github.com/searchandrescuegg/rescuestream-api/internal/testutil"
+)
+
+func TestWebhookHandler_Ready_StoresPublishMetadata(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// Create broadcaster and stream key
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+	// Setup handler
+	h := handler.NewWebhookHandler(service.NewWebhookProcessor(database.NewStreamRepo(db.Pool), database.NewStreamKeyRepo(db.Pool)), nil)
+
+	// Execute
+	body := `{"path":"` + keyValue + `","source_type":"rtmpConn","source_id":"conn-1","query":"unit=K9-3&title=Sector+4+ridge&lat=47.61&lon=-121.35&pass=secret"}`
+	req := httptest.NewRequest(http.MethodPost, "/webhook/ready", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	stream, err := database.NewStreamRepo(db.Pool).GetActiveByPath(context.Background(), keyValue)
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, stream.ID)
+
+	require.NotNil(t, stream.Title)
+	assert.Equal(t, "Sector 4 ridge", *stream.Title)
+	assert.Equal(t, "K9-3", stream.Metadata["unit"])
+	assert.Equal(t, 47.61, stream.Metadata["lat"])
+	assert.Equal(t, -121.35, stream.Metadata["lon"])
+	assert.NotContains(t, stream.Metadata, "pass")
+}
+
+func TestWebhookHandler_Ready_ResolvesKeyByPublishPath(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	// A key authenticated with user/password for an unrelated path
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Test Broadcaster")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+	authHandler := setupAuthHandler(t, db.Pool)
+	resp := executeAuthRequest(t, authHandler, service.AuthRequest{
+		User:     broadcasterID.String(),
+		Password: keyValue,
+		IP:       "192.168.1.1",
+		Action:   "publish",
+		Path:     "camera-1",
+		Protocol: "rtsp",
+		ID:       "conn-123",
+	})
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	// Setup handler
+	h := handler.NewWebhookHandler(service.NewWebhookProcessor(database.NewStreamRepo(db.Pool), database.NewStreamKeyRepo(db.Pool)), nil)
+
+	// Execute
+	req := httptest.NewRequest(http.MethodPost, "/webhook/ready", strings.NewReader(`{"path":"camera-1","source_type":"rtspSession","source_id":"conn-123"}`))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	var keyValueOfStream string
+	err := db.Pool.QueryRow(context.Background(), `
+		SELECT k.key_value FROM streams s JOIN stream_keys k ON k.id = s.stream_key_id
+		WHERE s.path = 'camera-1' AND s.status = 'active'`).Scan(&keyValueOfStream)
+	require.NoError(t, err)
+	assert.Equal(t, keyValue, keyValueOfStream)
+}
+
+func TestWebhookHandler_ReconnectWithinGraceResumesStream(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Drone Pilot")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+	streamRepo := database.NewStreamRepo(db.Pool)
+	h := handler.NewWebhookHandler(service.NewWebhookProcessor(streamRepo, database.NewStreamKeyRepo(db.Pool),
+		service.WithWebhookReconnectGrace(time.Minute),
+	), nil)
+	webhook := func(endpoint, body string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, endpoint, strings.NewReader(body))
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusNoContent, recorder.Code)
+	}
+	streamByKey := func() (uuid.UUID, string) {
+		t.Helper()
+		var id uuid.UUID
+		var status string
+		require.NoError(t, db.Pool.QueryRow(context.Background(), `
+			SELECT s.id, s.status FROM streams s JOIN stream_keys k ON k.id = s.stream_key_id
+			WHERE k.key_value = $1 ORDER BY s.started_at DESC LIMIT 1`, keyValue).Scan(&id, &status))
+		return id, status
+	}
+
+	webhook("/webhook/ready", `{"path":"`+keyValue+`","source_type":"rtmpConn","source_id":"conn-1"}`)
+	streamID, status := streamByKey()
+	assert.Equal(t, "active", status)
+
+	// The link drops: the stream is interrupted, not ended
+	webhook("/webhook/not-ready", `{"path":"`+keyValue+`"}`)
+	_, status = streamByKey()
+	assert.Equal(t, "interrupted", status)
+
+	// The same key republishes: the same stream is resumed in a new segment
+	webhook("/webhook/ready", `{"path":"`+keyValue+`","source_type":"rtmpConn","source_id":"conn-2"}`)
+	resumedID, status := streamByKey()
+	assert.Equal(t, streamID, resumedID)
+	assert.Equal(t, "active", status)
+
+	router := mux.NewRouter()
+	router.Handle("/streams/{id}/{action}", setupStreamHandler(t, db.Pool))
+	req := httptest.NewRequest(http.MethodGet, "/streams/"+streamID.String()+"/segments", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var segments handler.StreamSegmentListResponse
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&segments))
+	require.Equal(t, 2, segments.Count)
+	require.NotNil(t, segments.Segments[0].EndedAt)
+	assert.Equal(t, "conn-1", *segments.Segments[0].SourceID)
+	assert.Nil(t, segments.Segments[1].EndedAt)
+	assert.Equal(t, "conn-2", *segments.Segments[1].SourceID)
+
+	// Without a reconnect within the grace period the stream ends as of the drop
+	webhook("/webhook/not-ready", `{"path":"`+keyValue+`"}`)
+	_, err := db.Pool.Exec(context.Background(),
+		"UPDATE streams SET interrupted_at = NOW() - INTERVAL '2 minutes' WHERE id = $1", streamID)
+	require.NoError(t, err)
+
+	require.NoError(t, service.NewReconnectSweeper(streamRepo, time.Minute).Sweep(context.Background()))
+
+	var endedAt, interruptedAt time.Time
+	require.NoError(t, db.Pool.QueryRow(context.Background(),
+		"SELECT status, ended_at, interrupted_at FROM streams WHERE id = $1", streamID).Scan(&status, &endedAt, &interruptedAt))
+	assert.Equal(t, "ended", status)
+	assert.True(t, endedAt.Equal(interruptedAt))
+
+	// Publishing again starts a new stream
+	webhook("/webhook/ready", `{"path":"`+keyValue+`","source_type":"rtmpConn","source_id":"conn-3"}`)
+	newID, status := streamByKey()
+	assert.NotEqual(t, streamID, newID)
+	assert.Equal(t, "active", status)
+}
+
+func TestWebhookHandler_InboxAppliesWebhooksInOrderAndReplaysFailures(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Drone Pilot")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+	streamRepo := database.NewStreamRepo(db.Pool)
+	streamKeyRepo := database.NewStreamKeyRepo(db.Pool)
+	processor := service.NewWebhookProcessor(streamRepo, streamKeyRepo)
+	inbox := service.NewWebhookInbox(database.NewWebhookInboxRepo(db.Pool), processor)
+	h := handler.NewWebhookHandler(processor, nil, handler.WithWebhookInbox(inbox))
+	webhook := func(endpoint, body string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, endpoint, strings.NewReader(body))
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusNoContent, recorder.Code)
+	}
+	count := func(query string) int {
+		t.Helper()
+		var n int
+		require.NoError(t, db.Pool.QueryRow(context.Background(), query).Scan(&n))
+		return n
+	}
+
+	ready := `{"path":"` + keyValue + `","source_type":"rtmpConn","source_id":"conn-1"}`
+	webhook("/webhook/ready", ready)
+	webhook("/webhook/ready", ready)
+	webhook("/webhook/not-ready", `{"path":"`+keyValue+`","source_id":"conn-1"}`)
+
+	// The redelivered ready is dropped and nothing is applied yet
+	assert.Equal(t, 2, count("SELECT COUNT(*) FROM webhook_inbox"))
+	assert.Equal(t, 0, count("SELECT COUNT(*) FROM streams"))
+
+	// A ready waiting to be retried holds back the not-ready received after it
+	_, err := db.Pool.Exec(context.Background(),
+		"UPDATE webhook_inbox SET next_attempt_at = NOW() + INTERVAL '1 hour' WHERE type = 'ready'")
+	require.NoError(t, err)
+	require.NoError(t, inbox.ProcessPending(context.Background()))
+	assert.Equal(t, 2, count("SELECT COUNT(*) FROM webhook_inbox WHERE status = 'pending'"))
+
+	_, err = db.Pool.Exec(context.Background(), "UPDATE webhook_inbox SET next_attempt_at = NOW()")
+	require.NoError(t, err)
+	require.NoError(t, inbox.ProcessPending(context.Background()))
+	assert.Equal(t, 2, count("SELECT COUNT(*) FROM webhook_inbox WHERE status = 'processed'"))
+
+	var status, endReason string
+	require.NoError(t, db.Pool.QueryRow(context.Background(),
+		"SELECT status, end_reason FROM streams WHERE path = $1", keyValue).Scan(&status, &endReason))
+	assert.Equal(t, "ended", status)
+	assert.Equal(t, "publisher_disconnected", endReason)
+
+	// A webhook that ran out of attempts can be inspected and replayed
+	otherKey := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+	var failedID int64
+	require.NoError(t, db.Pool.QueryRow(context.Background(), `
+		INSERT INTO webhook_inbox (type, path, source_id, status, attempts, last_error)
+		VALUES ('ready', $1, 'conn-2', 'failed', 5, 'connection refused')
+		RETURNING id`, otherKey).Scan(&failedID))
+
+	router := mux.NewRouter()
+	inboxHandler := handler.NewWebhookInboxHandler(inbox, nil)
+	router.Handle("/admin/webhooks", inboxHandler)
+	router.Handle("/admin/webhooks/{id}", inboxHandler)
+	router.Handle("/admin/webhooks/{id}/{action}", inboxHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/webhooks?status=failed", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var events handler.WebhookEventListResponse
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&events))
+	require.Equal(t, 1, events.Count)
+	assert.Equal(t, failedID, events.Events[0].ID)
+	require.NotNil(t, events.Events[0].LastError)
+	assert.Equal(t, "connection refused", *events.Events[0].LastError)
+
+	replayURL := "/admin/webhooks/" + strconv.FormatInt(failedID, 10) + "/replay"
+	req = httptest.NewRequest(http.MethodPost, replayURL, nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+
+	// Only failed webhooks can be replayed
+	req = httptest.NewRequest(http.MethodPost, replayURL, nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	require.NoError(t, inbox.ProcessPending(context.Background()))
+
+	stream, err := streamRepo.GetActiveByPath(context.Background(), otherKey)
+	require.NoError(t, err)
+	require.NotNil(t, stream.SourceID)
+	assert.Equal(t, "conn-2", *stream.SourceID)
+}
+
+func TestWebhookHandler_InboxFailuresDoNotBlockPath(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Drone Pilot")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+
+	streamRepo := database.NewStreamRepo(db.Pool)
+	streamKeyRepo := database.NewStreamKeyRepo(db.Pool)
+	processor := service.NewWebhookProcessor(streamRepo, streamKeyRepo)
+	inbox := service.NewWebhookInbox(database.NewWebhookInboxRepo(db.Pool), processor)
+	h := handler.NewWebhookHandler(processor, nil, handler.WithWebhookInbox(inbox))
+	webhook := func(endpoint, body string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, endpoint, strings.NewReader(body))
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusNoContent, recorder.Code)
+	}
+
+	// A ready ran out of attempts
+	var failedID int64
+	require.NoError(t, db.Pool.QueryRow(context.Background(), `
+		INSERT INTO webhook_inbox (type, path, source_type, source_id, status, attempts, last_error)
+		VALUES ('ready', $1, 'rtmpConn', 'conn-1', 'failed', 5, 'connection refused')
+		RETURNING id`, keyValue).Scan(&failedID))
+
+	// Later webhooks on the same path are still applied
+	webhook("/webhook/not-ready", `{"path":"`+keyValue+`","source_id":"conn-1"}`)
+	webhook("/webhook/ready", `{"path":"`+keyValue+`","source_type":"rtmpConn","source_id":"conn-2"}`)
+	require.NoError(t, inbox.ProcessPending(context.Background()))
+
+	var pending int
+	require.NoError(t, db.Pool.QueryRow(context.Background(),
+		"SELECT COUNT(*) FROM webhook_inbox WHERE status = 'pending'").Scan(&pending))
+	assert.Equal(t, 0, pending)
+
+	stream, err := streamRepo.GetActiveByPath(context.Background(), keyValue)
+	require.NoError(t, err)
+	require.NotNil(t, stream.SourceID)
+	assert.Equal(t, "conn-2", *stream.SourceID)
+
+	// The failed ready was superseded and can no longer be replayed
+	_, err = inbox.Replay(context.Background(), failedID)
+	assert.ErrorIs(t, err, domain.ErrInvalidStatus)
+}
+
+func TestWebhookHandler_ReadyAfterRevokeIsRejected(t *testing.T) {
+	db := testutil.SetupTestDatabase(t)
+	defer db.Cleanup(t)
+
+	broadcasterID := createTestBroadcaster(t, db.Pool, "Drone Pilot")
+	keyValue := createTestStreamKey(t, db.Pool, broadcasterID, "active", nil)
+	keyID := streamKeyIDByValue(t, db.Pool, keyValue)
+
+	// MediaMTX has the approved publisher connected on the key's path
+	kicked := make(chan string, 1)
+	mediaMTX := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/v3/rtmpconns/list":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"pageCount": 1,
+				"itemCount": 1,
+				"items":     []map[string]interface{}{{"id": "conn-123", "path": keyValue}},
+			})
+		case strings.HasPrefix(r.URL.Path, "/v3/rtmpconns/kick/"):
+			kicked <- strings.TrimPrefix(r.URL.Path, "/v3/rtmpconns/kick/")
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"pageCount": 0, "itemCount": 0, "items": []interface{}{}})
+		}
+	}))
+	defer mediaMTX.Close()
+	mediaMTXClient, err := service.NewMediaMTXClient(mediaMTX.URL, service.PublicEndpoints{})
+	require.NoError(t, err)
+
+	countStreams := func(status string) int {
+		t.Helper()
+		var n int
+		require.NoError(t, db.Pool.QueryRow(context.Background(),
+			"SELECT COUNT(*) FROM streams WHERE stream_key_id = $1 AND status = $2", keyID, status).Scan(&n))
+		return n
+	}
+
+	// The publish is approved and reserves the key
+	resp := executeAuthRequest(t, setupAuthHandler(t, db.Pool), publishRequest("10.0.0.1", keyValue))
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, 1, countStreams("pending"))
+
+	// Revoking the key drops the reservation
+	streamRepo := database.NewStreamRepo(db.Pool)
+	streamKeyRepo := database.NewStreamKeyRepo(db.Pool)
+	streamKeyService := service.NewStreamKeyService(streamKeyRepo, streamRepo, database.NewBroadcasterRepo(db.Pool),
+		mediaMTXClient, database.NewUnitOfWork(db.Pool))
+	require.NoError(t, streamKeyService.Revoke(context.Background(), keyID))
+	assert.Equal(t, 0, countStreams("pending"))
+
+	// The ready webhook arriving afterwards does not start a stream and the
+	// publisher is kicked
+	h := handler.NewWebhookHandler(service.NewWebhookProcessor(streamRepo, streamKeyRepo,
+		service.WithWebhookMediaMTXClient(mediaMTXClient),
+	), nil)
+	req := httptest.NewRequest(http.MethodPost, "/webhook/ready",
+		strings.NewReader(`{"path":"`+keyValue+`","source_type":"rtmpConn","source_id":"conn-123"}`))
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+
+	assert.Equal(t, 0, countStreams("active"))
+	select {
+	case id := <-kicked:
+		assert.Equal(t, "conn-123", id)
+	default:
+		t.Fatal("publisher was not kicked")
+	}
+
+	// The repository also refuses to start a stream on the revoked key
+	err = streamRepo.Create(context.Background(), &domain.Stream{
+		StreamKeyID: keyID,
+		Path:        keyValue,
+		Status:      domain.StreamStatusActive,
+		StartedAt:   time.Now(),
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidStreamKey)
+}
@@ -22,12 +22,22 @@ type Server struct {
 	logger         *slog.Logger
 	authMiddleware *handler.AuthMiddleware
 
+	mediaMTXMiddleware *handler.MediaMTXMiddleware
+
 	// Handler dependencies (set via options)
 	authHandler        http.Handler
 	webhookHandler     http.Handler
 	streamHandler      http.Handler
 	streamKeyHandler   http.Handler
+	batchHandler       http.Handler
 	broadcasterHandler http.Handler
+	groupHandler       http.Handler
+	deviceHandler      http.Handler
+	lockoutHandler     http.Handler
+	inboxHandler       http.Handler
+	enrollmentHandler  http.Handler
+	enrollHandler      http.Handler
+	orgHandler         http.Handler
 	healthHandler      http.Handler
 }
 
@@ -45,6 +55,13 @@ func WithAuthMiddleware(m *handler.AuthMiddleware) Option {
 	}
 }
 
+// WithMediaMTXMiddleware sets the middleware guarding MediaMTX callbacks.
+func WithMediaMTXMiddleware(m *handler.MediaMTXMiddleware) Option {
+	return func(s *Server) {
+		s.mediaMTXMiddleware = m
+	}
+}
+
 // WithAuthHandler sets the auth handler.
 func WithAuthHandler(h http.Handler) Option {
 	return func(s *Server) {
@@ -80,6 +97,62 @@ func WithBroadcasterHandler(h http.Handler) Option {
 	}
 }
 
+// WithStreamKeyBatchHandler sets the bulk stream key issuance handler.
+func WithStreamKeyBatchHandler(h http.Handler) Option {
+	return func(s *Server) {
+		s.batchHandler = h
+	}
+}
+
+// WithBroadcasterGroupHandler sets the broadcaster group handler.
+func WithBroadcasterGroupHandler(h http.Handler) Option {
+	return func(s *Server) {
+		s.groupHandler = h
+	}
+}
+
+// WithDeviceHandler sets the device handler.
+func WithDeviceHandler(h http.Handler) Option {
+	return func(s *Server) {
+		s.deviceHandler = h
+	}
+}
+
+// WithEnrollmentTokenHandler sets the enrollment token admin handler.
+func WithEnrollmentTokenHandler(h http.Handler) Option {
+	return func(s *Server) {
+		s.enrollmentHandler = h
+	}
+}
+
+// WithEnrollHandler sets the public device enrollment handler.
+func WithEnrollHandler(h http.Handler) Option {
+	return func(s *Server) {
+		s.enrollHandler = h
+	}
+}
+
+// WithOrganizationHandler sets the organization admin handler.
+func WithOrganizationHandler(h http.Handler) Option {
+	return func(s *Server) {
+		s.orgHandler = h
+	}
+}
+
+// WithLockoutHandler sets the authentication lockout admin handler.
+func WithLockoutHandler(h http.Handler) Option {
+	return func(s *Server) {
+		s.lockoutHandler = h
+	}
+}
+
+// WithWebhookInboxHandler sets the webhook inbox admin handler.
+func WithWebhookInboxHandler(h http.Handler) Option {
+	return func(s *Server) {
+		s.inboxHandler = h
+	}
+}
+
 // WithHealthHandler sets the health handler.
 func WithHealthHandler(h http.Handler) Option {
 	return func(s *Server) {
@@ -116,14 +189,19 @@ func (s *Server) setupRoutes() {
 	s.router.Use(handler.RequestIDMiddleware)
 	s.router.Use(handler.LoggingMiddleware(s.logger))
 
-	// Public routes (no auth required) - for MediaMTX
+	// MediaMTX callback routes (shared token and/or source allowlist)
+	callbacks := s.router.PathPrefix("").Subrouter()
+	if s.mediaMTXMiddleware != nil {
+		callbacks.Use(s.mediaMTXMiddleware.Authenticate)
+	}
+
 	if s.authHandler != nil {
-		s.router.Handle("/auth", s.authHandler).Methods(http.MethodPost)
+		callbacks.Handle("/auth", s.authHandler).Methods(http.MethodPost)
 	}
 
 	if s.webhookHandler != nil {
-		s.router.Handle("/webhook/ready", s.webhookHandler).Methods(http.MethodPost)
-		s.router.Handle("/webhook/not-ready", s.webhookHandler).Methods(http.MethodPost)
+		callbacks.Handle("/webhook/ready", s.webhookHandler).Methods(http.MethodPost)
+		callbacks.Handle("/webhook/not-ready", s.webhookHandler).Methods(http.MethodPost)
 	}
 
 	// Health check (no auth required)
@@ -131,6 +209,11 @@ func (s *Server) setupRoutes() {
 		s.router.Handle("/health", s.healthHandler).Methods(http.MethodGet)
 	}
 
+	// Device enrollment (authenticated by the enrollment token)
+	if s.enrollHandler != nil {
+		s.router.Handle("/enroll", s.enrollHandler).Methods(http.MethodPost)
+	}
+
 	// Protected routes (require auth)
 	if s.authMiddleware != nil {
 		protected := s.router.PathPrefix("").Subrouter()
@@ -138,17 +221,69 @@ func (s *Server) setupRoutes() {
 
 		if s.streamHandler != nil {
 			protected.Handle("/streams", s.streamHandler).Methods(http.MethodGet)
-			protected.Handle("/streams/{id}", s.streamHandler).Methods(http.MethodGet)
+			protected.Handle("/streams/{id}", s.streamHandler).Methods(http.MethodGet, http.MethodPatch)
+			protected.Handle("/streams/{id}/{action:shares}", s.streamHandler).Methods(http.MethodGet, http.MethodPost)
+			protected.Handle("/streams/{id}/{action:shares}/{organization_id}", s.streamHandler).Methods(http.MethodDelete)
+			protected.Handle("/streams/{id}/{action:segments|timeline}", s.streamHandler).Methods(http.MethodGet)
 		}
 
 		if s.streamKeyHandler != nil {
 			protected.Handle("/stream-keys", s.streamKeyHandler).Methods(http.MethodGet, http.MethodPost)
-			protected.Handle("/stream-keys/{id}", s.streamKeyHandler).Methods(http.MethodGet, http.MethodDelete)
+			protected.Handle("/stream-keys/{id}", s.streamKeyHandler).Methods(http.MethodGet, http.MethodPatch, http.MethodDelete)
+			protected.Handle("/stream-keys/{id}/{action}", s.streamKeyHandler).Methods(http.MethodGet, http.MethodPost)
+		}
+
+		if s.batchHandler != nil {
+			protected.Handle("/stream-key-batches", s.batchHandler).Methods(http.MethodPost)
+			protected.Handle("/stream-key-batches/{id}", s.batchHandler).Methods(http.MethodGet)
+			protected.Handle("/stream-key-batches/{id}/{action}", s.batchHandler).Methods(http.MethodGet)
 		}
 
 		if s.broadcasterHandler != nil {
 			protected.Handle("/broadcasters", s.broadcasterHandler).Methods(http.MethodGet, http.MethodPost)
 			protected.Handle("/broadcasters/{id}", s.broadcasterHandler).Methods(http.MethodGet, http.MethodPatch, http.MethodDelete)
+			// Purging irreversibly deletes history, so it is an admin operation
+			protected.Handle("/broadcasters/{id}/{action:purge}", handler.RequireUnscoped(s.broadcasterHandler)).Methods(http.MethodPost)
+			protected.Handle("/broadcasters/{id}/{action}", s.broadcasterHandler).Methods(http.MethodPost)
+		}
+
+		if s.groupHandler != nil {
+			protected.Handle("/broadcaster-groups", s.groupHandler).Methods(http.MethodGet, http.MethodPost)
+			protected.Handle("/broadcaster-groups/{id}", s.groupHandler).Methods(http.MethodGet, http.MethodPatch, http.MethodDelete)
+			protected.Handle("/broadcaster-groups/{id}/{action}", s.groupHandler).Methods(http.MethodGet, http.MethodPost)
+			protected.Handle("/broadcaster-groups/{id}/{action}/{sub_id}", s.groupHandler).Methods(http.MethodGet, http.MethodDelete)
+		}
+
+		if s.deviceHandler != nil {
+			protected.Handle("/devices", s.deviceHandler).Methods(http.MethodGet, http.MethodPost)
+			protected.Handle("/devices/{id}", s.deviceHandler).Methods(http.MethodGet, http.MethodPatch, http.MethodDelete)
+		}
+
+		if s.enrollmentHandler != nil {
+			protected.Handle("/enrollment-tokens", s.enrollmentHandler).Methods(http.MethodGet, http.MethodPost)
+			protected.Handle("/enrollment-tokens/{id}", s.enrollmentHandler).Methods(http.MethodGet, http.MethodDelete)
+		}
+
+		// Deployment-wide administration (unavailable to organization-scoped API clients)
+		if s.orgHandler != nil {
+			orgHandler := handler.RequireUnscoped(s.orgHandler)
+			protected.Handle("/organizations", orgHandler).Methods(http.MethodGet, http.MethodPost)
+			protected.Handle("/organizations/{id}", orgHandler).Methods(http.MethodGet)
+			protected.Handle("/organizations/{id}/{action:api-clients}", orgHandler).Methods(http.MethodGet, http.MethodPost)
+			protected.Handle("/organizations/{id}/{action:api-clients}/{client_id}", orgHandler).Methods(http.MethodDelete)
+		}
+
+		if s.lockoutHandler != nil {
+			lockoutHandler := handler.RequireUnscoped(s.lockoutHandler)
+			protected.Handle("/admin/lockouts", lockoutHandler).Methods(http.MethodGet)
+			protected.Handle("/admin/lockouts/{scope}/{subject:.+}", lockoutHandler).Methods(http.MethodDelete)
+		}
+
+		if s.inboxHandler != nil {
+			inboxHandler := handler.RequireUnscoped(s.inboxHandler)
+			protected.Handle("/admin/webhooks", inboxHandler).Methods(http.MethodGet)
+			protected.Handle("/admin/webhooks/{id}", inboxHandler).Methods(http.MethodGet)
+			protected.Handle("/admin/webhooks/{id}/{action:replay}", inboxHandler).Methods(http.MethodPost)
 		}
 	}
 }
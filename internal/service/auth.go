@@ -5,20 +5,23 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
-
 	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
 )
 
+// DefaultReservationTTL is how long a publish approved at authentication
+// reserves its key by default while waiting for the ready webhook.
+const DefaultReservationTTL = 30 * time.Second
+
 // AuthService handles stream key authentication.
 type AuthService struct {
-	pool          *pgxpool.Pool
-	streamKeyRepo domain.StreamKeyRepository
-	streamRepo    domain.StreamRepository
-	logger        *slog.Logger
+	unitOfWork     domain.UnitOfWork
+	lockoutService *LockoutService
+	quotas         QuotaPolicy
+	reservationTTL time.Duration
+	logger         *slog.Logger
 }
 
 // AuthServiceOption is a functional option for configuring AuthService.
@@ -31,31 +34,59 @@ func WithAuthLogger(logger *slog.Logger) AuthServiceOption {
 	}
 }
 
-// NewAuthService creates a new AuthService.
-func NewAuthService(
-	pool *pgxpool.Pool,
-	streamKeyRepo domain.StreamKeyRepository,
-	streamRepo domain.StreamRepository,
-	opts ...AuthServiceOption,
-) *AuthService {
+// WithLockoutService enables brute-force lockout tracking for AuthService.
+func WithLockoutService(lockoutService *LockoutService) AuthServiceOption {
+	return func(s *AuthService) {
+		s.lockoutService = lockoutService
+	}
+}
+
+// WithAuthQuotaPolicy sets the deployment-wide broadcaster quotas enforced
+// when publishing.
+func WithAuthQuotaPolicy(policy QuotaPolicy) AuthServiceOption {
+	return func(s *AuthService) {
+		s.quotas = policy
+	}
+}
+
+// WithReservationTTL sets how long a publish approved at authentication
+// reserves its key while waiting for the ready webhook.
+func WithReservationTTL(ttl time.Duration) AuthServiceOption {
+	return func(s *AuthService) {
+		s.reservationTTL = ttl
+	}
+}
+
+// NewAuthService creates a new AuthService checking and reserving keys in
+// units of work.
+func NewAuthService(unitOfWork domain.UnitOfWork, opts ...AuthServiceOption) *AuthService {
 	s := &AuthService{
-		pool:          pool,
-		streamKeyRepo: streamKeyRepo,
-		streamRepo:    streamRepo,
-		logger:        slog.Default(),
+		unitOfWork:     unitOfWork,
+		reservationTTL: DefaultReservationTTL,
+		logger:         slog.Default(),
 	}
 
 	for _, opt := range opts {
 		opt(s)
 	}
 
+	if s.reservationTTL <= 0 {
+		s.reservationTTL = DefaultReservationTTL
+	}
+
 	return s
 }
 
 // AuthRequest represents an authentication request from MediaMTX.
+//
+// Publishers present a stream key in one of three ways:
+//   - as the path, e.g. rtmp://host/<key>
+//   - as the password, publishing to any path
+//   - as the password with the key or broadcaster ID as user, publishing to
+//     any path (RTSP basic auth, SRT stream IDs, ?user=&pass= query)
 type AuthRequest struct {
 	User     string `json:"user"`
-	Password string `json:"password"` // This is the stream key
+	Password string `json:"password"`
 	IP       string `json:"ip"`
 	Action   string `json:"action"`
 	Path     string `json:"path"`
@@ -69,45 +100,126 @@ type AuthResult struct {
 	Allowed     bool
 	StreamKeyID *string
 	Reason      string
+
+	// invalidCredentials marks rejections that count towards a lockout.
+	invalidCredentials bool
 }
 
 // Authenticate validates a stream key for publishing.
-// It uses a transaction with SELECT FOR UPDATE to prevent race conditions.
+// It locks the key in a unit of work to prevent race conditions,
+// and reserves the key for the connection with a pending stream so that no
+// other publisher passes the in-use check before the ready webhook arrives.
+// The same connection authenticating again renews its reservation.
 func (s *AuthService) Authenticate(ctx context.Context, req AuthRequest) (*AuthResult, error) {
 	// Only authenticate publish actions
 	if req.Action != "publish" {
 		return &AuthResult{Allowed: true, Reason: "non-publish action allowed"}, nil
 	}
 
-	// The stream key can be sent as password OR as the path
+	if req.Protocol == "srt" && isSRTStreamID(req.Path) {
+		streamID, err := ParseSRTStreamID(req.Path)
+		if err != nil {
+			return &AuthResult{Allowed: false, Reason: "invalid SRT stream ID: " + err.Error()}, nil
+		}
+		req.Path = streamID.Path
+		if req.User == "" && req.Password == "" {
+			req.User, req.Password = streamID.User, streamID.Password
+		}
+	}
+
+	if s.lockoutService != nil {
+		lockout, err := s.lockoutService.Check(ctx, req.IP, req.Path)
+		if err != nil {
+			return nil, err
+		}
+		if lockout != nil {
+			return &AuthResult{Allowed: false, Reason: "too many failed attempts"}, nil
+		}
+	}
+
+	if _, err := ParsePublishQuery(req.Query); err != nil {
+		return &AuthResult{Allowed: false, Reason: "invalid publish metadata: " + err.Error()}, nil
+	}
+
+	result, err := s.authenticatePublish(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.trackAttempt(ctx, req, result)
+
+	return result, nil
+}
+
+// trackAttempt feeds the outcome of a publish attempt into the lockout policy.
+// Failures here are logged rather than returned so that lockout bookkeeping
+// never changes the authentication decision.
+func (s *AuthService) trackAttempt(ctx context.Context, req AuthRequest, result *AuthResult) {
+	if s.lockoutService == nil {
+		return
+	}
+
+	var err error
+	switch {
+	case result.Allowed:
+		err = s.lockoutService.RecordSuccess(ctx, req.IP, req.Path)
+	case result.invalidCredentials:
+		err = s.lockoutService.RecordFailure(ctx, req.IP, req.Path)
+	}
+	if err != nil {
+		s.logger.Error("failed to track authentication attempt",
+			slog.String("error", err.Error()),
+			slog.String("path", req.Path),
+			slog.String("ip", req.IP),
+		)
+	}
+}
+
+func (s *AuthService) authenticatePublish(ctx context.Context, req AuthRequest) (*AuthResult, error) {
+	path := strings.TrimPrefix(req.Path, "/")
+	anonymous := req.User == "" && req.Password == ""
+
+	// Without a password the path is the stream key
 	// RTMP clients typically use: rtmp://host:1935/<stream_key>
-	// which puts the key in the path field
 	keyValue := req.Password
+	if anonymous {
+		keyValue = path
+	}
 	if keyValue == "" {
-		// Try extracting from path (strip leading slash if present)
-		keyValue = req.Path
-		if len(keyValue) > 0 && keyValue[0] == '/' {
-			keyValue = keyValue[1:]
+		if anonymous && challengesForCredentials(req.Protocol) {
+			return &AuthResult{Allowed: false, Reason: "credentials required"}, nil
 		}
+		return &AuthResult{Allowed: false, Reason: "missing stream key", invalidCredentials: true}, nil
 	}
-	if keyValue == "" {
-		return &AuthResult{Allowed: false, Reason: "missing stream key"}, nil
+	if path == "" {
+		return &AuthResult{Allowed: false, Reason: "missing path"}, nil
 	}
 
 	var result *AuthResult
 
-	// Use a transaction to ensure atomic check-and-update
-	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+	// Check and reserve the key atomically
+	err := s.unitOfWork.WithTx(ctx, func(repos domain.Repositories) error {
 		// Get and lock the stream key
-		key, err := s.getAndLockStreamKey(ctx, tx, keyValue)
+		key, err := repos.StreamKeys.GetAndLockByKeyValue(ctx, keyValue)
 		if err != nil {
 			if errors.Is(err, domain.ErrNotFound) {
-				result = &AuthResult{Allowed: false, Reason: "invalid stream key"}
+				if anonymous && challengesForCredentials(req.Protocol) {
+					// A path shaped like a key is a guess rather than a
+					// client waiting to be challenged
+					result = &AuthResult{Allowed: false, Reason: "credentials required", invalidCredentials: looksLikeStreamKey(keyValue)}
+					return nil
+				}
+				result = &AuthResult{Allowed: false, Reason: "invalid stream key", invalidCredentials: true}
 				return nil
 			}
 			return fmt.Errorf("failed to get stream key: %w", err)
 		}
 
+		if req.User != "" && req.User != key.ID.String() && req.User != key.BroadcasterID.String() {
+			result = &AuthResult{Allowed: false, Reason: "invalid credentials", invalidCredentials: true}
+			return nil
+		}
+
 		// Check if key is valid
 		if key.Status == domain.StreamKeyStatusRevoked {
 			result = &AuthResult{Allowed: false, Reason: "stream key revoked"}
@@ -119,30 +231,61 @@ func (s *AuthService) Authenticate(ctx context.Context, req AuthRequest) (*AuthR
 			return nil
 		}
 
+		if key.Status == domain.StreamKeyStatusSuspended {
+			result = &AuthResult{Allowed: false, Reason: "stream key suspended"}
+			return nil
+		}
+
 		if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
-			// Mark as expired
-			_, err = tx.Exec(ctx, "UPDATE stream_keys SET status = 'expired' WHERE id = $1", key.ID)
-			if err != nil {
+			if err := repos.StreamKeys.MarkExpired(ctx, key.ID); err != nil {
 				return fmt.Errorf("failed to update expired status: %w", err)
 			}
 			result = &AuthResult{Allowed: false, Reason: "stream key expired"}
 			return nil
 		}
 
-		// Check if key is already in use (has active stream)
-		activeStream, err := s.getActiveStreamByKeyID(ctx, tx, key.ID)
+		// Check if key is already in use (has an active stream, or is
+		// reserved by another connection)
+		liveStream, err := repos.Streams.GetLiveByStreamKeyID(ctx, key.ID)
 		if err != nil && !errors.Is(err, domain.ErrNotFound) {
 			return fmt.Errorf("failed to check active stream: %w", err)
 		}
-		if activeStream != nil {
+		if liveStream != nil && !isReservedFor(liveStream, req.ID) {
 			result = &AuthResult{Allowed: false, Reason: "stream key already in use"}
 			return nil
 		}
 
-		// Update last used timestamp
-		_, err = tx.Exec(ctx, "UPDATE stream_keys SET last_used_at = NOW() WHERE id = $1", key.ID)
+		limit, liveStreams, err := s.concurrentStreamUsage(ctx, repos, key)
 		if err != nil {
-			return fmt.Errorf("failed to update last used: %w", err)
+			return fmt.Errorf("failed to check concurrent streams: %w", err)
+		}
+		if limit > 0 && liveStreams >= limit {
+			result = &AuthResult{Allowed: false, Reason: fmt.Sprintf("broadcaster concurrent stream limit reached (%d of %d live)", liveStreams, limit)}
+			return nil
+		}
+
+		// The path is chosen by the publisher when it isn't the key, so
+		// it may already be taken by another key's stream
+		pathInUse, err := repos.Streams.IsPathLive(ctx, path, key.ID)
+		if err != nil {
+			return err
+		}
+		if pathInUse {
+			result = &AuthResult{Allowed: false, Reason: "path already in use"}
+			return nil
+		}
+
+		if err := repos.Streams.Reserve(ctx, key.ID, path, req.ID, s.reservationTTL); err != nil {
+			if errors.Is(err, domain.ErrStreamKeyInUse) {
+				result = &AuthResult{Allowed: false, Reason: "stream key already in use"}
+				return nil
+			}
+			return err
+		}
+
+		// Update last used timestamp and remember the path for lifecycle webhooks
+		if err := repos.StreamKeys.RecordPublish(ctx, key.ID, path); err != nil {
+			return err
 		}
 
 		keyIDStr := key.ID.String()
@@ -155,7 +298,8 @@ func (s *AuthService) Authenticate(ctx context.Context, req AuthRequest) (*AuthR
 		s.logger.Info("stream key authenticated",
 			slog.String("key_id", key.ID.String()),
 			slog.String("broadcaster_id", key.BroadcasterID.String()),
-			slog.String("path", req.Path),
+			slog.String("path", path),
+			slog.String("protocol", req.Protocol),
 			slog.String("ip", req.IP),
 		)
 
@@ -169,56 +313,31 @@ func (s *AuthService) Authenticate(ctx context.Context, req AuthRequest) (*AuthR
 	return result, nil
 }
 
-func (s *AuthService) getAndLockStreamKey(ctx context.Context, tx pgx.Tx, keyValue string) (*domain.StreamKey, error) {
-	query := `
-		SELECT id, key_value, broadcaster_id, status, created_at, expires_at, revoked_at, last_used_at
-		FROM stream_keys
-		WHERE key_value = $1
-		FOR UPDATE
-	`
-
-	var key domain.StreamKey
-	err := tx.QueryRow(ctx, query, keyValue).Scan(
-		&key.ID,
-		&key.KeyValue,
-		&key.BroadcasterID,
-		&key.Status,
-		&key.CreatedAt,
-		&key.ExpiresAt,
-		&key.RevokedAt,
-		&key.LastUsedAt,
-	)
+// concurrentStreamUsage returns the concurrent stream limit of a key's
+// broadcaster and its current number of live streams, counting unexpired
+// reservations of its other keys. The broadcaster stays locked until the unit
+// of work ends, so its other keys cannot be reserved meanwhile.
+func (s *AuthService) concurrentStreamUsage(ctx context.Context, repos domain.Repositories, key *domain.StreamKey) (int, int, error) {
+	broadcaster, err := repos.Broadcasters.GetAndLockByID(ctx, key.BroadcasterID)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, domain.ErrNotFound
-		}
-		return nil, err
+		return 0, 0, err
 	}
 
-	return &key, nil
-}
+	limit := s.quotas.For(broadcaster.Quotas).MaxConcurrentStreams
+	if limit <= 0 {
+		return 0, 0, nil
+	}
 
-func (s *AuthService) getActiveStreamByKeyID(ctx context.Context, tx pgx.Tx, keyID interface{}) (*domain.Stream, error) {
-	query := `
-		SELECT id, stream_key_id, path, status, started_at
-		FROM streams
-		WHERE stream_key_id = $1 AND status = 'active'
-	`
-
-	var stream domain.Stream
-	err := tx.QueryRow(ctx, query, keyID).Scan(
-		&stream.ID,
-		&stream.StreamKeyID,
-		&stream.Path,
-		&stream.Status,
-		&stream.StartedAt,
-	)
+	liveStreams, err := repos.Streams.CountLiveByBroadcasterID(ctx, key.BroadcasterID, key.ID)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, domain.ErrNotFound
-		}
-		return nil, err
+		return 0, 0, err
 	}
 
-	return &stream, nil
+	return limit, liveStreams, nil
+}
+
+// isReservedFor checks if a live stream is a reservation by the connection.
+func isReservedFor(stream *domain.Stream, connectionID string) bool {
+	return stream.Status == domain.StreamStatusPending &&
+		connectionID != "" && stream.SourceID != nil && *stream.SourceID == connectionID
 }
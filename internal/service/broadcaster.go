@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"time"
 
@@ -10,10 +12,22 @@ import (
 	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
 )
 
+const (
+	// DefaultBroadcasterPageSize is the page size used when none is requested.
+	DefaultBroadcasterPageSize = 50
+	// MaxBroadcasterPageSize is the largest page size that may be requested.
+	MaxBroadcasterPageSize = 200
+
+	// RecentStreamWindow is how far back streams count as recent in broadcaster details.
+	RecentStreamWindow = 24 * time.Hour
+)
+
 // BroadcasterService handles broadcaster management.
 type BroadcasterService struct {
-	broadcasterRepo domain.BroadcasterRepository
-	logger          *slog.Logger
+	broadcasterRepo  domain.BroadcasterRepository
+	streamRepo       domain.StreamRepository
+	streamKeyService *StreamKeyService
+	logger           *slog.Logger
 }
 
 // BroadcasterServiceOption is a functional option for configuring BroadcasterService.
@@ -29,11 +43,15 @@ func WithBroadcasterLogger(logger *slog.Logger) BroadcasterServiceOption {
 // NewBroadcasterService creates a new BroadcasterService.
 func NewBroadcasterService(
 	broadcasterRepo domain.BroadcasterRepository,
+	streamRepo domain.StreamRepository,
+	streamKeyService *StreamKeyService,
 	opts ...BroadcasterServiceOption,
 ) *BroadcasterService {
 	s := &BroadcasterService{
-		broadcasterRepo: broadcasterRepo,
-		logger:          slog.Default(),
+		broadcasterRepo:  broadcasterRepo,
+		streamRepo:       streamRepo,
+		streamKeyService: streamKeyService,
+		logger:           slog.Default(),
 	}
 
 	for _, opt := range opts {
@@ -47,12 +65,16 @@ func NewBroadcasterService(
 type CreateBroadcasterRequest struct {
 	DisplayName string
 	Metadata    map[string]interface{}
+	Quotas      domain.BroadcasterQuotas
 }
 
 // UpdateBroadcasterRequest represents a request to update a broadcaster.
 type UpdateBroadcasterRequest struct {
 	DisplayName *string
 	Metadata    map[string]interface{}
+	Status      *domain.BroadcasterStatus
+	// Quotas replaces the broadcaster's quota overrides when set.
+	Quotas *domain.BroadcasterQuotas
 }
 
 // Create creates a new broadcaster.
@@ -61,6 +83,8 @@ func (s *BroadcasterService) Create(ctx context.Context, req CreateBroadcasterRe
 		ID:          uuid.New(),
 		DisplayName: req.DisplayName,
 		Metadata:    req.Metadata,
+		Status:      domain.BroadcasterStatusOffline,
+		Quotas:      req.Quotas,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -86,9 +110,67 @@ func (s *BroadcasterService) GetByID(ctx context.Context, id uuid.UUID) (*domain
 	return s.broadcasterRepo.GetByID(ctx, id)
 }
 
-// List retrieves all broadcasters.
-func (s *BroadcasterService) List(ctx context.Context) ([]domain.Broadcaster, error) {
-	return s.broadcasterRepo.List(ctx)
+// GetDetail retrieves a broadcaster with its live stream, active keys and
+// recent stream count.
+func (s *BroadcasterService) GetDetail(ctx context.Context, id uuid.UUID) (*domain.BroadcasterDetail, error) {
+	broadcaster, err := s.broadcasterRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &domain.BroadcasterDetail{
+		Broadcaster: *broadcaster,
+		ActiveKeys:  []domain.StreamKey{},
+	}
+
+	liveStream, err := s.streamRepo.GetActiveByBroadcasterID(ctx, id)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get live stream: %w", err)
+	}
+	detail.LiveStream = liveStream
+
+	keys, err := s.streamKeyService.ListByBroadcaster(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if key.IsValid() {
+			detail.ActiveKeys = append(detail.ActiveKeys, key)
+		}
+	}
+
+	detail.RecentStreamCount, err = s.streamRepo.CountByBroadcasterSince(ctx, id, time.Now().Add(-RecentStreamWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	liveStreams, err := s.streamRepo.CountActiveByBroadcasterID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := s.streamKeyService.Quotas().For(broadcaster.Quotas)
+	detail.Usage = domain.BroadcasterUsage{
+		ActiveKeys:               len(detail.ActiveKeys),
+		MaxActiveKeys:            limits.MaxActiveKeys,
+		LiveStreams:              liveStreams,
+		MaxConcurrentStreams:     limits.MaxConcurrentStreams,
+		MaxStreamDurationSeconds: int(limits.MaxStreamDuration.Seconds()),
+	}
+
+	return detail, nil
+}
+
+// List retrieves a filtered, sorted page of broadcasters.
+func (s *BroadcasterService) List(ctx context.Context, opts domain.BroadcasterListOptions) (*domain.BroadcasterPage, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultBroadcasterPageSize
+	}
+	if opts.Limit > MaxBroadcasterPageSize {
+		opts.Limit = MaxBroadcasterPageSize
+	}
+
+	return s.broadcasterRepo.List(ctx, opts)
 }
 
 // Update updates an existing broadcaster.
@@ -106,22 +188,109 @@ func (s *BroadcasterService) Update(ctx context.Context, id uuid.UUID, req Updat
 		broadcaster.Metadata = req.Metadata
 	}
 
+	if req.Quotas != nil {
+		broadcaster.Quotas = *req.Quotas
+	}
+
+	if req.Status != nil && !req.Status.IsSettable() {
+		return nil, domain.ErrInvalidStatus
+	}
+
 	if err := s.broadcasterRepo.Update(ctx, broadcaster); err != nil {
 		return nil, err
 	}
 
+	if req.Status != nil {
+		if err := s.broadcasterRepo.UpdateStatus(ctx, id, *req.Status); err != nil {
+			return nil, err
+		}
+
+		s.logger.Info("broadcaster status changed",
+			slog.String("broadcaster_id", id.String()),
+			slog.String("status", string(*req.Status)),
+		)
+	}
+
 	// Refresh to get updated_at
 	return s.broadcasterRepo.GetByID(ctx, id)
 }
 
-// Delete deletes a broadcaster.
-func (s *BroadcasterService) Delete(ctx context.Context, id uuid.UUID) error {
+// Archive archives a broadcaster, revoking its active stream keys and ending
+// any live streams. Archiving an archived broadcaster revokes any keys left
+// active by an earlier, interrupted attempt.
+func (s *BroadcasterService) Archive(ctx context.Context, id uuid.UUID) error {
+	// Archive first so no new keys can be issued while revoking
+	if err := s.broadcasterRepo.Archive(ctx, id); err != nil {
+		return err
+	}
+
+	keys, err := s.streamKeyService.ListByBroadcaster(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	revoked := 0
+	for _, key := range keys {
+		if !key.IsRevocable() {
+			continue
+		}
+
+		if err := s.streamKeyService.Revoke(ctx, key.ID); err != nil {
+			return fmt.Errorf("failed to revoke stream key %s: %w", key.ID, err)
+		}
+		revoked++
+	}
+
+	s.logger.Info("broadcaster archived",
+		slog.String("broadcaster_id", id.String()),
+		slog.Int("revoked_keys", revoked),
+	)
+
+	return nil
+}
+
+// Restore restores an archived broadcaster. Keys revoked while archiving
+// stay revoked.
+func (s *BroadcasterService) Restore(ctx context.Context, id uuid.UUID) (*domain.Broadcaster, error) {
+	broadcaster, err := s.broadcasterRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !broadcaster.IsArchived() {
+		return nil, domain.ErrInvalidStatus
+	}
+
+	if err := s.broadcasterRepo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("broadcaster restored",
+		slog.String("broadcaster_id", id.String()),
+	)
+
+	return s.broadcasterRepo.GetByID(ctx, id)
+}
+
+// Purge permanently deletes an archived broadcaster along with its stream
+// keys and stream history.
+func (s *BroadcasterService) Purge(ctx context.Context, id uuid.UUID) error {
+	broadcaster, err := s.broadcasterRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !broadcaster.IsArchived() {
+		return domain.ErrInvalidStatus
+	}
+
 	if err := s.broadcasterRepo.Delete(ctx, id); err != nil {
 		return err
 	}
 
-	s.logger.Info("broadcaster deleted",
+	s.logger.Warn("broadcaster purged",
 		slog.String("broadcaster_id", id.String()),
+		slog.String("display_name", broadcaster.DisplayName),
 	)
 
 	return nil
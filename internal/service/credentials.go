@@ -0,0 +1,85 @@
+package service
+
+import (
+	"errors"
+	"strings"
+)
+
+// SRTStreamID is a parsed SRT stream ID.
+type SRTStreamID struct {
+	Mode     string
+	Path     string
+	User     string
+	Password string
+}
+
+// ParseSRTStreamID parses an SRT stream ID in either the MediaMTX format
+// (publish:path[:user:pass]) or the SRT access control format
+// (#!::r=path,m=publish,u=user,s=pass).
+func ParseSRTStreamID(streamID string) (*SRTStreamID, error) {
+	if strings.HasPrefix(streamID, "#!::") {
+		return parseSRTAccessControl(strings.TrimPrefix(streamID, "#!::"))
+	}
+
+	parts := strings.Split(streamID, ":")
+	switch len(parts) {
+	case 2, 4:
+	default:
+		return nil, errors.New("expected action:path or action:path:user:pass")
+	}
+
+	id := &SRTStreamID{Mode: parts[0], Path: parts[1]}
+	if len(parts) == 4 {
+		id.User, id.Password = parts[2], parts[3]
+	}
+
+	return id, id.validate()
+}
+
+func parseSRTAccessControl(streamID string) (*SRTStreamID, error) {
+	id := &SRTStreamID{}
+	for _, field := range strings.Split(streamID, ",") {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, errors.New("expected key=value fields")
+		}
+
+		switch key {
+		case "r":
+			id.Path = value
+		case "m":
+			id.Mode = value
+		case "u":
+			id.User = value
+		case "s":
+			id.Password = value
+		}
+	}
+
+	return id, id.validate()
+}
+
+func (id *SRTStreamID) validate() error {
+	if id.Mode != "publish" && id.Mode != "read" && id.Mode != "request" {
+		return errors.New("unknown mode " + id.Mode)
+	}
+	if id.Path == "" {
+		return errors.New("missing path")
+	}
+	return nil
+}
+
+// isSRTStreamID reports whether an SRT path is an unparsed stream ID rather
+// than a plain path.
+func isSRTStreamID(path string) bool {
+	return strings.HasPrefix(path, "#!::") ||
+		strings.HasPrefix(path, "publish:") ||
+		strings.HasPrefix(path, "read:")
+}
+
+// challengesForCredentials reports whether the protocol asks the client for
+// credentials after an anonymous attempt is rejected, as RTSP does with
+// basic auth. Such rejections are part of the handshake, not failures.
+func challengesForCredentials(protocol string) bool {
+	return protocol == "rtsp" || protocol == "rtsps"
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// DeviceService manages the devices broadcasters publish from.
+type DeviceService struct {
+	deviceRepo      domain.DeviceRepository
+	broadcasterRepo domain.BroadcasterRepository
+	logger          *slog.Logger
+}
+
+// DeviceServiceOption is a functional option for configuring DeviceService.
+type DeviceServiceOption func(*DeviceService)
+
+// WithDeviceLogger sets the logger for DeviceService.
+func WithDeviceLogger(logger *slog.Logger) DeviceServiceOption {
+	return func(s *DeviceService) {
+		s.logger = logger
+	}
+}
+
+// NewDeviceService creates a new DeviceService.
+func NewDeviceService(
+	deviceRepo domain.DeviceRepository,
+	broadcasterRepo domain.BroadcasterRepository,
+	opts ...DeviceServiceOption,
+) *DeviceService {
+	s := &DeviceService{
+		deviceRepo:      deviceRepo,
+		broadcasterRepo: broadcasterRepo,
+		logger:          slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// CreateDeviceRequest represents a request to register a device.
+type CreateDeviceRequest struct {
+	BroadcasterID uuid.UUID
+	Type          domain.DeviceType
+	Serial        *string
+	Model         *string
+	Capabilities  []string
+}
+
+// UpdateDeviceRequest represents a request to update a device. Nil fields are
+// left unchanged; an empty serial or model clears it.
+type UpdateDeviceRequest struct {
+	Type         *domain.DeviceType
+	Serial       *string
+	Model        *string
+	Capabilities *[]string
+}
+
+// Create registers a device for a broadcaster. Archived broadcasters cannot
+// register devices.
+func (s *DeviceService) Create(ctx context.Context, req CreateDeviceRequest) (*domain.Device, error) {
+	broadcaster, err := s.broadcasterRepo.GetByID(ctx, req.BroadcasterID)
+	if err != nil {
+		return nil, err
+	}
+
+	if broadcaster.IsArchived() {
+		return nil, domain.ErrBroadcasterArchived
+	}
+
+	now := time.Now()
+	device := &domain.Device{
+		ID:            uuid.New(),
+		BroadcasterID: req.BroadcasterID,
+		Type:          req.Type,
+		Serial:        optionalString(req.Serial),
+		Model:         optionalString(req.Model),
+		Capabilities:  NormalizeTags(req.Capabilities),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := s.deviceRepo.Create(ctx, device); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("device registered",
+		slog.String("device_id", device.ID.String()),
+		slog.String("broadcaster_id", device.BroadcasterID.String()),
+		slog.String("type", string(device.Type)),
+	)
+
+	return device, nil
+}
+
+// GetByID retrieves a device by ID.
+func (s *DeviceService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Device, error) {
+	return s.deviceRepo.GetByID(ctx, id)
+}
+
+// List retrieves the devices matching the filter.
+func (s *DeviceService) List(ctx context.Context, filter domain.DeviceFilter) ([]domain.Device, error) {
+	return s.deviceRepo.List(ctx, filter)
+}
+
+// Update updates a device. Its broadcaster cannot be changed.
+func (s *DeviceService) Update(ctx context.Context, id uuid.UUID, req UpdateDeviceRequest) (*domain.Device, error) {
+	device, err := s.deviceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Type != nil {
+		device.Type = *req.Type
+	}
+
+	if req.Serial != nil {
+		device.Serial = emptyToNil(*req.Serial)
+	}
+
+	if req.Model != nil {
+		device.Model = emptyToNil(*req.Model)
+	}
+
+	if req.Capabilities != nil {
+		device.Capabilities = NormalizeTags(*req.Capabilities)
+	}
+
+	if err := s.deviceRepo.Update(ctx, device); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("device updated",
+		slog.String("device_id", id.String()),
+	)
+
+	return s.deviceRepo.GetByID(ctx, id)
+}
+
+// Delete deletes a device, unbinding it from its stream keys.
+func (s *DeviceService) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.deviceRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.Info("device deleted",
+		slog.String("device_id", id.String()),
+	)
+
+	return nil
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// EnrollmentService manages enrollment tokens and device self-registration.
+type EnrollmentService struct {
+	enrollmentRepo   domain.EnrollmentRepository
+	streamKeyService *StreamKeyService
+	lockoutService   *LockoutService
+	logger           *slog.Logger
+}
+
+// EnrollmentServiceOption is a functional option for configuring EnrollmentService.
+type EnrollmentServiceOption func(*EnrollmentService)
+
+// WithEnrollmentLogger sets the logger for EnrollmentService.
+func WithEnrollmentLogger(logger *slog.Logger) EnrollmentServiceOption {
+	return func(s *EnrollmentService) {
+		s.logger = logger
+	}
+}
+
+// WithEnrollmentLockoutService locks out IPs that repeatedly present unknown
+// or unusable enrollment tokens.
+func WithEnrollmentLockoutService(lockoutService *LockoutService) EnrollmentServiceOption {
+	return func(s *EnrollmentService) {
+		s.lockoutService = lockoutService
+	}
+}
+
+// NewEnrollmentService creates a new EnrollmentService.
+func NewEnrollmentService(
+	enrollmentRepo domain.EnrollmentRepository,
+	streamKeyService *StreamKeyService,
+	opts ...EnrollmentServiceOption,
+) *EnrollmentService {
+	s := &EnrollmentService{
+		enrollmentRepo:   enrollmentRepo,
+		streamKeyService: streamKeyService,
+		logger:           slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// CreateEnrollmentTokenRequest represents a request to mint an enrollment token.
+type CreateEnrollmentTokenRequest struct {
+	Label           *string
+	MaxUses         *int
+	ExpiresAt       *time.Time
+	DefaultMetadata map[string]interface{}
+	KeyTTL          *time.Duration
+}
+
+// EnrollmentTokenDetail is an enrollment token with its redemptions.
+type EnrollmentTokenDetail struct {
+	domain.EnrollmentToken
+	Redemptions []domain.EnrollmentRedemption `json:"redemptions"`
+}
+
+// RedeemRequest represents a device redeeming an enrollment token.
+type RedeemRequest struct {
+	Token       string
+	DisplayName string
+	Metadata    map[string]interface{}
+	IP          string
+	UserAgent   string
+}
+
+// Enrollment is the result of a redemption: the new broadcaster and its key.
+type Enrollment struct {
+	Broadcaster domain.Broadcaster       `json:"broadcaster"`
+	StreamKey   domain.StreamKeyWithURLs `json:"stream_key"`
+}
+
+// CreateToken mints a new enrollment token. The token value is only
+// returned here; only its hash is stored.
+func (s *EnrollmentService) CreateToken(ctx context.Context, req CreateEnrollmentTokenRequest) (*domain.EnrollmentToken, error) {
+	value, err := generateEnrollmentToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate enrollment token: %w", err)
+	}
+
+	token := &domain.EnrollmentToken{
+		ID:              uuid.New(),
+		Token:           value,
+		Label:           optionalString(req.Label),
+		MaxUses:         req.MaxUses,
+		ExpiresAt:       req.ExpiresAt,
+		DefaultMetadata: req.DefaultMetadata,
+		CreatedAt:       time.Now(),
+	}
+	if token.DefaultMetadata == nil {
+		token.DefaultMetadata = make(map[string]interface{})
+	}
+	if req.KeyTTL != nil {
+		seconds := int(req.KeyTTL.Seconds())
+		token.KeyTTLSeconds = &seconds
+	}
+
+	if err := s.enrollmentRepo.Create(ctx, token, hashEnrollmentToken(value)); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("enrollment token created",
+		slog.String("token_id", token.ID.String()),
+	)
+
+	return token, nil
+}
+
+// List retrieves all enrollment tokens.
+func (s *EnrollmentService) List(ctx context.Context) ([]domain.EnrollmentToken, error) {
+	return s.enrollmentRepo.List(ctx)
+}
+
+// Get retrieves an enrollment token with its redemptions.
+func (s *EnrollmentService) Get(ctx context.Context, id uuid.UUID) (*EnrollmentTokenDetail, error) {
+	token, err := s.enrollmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	redemptions, err := s.enrollmentRepo.ListRedemptions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if redemptions == nil {
+		redemptions = []domain.EnrollmentRedemption{}
+	}
+
+	return &EnrollmentTokenDetail{EnrollmentToken: *token, Redemptions: redemptions}, nil
+}
+
+// Revoke revokes an enrollment token. Broadcasters and keys already
+// enrolled with it are unaffected.
+func (s *EnrollmentService) Revoke(ctx context.Context, id uuid.UUID) error {
+	if err := s.enrollmentRepo.Revoke(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.Info("enrollment token revoked",
+		slog.String("token_id", id.String()),
+	)
+
+	return nil
+}
+
+// Redeem creates a broadcaster and stream key for a device presenting an
+// enrollment token. The token's default metadata is applied under the
+// metadata supplied by the device. With lockouts enabled, IPs presenting too
+// many unknown or unusable tokens get ErrLockedOut.
+func (s *EnrollmentService) Redeem(ctx context.Context, req RedeemRequest) (*Enrollment, error) {
+	if s.lockoutService != nil {
+		lockout, err := s.lockoutService.Check(ctx, req.IP, "")
+		if err != nil {
+			return nil, err
+		}
+		if lockout != nil {
+			return nil, domain.ErrLockedOut
+		}
+	}
+
+	enrollment, err := s.redeem(ctx, req)
+	s.trackAttempt(ctx, req, err)
+	return enrollment, err
+}
+
+// trackAttempt feeds the outcome of a redemption into the lockout policy.
+// Failures here are logged rather than returned so that lockout bookkeeping
+// never changes the redemption outcome.
+func (s *EnrollmentService) trackAttempt(ctx context.Context, req RedeemRequest, redeemErr error) {
+	if s.lockoutService == nil {
+		return
+	}
+
+	var err error
+	switch {
+	case redeemErr == nil:
+		err = s.lockoutService.RecordSuccess(ctx, req.IP, "")
+	case errors.Is(redeemErr, domain.ErrNotFound), errors.Is(redeemErr, domain.ErrEnrollmentTokenUnusable):
+		err = s.lockoutService.RecordFailure(ctx, req.IP, "")
+	}
+	if err != nil {
+		s.logger.Error("failed to track enrollment attempt",
+			slog.String("error", err.Error()),
+			slog.String("ip", req.IP),
+		)
+	}
+}
+
+func (s *EnrollmentService) redeem(ctx context.Context, req RedeemRequest) (*Enrollment, error) {
+	tokenHash := hashEnrollmentToken(req.Token)
+
+	// Check up front so unusable tokens are rejected before any work is done;
+	// the repository re-checks under lock.
+	token, err := s.enrollmentRepo.GetByHash(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := token.CheckRedeemable(now); err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]interface{}, len(token.DefaultMetadata)+len(req.Metadata))
+	for k, v := range token.DefaultMetadata {
+		metadata[k] = v
+	}
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+
+	broadcaster := &domain.Broadcaster{
+		ID:          uuid.New(),
+		DisplayName: req.DisplayName,
+		Metadata:    metadata,
+		Status:      domain.BroadcasterStatusOffline,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	keyValue, err := generateStreamKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate stream key: %w", err)
+	}
+
+	key := &domain.StreamKey{
+		ID:            uuid.New(),
+		KeyValue:      keyValue,
+		BroadcasterID: broadcaster.ID,
+		Label:         token.Label,
+		Status:        domain.StreamKeyStatusActive,
+		CreatedAt:     now,
+	}
+	if token.KeyTTLSeconds != nil {
+		expiresAt := now.Add(time.Duration(*token.KeyTTLSeconds) * time.Second)
+		key.ExpiresAt = &expiresAt
+	}
+
+	redemption := &domain.EnrollmentRedemption{
+		DisplayName: req.DisplayName,
+		IP:          req.IP,
+		UserAgent:   req.UserAgent,
+		RedeemedAt:  now,
+	}
+
+	if err := s.enrollmentRepo.Redeem(ctx, tokenHash, broadcaster, key, redemption); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("enrollment token redeemed",
+		slog.String("token_id", token.ID.String()),
+		slog.String("broadcaster_id", broadcaster.ID.String()),
+		slog.String("key_id", key.ID.String()),
+		slog.String("ip", req.IP),
+	)
+
+	return &Enrollment{
+		Broadcaster: *broadcaster,
+		StreamKey: domain.StreamKeyWithURLs{
+			StreamKey:  *key,
+			IngestURLs: s.streamKeyService.IngestURLs(key.KeyValue),
+		},
+	}, nil
+}
+
+func generateEnrollmentToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "et_" + base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// hashEnrollmentToken returns the stored form of a token value.
+func hashEnrollmentToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// EventPublisher logs operational events and optionally forwards them to an
+// HTTP webhook, such as an alerting or chat integration used by on-call.
+type EventPublisher struct {
+	webhookURL string
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// EventPublisherOption is a functional option for configuring EventPublisher.
+type EventPublisherOption func(*EventPublisher)
+
+// WithEventWebhookURL sets the URL events are POSTed to as JSON.
+func WithEventWebhookURL(url string) EventPublisherOption {
+	return func(p *EventPublisher) {
+		p.webhookURL = url
+	}
+}
+
+// WithEventHTTPClient sets the HTTP client used for webhook delivery.
+func WithEventHTTPClient(c *http.Client) EventPublisherOption {
+	return func(p *EventPublisher) {
+		p.httpClient = c
+	}
+}
+
+// WithEventLogger sets the logger for EventPublisher.
+func WithEventLogger(logger *slog.Logger) EventPublisherOption {
+	return func(p *EventPublisher) {
+		p.logger = logger
+	}
+}
+
+// NewEventPublisher creates a new EventPublisher.
+func NewEventPublisher(opts ...EventPublisherOption) *EventPublisher {
+	p := &EventPublisher{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		logger:     slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Publish logs the event and, when a webhook is configured, delivers it in the
+// background so callers on the request path are never blocked by the receiver.
+func (p *EventPublisher) Publish(ctx context.Context, event domain.Event) error {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	p.logger.Warn("event published",
+		slog.String("type", string(event.Type)),
+		slog.Any("data", event.Data),
+	)
+
+	if p.webhookURL == "" {
+		return nil
+	}
+
+	go func() {
+		deliverCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.httpClient.Timeout)
+		defer cancel()
+
+		if err := p.deliver(deliverCtx, event); err != nil {
+			p.logger.Error("failed to deliver event",
+				slog.String("error", err.Error()),
+				slog.String("type", string(event.Type)),
+			)
+		}
+	}()
+
+	return nil
+}
+
+func (p *EventPublisher) deliver(ctx context.Context, event domain.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create event request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send event: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("event webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// DefaultExpirySweepInterval is how often the expiry sweeper runs by default.
+const DefaultExpirySweepInterval = time.Minute
+
+// DefaultExpiryNoticeLeadTimes returns the default lead times at which
+// expiring-soon events are emitted.
+func DefaultExpiryNoticeLeadTimes() []time.Duration {
+	return []time.Duration{24 * time.Hour, time.Hour, 15 * time.Minute}
+}
+
+// ExpirySweeper periodically expires stream keys past their expiry time,
+// terminating any stream still running on them, and emits expiring-soon
+// events ahead of expiry.
+type ExpirySweeper struct {
+	streamKeyRepo    domain.StreamKeyRepository
+	streamKeyService *StreamKeyService
+	events           domain.EventPublisher
+	interval         time.Duration
+	leadTimes        []time.Duration
+	logger           *slog.Logger
+}
+
+// ExpirySweeperOption is a functional option for configuring ExpirySweeper.
+type ExpirySweeperOption func(*ExpirySweeper)
+
+// WithExpirySweeperLogger sets the logger for ExpirySweeper.
+func WithExpirySweeperLogger(logger *slog.Logger) ExpirySweeperOption {
+	return func(s *ExpirySweeper) {
+		s.logger = logger
+	}
+}
+
+// WithExpirySweepInterval sets how often the sweeper runs.
+func WithExpirySweepInterval(interval time.Duration) ExpirySweeperOption {
+	return func(s *ExpirySweeper) {
+		s.interval = interval
+	}
+}
+
+// WithExpiryNoticeLeadTimes sets the lead times at which expiring-soon events
+// are emitted. Non-positive lead times are ignored.
+func WithExpiryNoticeLeadTimes(leadTimes []time.Duration) ExpirySweeperOption {
+	return func(s *ExpirySweeper) {
+		s.leadTimes = nil
+		for _, lt := range leadTimes {
+			if lt > 0 {
+				s.leadTimes = append(s.leadTimes, lt)
+			}
+		}
+	}
+}
+
+// WithExpiryEventPublisher sets the publisher notified of expiring and expired keys.
+func WithExpiryEventPublisher(events domain.EventPublisher) ExpirySweeperOption {
+	return func(s *ExpirySweeper) {
+		s.events = events
+	}
+}
+
+// NewExpirySweeper creates a new ExpirySweeper.
+func NewExpirySweeper(streamKeyRepo domain.StreamKeyRepository, streamKeyService *StreamKeyService, opts ...ExpirySweeperOption) *ExpirySweeper {
+	s := &ExpirySweeper{
+		streamKeyRepo:    streamKeyRepo,
+		streamKeyService: streamKeyService,
+		interval:         DefaultExpirySweepInterval,
+		leadTimes:        DefaultExpiryNoticeLeadTimes(),
+		logger:           slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.interval <= 0 {
+		s.interval = DefaultExpirySweepInterval
+	}
+
+	// Ascending, so a key is only notified at the shortest lead time it is within
+	sort.Slice(s.leadTimes, func(i, j int) bool { return s.leadTimes[i] < s.leadTimes[j] })
+
+	return s
+}
+
+// Run sweeps immediately and then on every interval until ctx is cancelled.
+func (s *ExpirySweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Sweep(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("stream key expiry sweep failed",
+				slog.String("error", err.Error()),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep expires every active key past its expiry time and emits any
+// expiring-soon events that are due.
+func (s *ExpirySweeper) Sweep(ctx context.Context) error {
+	now := time.Now()
+
+	if err := s.expireKeys(ctx, now); err != nil {
+		return err
+	}
+
+	return s.notifyExpiring(ctx, now)
+}
+
+func (s *ExpirySweeper) expireKeys(ctx context.Context, now time.Time) error {
+	keys, err := s.streamKeyRepo.ListActiveExpiringBefore(ctx, now)
+	if err != nil {
+		return fmt.Errorf("failed to list expired stream keys: %w", err)
+	}
+
+	for _, key := range keys {
+		if err := s.streamKeyService.Expire(ctx, key.ID); err != nil {
+			// Revoked or expired by someone else since it was listed
+			if errors.Is(err, domain.ErrNotFound) {
+				continue
+			}
+			s.logger.Error("failed to expire stream key",
+				slog.String("error", err.Error()),
+				slog.String("key_id", key.ID.String()),
+			)
+			continue
+		}
+
+		s.publish(ctx, domain.Event{
+			Type:       domain.EventStreamKeyExpired,
+			OccurredAt: now,
+			Data: map[string]interface{}{
+				"stream_key_id":  key.ID,
+				"broadcaster_id": key.BroadcasterID,
+				"expires_at":     key.ExpiresAt,
+			},
+		})
+	}
+
+	return nil
+}
+
+func (s *ExpirySweeper) notifyExpiring(ctx context.Context, now time.Time) error {
+	if len(s.leadTimes) == 0 {
+		return nil
+	}
+
+	maxLead := s.leadTimes[len(s.leadTimes)-1]
+	keys, err := s.streamKeyRepo.ListActiveExpiringBefore(ctx, now.Add(maxLead))
+	if err != nil {
+		return fmt.Errorf("failed to list expiring stream keys: %w", err)
+	}
+
+	for _, key := range keys {
+		if key.ExpiresAt == nil {
+			continue
+		}
+
+		remaining := key.ExpiresAt.Sub(now)
+		if remaining <= 0 {
+			continue
+		}
+
+		leadTime, ok := s.leadTimeFor(remaining)
+		if !ok {
+			continue
+		}
+
+		recorded, err := s.streamKeyRepo.RecordExpiryNotice(ctx, key.ID, leadTime, *key.ExpiresAt)
+		if err != nil {
+			s.logger.Error("failed to record expiry notice",
+				slog.String("error", err.Error()),
+				slog.String("key_id", key.ID.String()),
+			)
+			continue
+		}
+		if !recorded {
+			continue
+		}
+
+		s.logger.Info("stream key expiring soon",
+			slog.String("key_id", key.ID.String()),
+			slog.Duration("lead_time", leadTime),
+		)
+
+		s.publish(ctx, domain.Event{
+			Type:       domain.EventStreamKeyExpiringSoon,
+			OccurredAt: now,
+			Data: map[string]interface{}{
+				"stream_key_id":     key.ID,
+				"broadcaster_id":    key.BroadcasterID,
+				"expires_at":        key.ExpiresAt,
+				"lead_time_seconds": int(leadTime.Seconds()),
+			},
+		})
+	}
+
+	return nil
+}
+
+// leadTimeFor returns the shortest lead time that remaining falls within.
+func (s *ExpirySweeper) leadTimeFor(remaining time.Duration) (time.Duration, bool) {
+	for _, lt := range s.leadTimes {
+		if remaining <= lt {
+			return lt, true
+		}
+	}
+	return 0, false
+}
+
+func (s *ExpirySweeper) publish(ctx context.Context, event domain.Event) {
+	if s.events == nil {
+		return
+	}
+
+	if err := s.events.Publish(ctx, event); err != nil {
+		s.logger.Error("failed to publish stream key expiry event",
+			slog.String("error", err.Error()),
+			slog.String("type", string(event.Type)),
+		)
+	}
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// MaxGroupMembersPerRequest is the most broadcasters that can be added to a
+// group in one request.
+const MaxGroupMembersPerRequest = 200
+
+// BroadcasterGroupService manages broadcaster groups and their bulk jobs.
+type BroadcasterGroupService struct {
+	groupRepo        domain.BroadcasterGroupRepository
+	jobRepo          domain.GroupJobRepository
+	broadcasterRepo  domain.BroadcasterRepository
+	batchRepo        domain.StreamKeyBatchRepository
+	streamService    *StreamService
+	streamKeyService *StreamKeyService
+	logger           *slog.Logger
+}
+
+// BroadcasterGroupServiceOption is a functional option for configuring BroadcasterGroupService.
+type BroadcasterGroupServiceOption func(*BroadcasterGroupService)
+
+// WithBroadcasterGroupLogger sets the logger for BroadcasterGroupService.
+func WithBroadcasterGroupLogger(logger *slog.Logger) BroadcasterGroupServiceOption {
+	return func(s *BroadcasterGroupService) {
+		s.logger = logger
+	}
+}
+
+// NewBroadcasterGroupService creates a new BroadcasterGroupService.
+func NewBroadcasterGroupService(
+	groupRepo domain.BroadcasterGroupRepository,
+	jobRepo domain.GroupJobRepository,
+	broadcasterRepo domain.BroadcasterRepository,
+	batchRepo domain.StreamKeyBatchRepository,
+	streamService *StreamService,
+	streamKeyService *StreamKeyService,
+	opts ...BroadcasterGroupServiceOption,
+) *BroadcasterGroupService {
+	s := &BroadcasterGroupService{
+		groupRepo:        groupRepo,
+		jobRepo:          jobRepo,
+		broadcasterRepo:  broadcasterRepo,
+		batchRepo:        batchRepo,
+		streamService:    streamService,
+		streamKeyService: streamKeyService,
+		logger:           slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// CreateGroupRequest represents a request to create a broadcaster group.
+type CreateGroupRequest struct {
+	Name        string
+	Description *string
+}
+
+// UpdateGroupRequest represents a request to update a broadcaster group. Nil
+// fields are left unchanged; an empty description clears it.
+type UpdateGroupRequest struct {
+	Name        *string
+	Description *string
+}
+
+// Create creates a new broadcaster group.
+func (s *BroadcasterGroupService) Create(ctx context.Context, req CreateGroupRequest) (*domain.BroadcasterGroup, error) {
+	now := time.Now()
+	group := &domain.BroadcasterGroup{
+		ID:          uuid.New(),
+		Name:        req.Name,
+		Description: optionalString(req.Description),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := s.groupRepo.Create(ctx, group); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("broadcaster group created",
+		slog.String("group_id", group.ID.String()),
+		slog.String("name", group.Name),
+	)
+
+	return group, nil
+}
+
+// GetByID retrieves a broadcaster group by ID.
+func (s *BroadcasterGroupService) GetByID(ctx context.Context, id uuid.UUID) (*domain.BroadcasterGroup, error) {
+	return s.groupRepo.GetByID(ctx, id)
+}
+
+// List retrieves all broadcaster groups.
+func (s *BroadcasterGroupService) List(ctx context.Context) ([]domain.BroadcasterGroup, error) {
+	return s.groupRepo.List(ctx)
+}
+
+// Update updates the name and description of a broadcaster group.
+func (s *BroadcasterGroupService) Update(ctx context.Context, id uuid.UUID, req UpdateGroupRequest) (*domain.BroadcasterGroup, error) {
+	group, err := s.groupRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		group.Name = *req.Name
+	}
+
+	if req.Description != nil {
+		group.Description = emptyToNil(*req.Description)
+	}
+
+	if err := s.groupRepo.Update(ctx, group); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("broadcaster group updated",
+		slog.String("group_id", id.String()),
+	)
+
+	return s.groupRepo.GetByID(ctx, id)
+}
+
+// Delete deletes a broadcaster group. Its members are not affected.
+func (s *BroadcasterGroupService) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.groupRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.Info("broadcaster group deleted",
+		slog.String("group_id", id.String()),
+	)
+
+	return nil
+}
+
+// AddMembers adds broadcasters to a group. Broadcasters must belong to the
+// group's organization; existing members are ignored.
+func (s *BroadcasterGroupService) AddMembers(ctx context.Context, groupID uuid.UUID, broadcasterIDs []uuid.UUID) error {
+	group, err := s.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range broadcasterIDs {
+		broadcaster, err := s.broadcasterRepo.GetByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("broadcaster %s: %w", id, err)
+		}
+
+		if broadcaster.OrganizationID != group.OrganizationID {
+			return fmt.Errorf("broadcaster %s: %w", id, domain.ErrNotFound)
+		}
+	}
+
+	if err := s.groupRepo.AddMembers(ctx, groupID, broadcasterIDs); err != nil {
+		return err
+	}
+
+	s.logger.Info("broadcaster group members added",
+		slog.String("group_id", groupID.String()),
+		slog.Int("count", len(broadcasterIDs)),
+	)
+
+	return nil
+}
+
+// RemoveMember removes a broadcaster from a group.
+func (s *BroadcasterGroupService) RemoveMember(ctx context.Context, groupID, broadcasterID uuid.UUID) error {
+	if _, err := s.groupRepo.GetByID(ctx, groupID); err != nil {
+		return err
+	}
+
+	if err := s.groupRepo.RemoveMember(ctx, groupID, broadcasterID); err != nil {
+		return err
+	}
+
+	s.logger.Info("broadcaster group member removed",
+		slog.String("group_id", groupID.String()),
+		slog.String("broadcaster_id", broadcasterID.String()),
+	)
+
+	return nil
+}
+
+// ListMembers lists the broadcasters in a group.
+func (s *BroadcasterGroupService) ListMembers(ctx context.Context, groupID uuid.UUID) ([]domain.Broadcaster, error) {
+	if _, err := s.groupRepo.GetByID(ctx, groupID); err != nil {
+		return nil, err
+	}
+
+	members, err := s.groupRepo.ListMembers(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if members == nil {
+		members = []domain.Broadcaster{}
+	}
+
+	return members, nil
+}
+
+// ListStreams lists the active streams of a group's members.
+func (s *BroadcasterGroupService) ListStreams(ctx context.Context, groupID uuid.UUID) ([]domain.StreamWithURLs, error) {
+	if _, err := s.groupRepo.GetByID(ctx, groupID); err != nil {
+		return nil, err
+	}
+
+	return s.streamService.ListActive(ctx, domain.StreamFilter{GroupID: &groupID})
+}
+
+// ListStreamKeys lists the stream keys of a group's members, without key values.
+func (s *BroadcasterGroupService) ListStreamKeys(ctx context.Context, groupID uuid.UUID) ([]domain.StreamKey, error) {
+	if _, err := s.groupRepo.GetByID(ctx, groupID); err != nil {
+		return nil, err
+	}
+
+	keys, err := s.streamKeyService.ListByGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if keys == nil {
+		keys = []domain.StreamKey{}
+	}
+
+	return keys, nil
+}
+
+// CreateGroupJobRequest represents a request to run a bulk action on a group.
+type CreateGroupJobRequest struct {
+	Action domain.GroupJobAction
+	// Label is set on keys issued by issue_keys.
+	Label *string
+	// ExpiresAt is the expiry of keys issued by issue_keys, or the expiry
+	// set by set_expiry, where nil clears it.
+	ExpiresAt *time.Time
+}
+
+// GroupJobDetail is a group job with its per-member results.
+type GroupJobDetail struct {
+	domain.GroupJob
+	Results []domain.GroupJobResult `json:"results"`
+}
+
+// CreateJob queues a bulk action on the current members of a group. Keys
+// issued by issue_keys are added to a new batch whose key values can be
+// exported once the job has completed.
+func (s *BroadcasterGroupService) CreateJob(ctx context.Context, groupID uuid.UUID, req CreateGroupJobRequest) (*domain.GroupJob, error) {
+	group, err := s.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &domain.GroupJob{
+		ID:        uuid.New(),
+		GroupID:   groupID,
+		Action:    req.Action,
+		Status:    domain.GroupJobStatusPending,
+		Label:     optionalString(req.Label),
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: now,
+	}
+
+	if req.Action == domain.GroupJobIssueKeys {
+		batch := &domain.StreamKeyBatch{
+			ID:             uuid.New(),
+			OrganizationID: group.OrganizationID,
+			Label:          job.Label,
+			ExpiresAt:      job.ExpiresAt,
+			CreatedAt:      now,
+		}
+		if err := s.batchRepo.Create(ctx, batch, nil, nil); err != nil {
+			return nil, err
+		}
+		job.BatchID = &batch.ID
+	}
+
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("broadcaster group job queued",
+		slog.String("group_id", groupID.String()),
+		slog.String("job_id", job.ID.String()),
+		slog.String("action", string(job.Action)),
+		slog.Int("members", job.Counts.Total),
+	)
+
+	return job, nil
+}
+
+// ListJobs lists the jobs of a group, newest first.
+func (s *BroadcasterGroupService) ListJobs(ctx context.Context, groupID uuid.UUID) ([]domain.GroupJob, error) {
+	if _, err := s.groupRepo.GetByID(ctx, groupID); err != nil {
+		return nil, err
+	}
+
+	jobs, err := s.jobRepo.ListByGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if jobs == nil {
+		jobs = []domain.GroupJob{}
+	}
+
+	return jobs, nil
+}
+
+// GetJob retrieves a group job with its per-member results.
+func (s *BroadcasterGroupService) GetJob(ctx context.Context, groupID, jobID uuid.UUID) (*GroupJobDetail, error) {
+	job, err := s.jobRepo.GetByID(ctx, groupID, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := s.jobRepo.ListResults(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if results == nil {
+		results = []domain.GroupJobResult{}
+	}
+
+	return &GroupJobDetail{GroupJob: *job, Results: results}, nil
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// DefaultGroupJobPollInterval is how often the group job runner looks for
+// queued jobs by default.
+const DefaultGroupJobPollInterval = 5 * time.Second
+
+// groupJobLease is how long a claimed job is held without progress before
+// another runner may claim it, in case its runner died or gave up on it.
+const groupJobLease = 2 * time.Minute
+
+// GroupJobRunner executes queued broadcaster group jobs, recording a result
+// for every member.
+type GroupJobRunner struct {
+	jobRepo          domain.GroupJobRepository
+	streamKeyService *StreamKeyService
+	interval         time.Duration
+	logger           *slog.Logger
+}
+
+// GroupJobRunnerOption is a functional option for configuring GroupJobRunner.
+type GroupJobRunnerOption func(*GroupJobRunner)
+
+// WithGroupJobRunnerLogger sets the logger for GroupJobRunner.
+func WithGroupJobRunnerLogger(logger *slog.Logger) GroupJobRunnerOption {
+	return func(r *GroupJobRunner) {
+		r.logger = logger
+	}
+}
+
+// WithGroupJobPollInterval sets how often the runner looks for queued jobs.
+func WithGroupJobPollInterval(interval time.Duration) GroupJobRunnerOption {
+	return func(r *GroupJobRunner) {
+		r.interval = interval
+	}
+}
+
+// NewGroupJobRunner creates a new GroupJobRunner.
+func NewGroupJobRunner(jobRepo domain.GroupJobRepository, streamKeyService *StreamKeyService, opts ...GroupJobRunnerOption) *GroupJobRunner {
+	r := &GroupJobRunner{
+		jobRepo:          jobRepo,
+		streamKeyService: streamKeyService,
+		interval:         DefaultGroupJobPollInterval,
+		logger:           slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.interval <= 0 {
+		r.interval = DefaultGroupJobPollInterval
+	}
+
+	return r
+}
+
+// Run processes queued jobs immediately and then on every interval until ctx
+// is cancelled.
+func (r *GroupJobRunner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.ProcessPending(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error("broadcaster group job processing failed",
+				slog.String("error", err.Error()),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessPending runs queued jobs one at a time until none are left.
+func (r *GroupJobRunner) ProcessPending(ctx context.Context) error {
+	for ctx.Err() == nil {
+		job, err := r.jobRepo.ClaimNext(ctx, groupJobLease)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if err := r.process(ctx, job); err != nil {
+			return fmt.Errorf("group job %s: %w", job.ID, err)
+		}
+	}
+
+	return ctx.Err()
+}
+
+// process applies a job to each member it has not processed yet, renewing its
+// lease as it goes. A member's failure is recorded in its result and does not
+// stop the job; any other error leaves the job to be claimed again once its
+// lease runs out. Members whose result was not recorded are applied again,
+// which reissues their key in the job's batch rather than creating another.
+func (r *GroupJobRunner) process(ctx context.Context, job *domain.GroupJob) error {
+	// Act with the permissions of the group's organization
+	ctx = domain.ContextWithOrganization(ctx, job.OrganizationID)
+
+	members, err := r.jobRepo.ListPendingMembers(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, broadcasterID := range members {
+		result := r.apply(ctx, job, broadcasterID)
+		if result.Status == domain.GroupJobResultFailed {
+			failed++
+		}
+
+		if err := r.jobRepo.RecordResult(ctx, job.ID, result); err != nil {
+			// The broadcaster was purged since the job was queued
+			if errors.Is(err, domain.ErrNotFound) {
+				continue
+			}
+			return err
+		}
+
+		if err := r.jobRepo.RenewLease(ctx, job.ID, groupJobLease); err != nil {
+			return err
+		}
+	}
+
+	if err := r.jobRepo.Complete(ctx, job.ID); err != nil {
+		return err
+	}
+
+	r.logger.Info("broadcaster group job completed",
+		slog.String("job_id", job.ID.String()),
+		slog.String("group_id", job.GroupID.String()),
+		slog.String("action", string(job.Action)),
+		slog.Int("members", len(members)),
+		slog.Int("failed", failed),
+	)
+
+	return nil
+}
+
+// apply runs a job's action for one member.
+func (r *GroupJobRunner) apply(ctx context.Context, job *domain.GroupJob, broadcasterID uuid.UUID) domain.GroupJobResult {
+	result := domain.GroupJobResult{BroadcasterID: broadcasterID}
+
+	var errs []error
+	switch job.Action {
+	case domain.GroupJobIssueKeys:
+		key, err := r.streamKeyService.Create(ctx, CreateRequest{
+			BroadcasterID: broadcasterID,
+			Label:         job.Label,
+			ExpiresAt:     job.ExpiresAt,
+			BatchID:       job.BatchID,
+		})
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			result.StreamKeyIDs = append(result.StreamKeyIDs, key.ID)
+		}
+	case domain.GroupJobRevokeKeys, domain.GroupJobSetExpiry:
+		keys, err := r.streamKeyService.ListByBroadcaster(ctx, broadcasterID)
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		for _, key := range keys {
+			if !key.IsRevocable() {
+				continue
+			}
+
+			if job.Action == domain.GroupJobRevokeKeys {
+				err = r.streamKeyService.Revoke(ctx, key.ID)
+			} else {
+				_, err = r.streamKeyService.Update(ctx, key.ID, UpdateStreamKeyRequest{
+					ExpiresAt:      job.ExpiresAt,
+					ClearExpiresAt: job.ExpiresAt == nil,
+				})
+			}
+
+			if err != nil {
+				// Revoked or expired since it was listed
+				if errors.Is(err, domain.ErrInvalidStatus) {
+					continue
+				}
+				errs = append(errs, fmt.Errorf("stream key %s: %w", key.ID, err))
+				continue
+			}
+			result.StreamKeyIDs = append(result.StreamKeyIDs, key.ID)
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown group job action %q", job.Action))
+	}
+
+	switch {
+	case len(errs) > 0:
+		result.Status = domain.GroupJobResultFailed
+		msg := errors.Join(errs...).Error()
+		result.Error = &msg
+		r.logger.Warn("broadcaster group job failed for member",
+			slog.String("job_id", job.ID.String()),
+			slog.String("broadcaster_id", broadcasterID.String()),
+			slog.String("error", msg),
+		)
+	case len(result.StreamKeyIDs) == 0:
+		result.Status = domain.GroupJobResultSkipped
+	default:
+		result.Status = domain.GroupJobResultSucceeded
+	}
+
+	return result
+}
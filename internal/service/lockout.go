@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"unicode/utf8"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// maxLockoutSubjectLength matches the size of the auth_lockouts.subject column.
+const maxLockoutSubjectLength = 255
+
+// LockoutPolicy configures when repeated authentication failures lock out a
+// source IP or path, and for how long.
+type LockoutPolicy struct {
+	// MaxFailures is the number of failures within Window that triggers a lockout.
+	MaxFailures int
+	// Window is the period over which failures are counted.
+	Window time.Duration
+	// BaseDuration is the length of the first lockout. Each subsequent lockout
+	// of the same subject doubles, up to MaxDuration.
+	BaseDuration time.Duration
+	// MaxDuration caps the lockout length.
+	MaxDuration time.Duration
+	// LockPaths also tracks failures per path, regardless of source IP. This
+	// stops guessing spread across many IPs, but lets anyone lock a
+	// broadcaster out of its own key by failing against its path.
+	LockPaths bool
+}
+
+// DefaultLockoutPolicy returns the default lockout policy.
+func DefaultLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{
+		MaxFailures:  5,
+		Window:       15 * time.Minute,
+		BaseDuration: time.Minute,
+		MaxDuration:  time.Hour,
+		LockPaths:    true,
+	}
+}
+
+// lockoutDuration returns the duration of the nth lockout (1-based).
+func (p LockoutPolicy) lockoutDuration(n int) time.Duration {
+	d := p.BaseDuration
+	for i := 1; i < n && d < p.MaxDuration; i++ {
+		d *= 2
+	}
+	if d > p.MaxDuration {
+		d = p.MaxDuration
+	}
+	return d
+}
+
+// LockoutService tracks failed publish attempts and locks out abusive sources.
+type LockoutService struct {
+	lockoutRepo domain.AuthLockoutRepository
+	events      domain.EventPublisher
+	policy      LockoutPolicy
+	logger      *slog.Logger
+}
+
+// LockoutServiceOption is a functional option for configuring LockoutService.
+type LockoutServiceOption func(*LockoutService)
+
+// WithLockoutLogger sets the logger for LockoutService.
+func WithLockoutLogger(logger *slog.Logger) LockoutServiceOption {
+	return func(s *LockoutService) {
+		s.logger = logger
+	}
+}
+
+// WithLockoutPolicy sets the lockout policy.
+func WithLockoutPolicy(policy LockoutPolicy) LockoutServiceOption {
+	return func(s *LockoutService) {
+		s.policy = policy
+	}
+}
+
+// WithLockoutEventPublisher sets the publisher notified when a lockout triggers.
+func WithLockoutEventPublisher(events domain.EventPublisher) LockoutServiceOption {
+	return func(s *LockoutService) {
+		s.events = events
+	}
+}
+
+// NewLockoutService creates a new LockoutService.
+func NewLockoutService(lockoutRepo domain.AuthLockoutRepository, opts ...LockoutServiceOption) *LockoutService {
+	s := &LockoutService{
+		lockoutRepo: lockoutRepo,
+		policy:      DefaultLockoutPolicy(),
+		logger:      slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+type lockoutSubject struct {
+	scope   domain.LockoutScope
+	subject string
+}
+
+// lockoutSubjects returns the lockout subjects a publish attempt is tracked under.
+func (s *LockoutService) lockoutSubjects(ip, path string) []lockoutSubject {
+	var result []lockoutSubject
+	if ip != "" {
+		result = append(result, lockoutSubject{scope: domain.LockoutScopeIP, subject: truncate(ip, maxLockoutSubjectLength)})
+	}
+	if path != "" && s.policy.LockPaths {
+		result = append(result, lockoutSubject{scope: domain.LockoutScopePath, subject: truncate(path, maxLockoutSubjectLength)})
+	}
+	return result
+}
+
+// Check returns the lockout currently blocking a publish attempt from ip to
+// path, or nil if the attempt may proceed.
+func (s *LockoutService) Check(ctx context.Context, ip, path string) (*domain.AuthLockout, error) {
+	now := time.Now()
+	for _, sub := range s.lockoutSubjects(ip, path) {
+		lockout, err := s.lockoutRepo.Get(ctx, sub.scope, sub.subject)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to check lockout: %w", err)
+		}
+		if lockout.IsLocked(now) {
+			return lockout, nil
+		}
+	}
+	return nil, nil
+}
+
+// RecordFailure records a failed publish attempt and locks out any subject
+// that has reached the failure threshold.
+func (s *LockoutService) RecordFailure(ctx context.Context, ip, path string) error {
+	now := time.Now()
+	for _, sub := range s.lockoutSubjects(ip, path) {
+		lockout, err := s.lockoutRepo.RecordFailure(ctx, sub.scope, sub.subject, now.Add(-s.policy.Window))
+		if err != nil {
+			return err
+		}
+
+		if lockout.FailureCount < s.policy.MaxFailures {
+			continue
+		}
+
+		duration := s.policy.lockoutDuration(lockout.LockoutCount + 1)
+		lockout, err = s.lockoutRepo.Lock(ctx, sub.scope, sub.subject, now.Add(duration))
+		if err != nil {
+			return fmt.Errorf("failed to lock out %s %s: %w", sub.scope, sub.subject, err)
+		}
+
+		s.logger.Warn("authentication lockout triggered",
+			slog.String("scope", string(lockout.Scope)),
+			slog.String("subject", lockout.Subject),
+			slog.Int("lockout_count", lockout.LockoutCount),
+			slog.Duration("duration", duration),
+		)
+
+		s.publishLockout(ctx, lockout, duration)
+	}
+	return nil
+}
+
+// RecordSuccess resets the failure counters after a successful publish attempt.
+// Lockout history is kept so repeat offenders still escalate.
+func (s *LockoutService) RecordSuccess(ctx context.Context, ip, path string) error {
+	for _, sub := range s.lockoutSubjects(ip, path) {
+		if err := s.lockoutRepo.ResetFailures(ctx, sub.scope, sub.subject); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns all tracked lockout states.
+func (s *LockoutService) List(ctx context.Context) ([]domain.AuthLockout, error) {
+	return s.lockoutRepo.List(ctx)
+}
+
+// Clear removes the lockout state for a scope and subject.
+func (s *LockoutService) Clear(ctx context.Context, scope domain.LockoutScope, subject string) error {
+	if err := s.lockoutRepo.Delete(ctx, scope, subject); err != nil {
+		return err
+	}
+
+	s.logger.Info("authentication lockout cleared",
+		slog.String("scope", string(scope)),
+		slog.String("subject", subject),
+	)
+
+	return nil
+}
+
+func (s *LockoutService) publishLockout(ctx context.Context, lockout *domain.AuthLockout, duration time.Duration) {
+	if s.events == nil {
+		return
+	}
+
+	event := domain.Event{
+		Type:       domain.EventAuthLockout,
+		OccurredAt: time.Now(),
+		Data: map[string]interface{}{
+			"scope":            lockout.Scope,
+			"subject":          lockout.Subject,
+			"lockout_count":    lockout.LockoutCount,
+			"locked_until":     lockout.LockedUntil,
+			"duration_seconds": int(duration.Seconds()),
+		},
+	}
+
+	if err := s.events.Publish(ctx, event); err != nil {
+		s.logger.Error("failed to publish lockout event",
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// truncate shortens s to at most n bytes without splitting a rune.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
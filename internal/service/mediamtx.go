@@ -5,16 +5,32 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	mediamtx "alpineworks.io/gomediamtx"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
 )
 
 // MediaMTXClient wraps the gomediamtx client with functional options.
 type MediaMTXClient struct {
 	client    *mediamtx.ClientWithResponses
 	logger    *slog.Logger
-	publicURL string
+	endpoints PublicEndpoints
+}
+
+// PublicEndpoints are the base URLs publishers and viewers reach MediaMTX on,
+// one per protocol. An empty URL marks the protocol as disabled, and no URLs
+// are generated for it.
+type PublicEndpoints struct {
+	RTMP   string // e.g. rtmp://stream.example.org:1935
+	RTSP   string // e.g. rtsp://stream.example.org:8554
+	SRT    string // e.g. srt://stream.example.org:8890
+	WebRTC string // WHIP/WHEP, e.g. https://stream.example.org:8889
+	HLS    string // e.g. https://stream.example.org:8888
+	LLHLS  string // low-latency HLS, e.g. https://stream.example.org:8888
 }
 
 // MediaMTXOption is a functional option for configuring the MediaMTX client.
@@ -56,7 +72,7 @@ func WithTimeout(d time.Duration) MediaMTXOption {
 }
 
 // NewMediaMTXClient creates a new MediaMTX client.
-func NewMediaMTXClient(apiURL, publicURL string, opts ...MediaMTXOption) (*MediaMTXClient, error) {
+func NewMediaMTXClient(apiURL string, endpoints PublicEndpoints, opts ...MediaMTXOption) (*MediaMTXClient, error) {
 	cfg := defaultMediaMTXOptions()
 	for _, opt := range opts {
 		opt(cfg)
@@ -70,7 +86,7 @@ func NewMediaMTXClient(apiURL, publicURL string, opts ...MediaMTXOption) (*Media
 	return &MediaMTXClient{
 		client:    client,
 		logger:    cfg.logger,
-		publicURL: publicURL,
+		endpoints: endpoints.normalize(),
 	}, nil
 }
 
@@ -132,17 +148,121 @@ func (c *MediaMTXClient) KickPath(ctx context.Context, path string) error {
 	return nil
 }
 
-// GetHLSURL returns the HLS URL for a stream path.
-func (c *MediaMTXClient) GetHLSURL(path string) string {
-	return fmt.Sprintf("%s/%s/index.m3u8", c.publicURL, path)
+// pathStatsPageSize is the number of paths requested per page of path stats.
+const pathStatsPageSize = 100
+
+// PathStats are the statistics of a MediaMTX path.
+type PathStats struct {
+	// Ready is whether a publisher is sending on the path.
+	Ready bool
+	// BytesReceived is the total received from publishers of the path since
+	// it was created.
+	BytesReceived uint64
 }
 
-// GetWebRTCURL returns the WebRTC WHEP URL for a stream path.
-func (c *MediaMTXClient) GetWebRTCURL(path string) string {
-	return fmt.Sprintf("%s/%s/whep", c.publicURL, path)
+// PathStats returns the statistics of every MediaMTX path by name.
+func (c *MediaMTXClient) PathStats(ctx context.Context) (map[string]PathStats, error) {
+	stats := make(map[string]PathStats)
+	itemsPerPage := pathStatsPageSize
+
+	for page := 0; ; page++ {
+		resp, err := c.client.PathsListWithResponse(ctx, &mediamtx.PathsListParams{
+			Page:         &page,
+			ItemsPerPage: &itemsPerPage,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list paths: %w", err)
+		}
+		if resp.JSON200 == nil {
+			return nil, fmt.Errorf("failed to list paths: no path list returned")
+		}
+
+		if resp.JSON200.Items != nil {
+			for _, path := range *resp.JSON200.Items {
+				if path.Name == nil {
+					continue
+				}
+				var pathStats PathStats
+				if path.Ready != nil {
+					pathStats.Ready = *path.Ready
+				}
+				if path.BytesReceived != nil {
+					pathStats.BytesReceived = uint64(*path.BytesReceived)
+				}
+				stats[*path.Name] = pathStats
+			}
+		}
+
+		if resp.JSON200.PageCount == nil || page+1 >= *resp.JSON200.PageCount {
+			return stats, nil
+		}
+	}
+}
+
+// Endpoints returns the public endpoints of the media server.
+func (c *MediaMTXClient) Endpoints() PublicEndpoints {
+	return c.endpoints
 }
 
-// GetRTMPURL returns the RTMP URL for a stream path.
-func (c *MediaMTXClient) GetRTMPURL(path string) string {
-	return fmt.Sprintf("rtmp://%s/%s", c.publicURL, path)
+// PlaybackURLs returns the playback URLs of a stream path for each enabled protocol.
+func (c *MediaMTXClient) PlaybackURLs(path string) domain.StreamURLs {
+	path = url.PathEscape(path)
+	e := c.endpoints
+
+	var urls domain.StreamURLs
+	if e.WebRTC != "" {
+		urls.WebRTC = fmt.Sprintf("%s/%s/whep", e.WebRTC, path)
+	}
+	if e.HLS != "" {
+		urls.HLS = fmt.Sprintf("%s/%s/index.m3u8", e.HLS, path)
+	}
+	if e.LLHLS != "" {
+		urls.LLHLS = fmt.Sprintf("%s/%s/index.m3u8", e.LLHLS, path)
+	}
+	if e.RTSP != "" {
+		urls.RTSP = fmt.Sprintf("%s/%s", e.RTSP, path)
+	}
+	if e.RTMP != "" {
+		urls.RTMP = fmt.Sprintf("%s/%s", e.RTMP, path)
+	}
+	if e.SRT != "" {
+		urls.SRT = fmt.Sprintf("%s?streamid=%s", e.SRT, url.QueryEscape("read:"+path))
+	}
+
+	return urls
+}
+
+// IngestURLs returns ready-to-use publish URLs for a stream key for each
+// enabled protocol. The key is used as the path, so no separate credentials
+// are needed.
+func (c *MediaMTXClient) IngestURLs(keyValue string) domain.IngestURLs {
+	path := url.PathEscape(keyValue)
+	e := c.endpoints
+
+	var urls domain.IngestURLs
+	if e.RTMP != "" {
+		urls.RTMP = fmt.Sprintf("%s/%s", e.RTMP, path)
+	}
+	if e.RTSP != "" {
+		urls.RTSP = fmt.Sprintf("%s/%s", e.RTSP, path)
+	}
+	if e.SRT != "" {
+		urls.SRT = fmt.Sprintf("%s?streamid=%s", e.SRT, url.QueryEscape("publish:"+path))
+	}
+	if e.WebRTC != "" {
+		urls.WHIP = fmt.Sprintf("%s/%s/whip", e.WebRTC, path)
+	}
+
+	return urls
+}
+
+func (e PublicEndpoints) normalize() PublicEndpoints {
+	return PublicEndpoints{
+		RTMP:   strings.TrimRight(e.RTMP, "/"),
+		RTSP:   strings.TrimRight(e.RTSP, "/"),
+		SRT:    strings.TrimRight(e.SRT, "/"),
+		WebRTC: strings.TrimRight(e.WebRTC, "/"),
+		HLS:    strings.TrimRight(e.HLS, "/"),
+		LLHLS:  strings.TrimRight(e.LLHLS, "/"),
+	}
 }
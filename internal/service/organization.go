@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// OrganizationService manages organizations and their API clients.
+type OrganizationService struct {
+	orgRepo    domain.OrganizationRepository
+	clientRepo domain.APIClientRepository
+	logger     *slog.Logger
+}
+
+// OrganizationServiceOption is a functional option for configuring OrganizationService.
+type OrganizationServiceOption func(*OrganizationService)
+
+// WithOrganizationLogger sets the logger for OrganizationService.
+func WithOrganizationLogger(logger *slog.Logger) OrganizationServiceOption {
+	return func(s *OrganizationService) {
+		s.logger = logger
+	}
+}
+
+// NewOrganizationService creates a new OrganizationService.
+func NewOrganizationService(
+	orgRepo domain.OrganizationRepository,
+	clientRepo domain.APIClientRepository,
+	opts ...OrganizationServiceOption,
+) *OrganizationService {
+	s := &OrganizationService{
+		orgRepo:    orgRepo,
+		clientRepo: clientRepo,
+		logger:     slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Create creates a new organization.
+func (s *OrganizationService) Create(ctx context.Context, slug, name string) (*domain.Organization, error) {
+	org := &domain.Organization{
+		ID:        uuid.New(),
+		Slug:      slug,
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.orgRepo.Create(ctx, org); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("organization created",
+		slog.String("organization_id", org.ID.String()),
+		slog.String("slug", org.Slug),
+	)
+
+	return org, nil
+}
+
+// GetByID retrieves an organization by ID.
+func (s *OrganizationService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Organization, error) {
+	return s.orgRepo.GetByID(ctx, id)
+}
+
+// List retrieves all organizations.
+func (s *OrganizationService) List(ctx context.Context) ([]domain.Organization, error) {
+	return s.orgRepo.List(ctx)
+}
+
+// CreateAPIClient issues an API key and secret for an organization. The
+// secret is only returned here.
+func (s *OrganizationService) CreateAPIClient(ctx context.Context, organizationID uuid.UUID, name string) (*domain.APIClient, error) {
+	if _, err := s.orgRepo.GetByID(ctx, organizationID); err != nil {
+		return nil, err
+	}
+
+	apiKey, err := generateCredential("ak_", 16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	secret, err := generateCredential("", 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API secret: %w", err)
+	}
+
+	client := &domain.APIClient{
+		ID:             uuid.New(),
+		OrganizationID: organizationID,
+		Name:           name,
+		APIKey:         apiKey,
+		Secret:         secret,
+		CreatedAt:      time.Now(),
+	}
+
+	if err := s.clientRepo.Create(ctx, client); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("API client created",
+		slog.String("organization_id", organizationID.String()),
+		slog.String("client_id", client.ID.String()),
+	)
+
+	return client, nil
+}
+
+// ListAPIClients lists an organization's API clients without their secrets.
+func (s *OrganizationService) ListAPIClients(ctx context.Context, organizationID uuid.UUID) ([]domain.APIClient, error) {
+	if _, err := s.orgRepo.GetByID(ctx, organizationID); err != nil {
+		return nil, err
+	}
+
+	clients, err := s.clientRepo.ListByOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if clients == nil {
+		clients = []domain.APIClient{}
+	}
+
+	return clients, nil
+}
+
+// RevokeAPIClient revokes an organization's API client.
+func (s *OrganizationService) RevokeAPIClient(ctx context.Context, organizationID, id uuid.UUID) error {
+	if err := s.clientRepo.Revoke(ctx, organizationID, id); err != nil {
+		return err
+	}
+
+	s.logger.Info("API client revoked",
+		slog.String("organization_id", organizationID.String()),
+		slog.String("client_id", id.String()),
+	)
+
+	return nil
+}
+
+// GetAPIClient retrieves an API client, including its secret, by API key.
+func (s *OrganizationService) GetAPIClient(ctx context.Context, apiKey string) (*domain.APIClient, error) {
+	return s.clientRepo.GetByAPIKey(ctx, apiKey)
+}
+
+func generateCredential(prefix string, size int) (string, error) {
+	bytes := make([]byte, size)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(bytes), nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/google/uuid"
+	qrcode "github.com/skip2/go-qrcode"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// QRTarget selects what a provisioning QR code encodes.
+type QRTarget string
+
+const (
+	// QRTargetDefault picks the Larix deep link when RTMP is enabled,
+	// otherwise the first enabled ingest URL.
+	QRTargetDefault QRTarget = ""
+	QRTargetLarix   QRTarget = "larix"
+	QRTargetRTMP    QRTarget = "rtmp"
+	QRTargetSRT     QRTarget = "srt"
+	QRTargetRTSP    QRTarget = "rtsp"
+	QRTargetWHIP    QRTarget = "whip"
+)
+
+// ErrQRTargetUnavailable indicates the requested QR target has no URL,
+// because the protocol is disabled or unknown.
+var ErrQRTargetUnavailable = errors.New("QR target unavailable")
+
+// provisioningQRSize is the width and height of provisioning QR codes in pixels.
+const provisioningQRSize = 512
+
+// Provisioning builds a provisioning bundle for an active stream key.
+func (s *StreamKeyService) Provisioning(ctx context.Context, id uuid.UUID, target QRTarget) (*domain.ProvisioningBundle, error) {
+	key, err := s.streamKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !key.IsValid() {
+		return nil, domain.ErrInvalidStatus
+	}
+
+	bundle := &domain.ProvisioningBundle{
+		StreamKeyID:   key.ID,
+		BroadcasterID: key.BroadcasterID,
+		ExpiresAt:     key.ExpiresAt,
+		IngestURLs:    s.mediaMTXClient.IngestURLs(key.KeyValue),
+	}
+
+	if server := s.mediaMTXClient.Endpoints().RTMP; server != "" {
+		bundle.OBS = &domain.OBSService{
+			Type: "rtmp_custom",
+			Settings: domain.OBSServiceSettings{
+				Server: server,
+				Key:    key.KeyValue,
+			},
+		}
+		bundle.LarixURL = larixDeepLink(bundle.IngestURLs.RTMP, "RescueStream")
+	}
+
+	bundle.QRContent = qrContent(bundle, target)
+	if bundle.QRContent == "" {
+		return nil, fmt.Errorf("%w: %s", ErrQRTargetUnavailable, target)
+	}
+
+	bundle.QRCodePNG, err = qrcode.Encode(bundle.QRContent, qrcode.Medium, provisioningQRSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+
+	return bundle, nil
+}
+
+func qrContent(bundle *domain.ProvisioningBundle, target QRTarget) string {
+	urls := bundle.IngestURLs
+
+	switch target {
+	case QRTargetDefault:
+		for _, content := range []string{bundle.LarixURL, urls.SRT, urls.RTSP, urls.WHIP} {
+			if content != "" {
+				return content
+			}
+		}
+		return ""
+	case QRTargetLarix:
+		return bundle.LarixURL
+	case QRTargetRTMP:
+		return urls.RTMP
+	case QRTargetSRT:
+		return urls.SRT
+	case QRTargetRTSP:
+		return urls.RTSP
+	case QRTargetWHIP:
+		return urls.WHIP
+	default:
+		return ""
+	}
+}
+
+// larixDeepLink builds a Larix Broadcaster link that adds a connection when
+// opened on a device with Larix installed.
+func larixDeepLink(ingestURL, name string) string {
+	query := url.Values{}
+	query.Set("conn[][url]", ingestURL)
+	query.Set("conn[][name]", name)
+	query.Set("conn[][overwrite]", "on")
+	return "larix://set/v1?" + query.Encode()
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Publish query parameters accepted in ingest URLs,
+// e.g. rtmp://host/<key>?unit=K9-3&title=Ridge&lat=47.6&lon=-121.3
+const (
+	PublishQueryUnit  = "unit"
+	PublishQueryTitle = "title"
+	PublishQueryLat   = "lat"
+	PublishQueryLon   = "lon"
+
+	maxPublishUnitLength = 64
+)
+
+// publishCredentialParams are query parameters MediaMTX accepts as
+// credentials. They are not metadata and are never stored.
+var publishCredentialParams = map[string]bool{
+	"user": true,
+	"pass": true,
+}
+
+// ParsePublishQuery validates the query string of an ingest URL and returns
+// the metadata it carries. Unknown parameters are rejected so that typos are
+// reported to the publisher rather than silently dropped.
+func ParsePublishQuery(query string) (map[string]interface{}, error) {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("malformed query: %w", err)
+	}
+
+	metadata := make(map[string]interface{})
+	for key, vals := range values {
+		if publishCredentialParams[key] {
+			continue
+		}
+
+		if len(vals) > 1 {
+			return nil, fmt.Errorf("%s given more than once", key)
+		}
+		value := vals[0]
+
+		switch key {
+		case PublishQueryUnit:
+			if utf8.RuneCountInString(value) > maxPublishUnitLength {
+				return nil, fmt.Errorf("unit must be at most %d characters", maxPublishUnitLength)
+			}
+			metadata[key] = value
+		case PublishQueryTitle:
+			if utf8.RuneCountInString(value) > MaxStreamTitleLength {
+				return nil, fmt.Errorf("title must be at most %d characters", MaxStreamTitleLength)
+			}
+			metadata[key] = value
+		case PublishQueryLat:
+			lat, err := parseCoordinate(value, 90)
+			if err != nil {
+				return nil, fmt.Errorf("lat %w", err)
+			}
+			metadata[key] = lat
+		case PublishQueryLon:
+			lon, err := parseCoordinate(value, 180)
+			if err != nil {
+				return nil, fmt.Errorf("lon %w", err)
+			}
+			metadata[key] = lon
+		default:
+			return nil, fmt.Errorf("unsupported parameter %q", key)
+		}
+	}
+
+	_, hasLat := metadata[PublishQueryLat]
+	_, hasLon := metadata[PublishQueryLon]
+	if hasLat != hasLon {
+		return nil, fmt.Errorf("lat and lon must be given together")
+	}
+
+	return metadata, nil
+}
+
+func parseCoordinate(value string, limit float64) (float64, error) {
+	coordinate, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(coordinate) || math.Abs(coordinate) > limit {
+		return 0, fmt.Errorf("must be a number between -%g and %g", limit, limit)
+	}
+	return coordinate, nil
+}
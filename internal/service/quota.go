@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// DefaultDurationSweepInterval is how often stream durations are checked by default.
+const DefaultDurationSweepInterval = 30 * time.Second
+
+// DefaultDurationWarningLeadTime is how long before its maximum duration a
+// stream is warned by default.
+const DefaultDurationWarningLeadTime = 5 * time.Minute
+
+// QuotaPolicy holds the deployment-wide per-broadcaster limits. Zero means
+// unlimited.
+type QuotaPolicy struct {
+	// MaxActiveKeys caps the active, unexpired stream keys of a broadcaster.
+	MaxActiveKeys int
+	// MaxConcurrentStreams caps the simultaneous live streams of a broadcaster.
+	MaxConcurrentStreams int
+	// MaxStreamDuration caps how long a single stream may run.
+	MaxStreamDuration time.Duration
+}
+
+// For returns the limits for a broadcaster, applying its quota overrides.
+func (p QuotaPolicy) For(quotas domain.BroadcasterQuotas) QuotaPolicy {
+	if quotas.MaxActiveKeys != nil {
+		p.MaxActiveKeys = *quotas.MaxActiveKeys
+	}
+	if quotas.MaxConcurrentStreams != nil {
+		p.MaxConcurrentStreams = *quotas.MaxConcurrentStreams
+	}
+	if quotas.MaxStreamDurationSeconds != nil {
+		p.MaxStreamDuration = time.Duration(*quotas.MaxStreamDurationSeconds) * time.Second
+	}
+	return p
+}
+
+// checkActiveKeyQuota returns ErrQuotaExceeded if the broadcaster cannot hold
+// another active stream key. The broadcaster must be locked in the unit of
+// work streamKeyRepo belongs to, so concurrent checks cannot both pass.
+func checkActiveKeyQuota(ctx context.Context, streamKeyRepo domain.StreamKeyRepository, broadcaster *domain.Broadcaster, policy QuotaPolicy) error {
+	limit := policy.For(broadcaster.Quotas).MaxActiveKeys
+	if limit <= 0 {
+		return nil
+	}
+
+	count, err := streamKeyRepo.CountActiveByBroadcaster(ctx, broadcaster.ID)
+	if err != nil {
+		return err
+	}
+
+	if count >= limit {
+		return fmt.Errorf("%w: broadcaster has %d of %d active stream keys", domain.ErrQuotaExceeded, count, limit)
+	}
+
+	return nil
+}
+
+// DurationEnforcer periodically warns streams approaching their maximum
+// duration and ends streams that have run longer. The maximum duration of a
+// stream is that of its key, else of its broadcaster, else the deployment-wide
+// quota.
+type DurationEnforcer struct {
+	streamRepo     domain.StreamRepository
+	mediaMTXClient *MediaMTXClient
+	events         domain.EventPublisher
+	policy         QuotaPolicy
+	interval       time.Duration
+	warningLead    time.Duration
+	logger         *slog.Logger
+}
+
+// DurationEnforcerOption is a functional option for configuring DurationEnforcer.
+type DurationEnforcerOption func(*DurationEnforcer)
+
+// WithDurationEnforcerLogger sets the logger for DurationEnforcer.
+func WithDurationEnforcerLogger(logger *slog.Logger) DurationEnforcerOption {
+	return func(e *DurationEnforcer) {
+		e.logger = logger
+	}
+}
+
+// WithDurationEnforcerInterval sets how often stream durations are checked.
+func WithDurationEnforcerInterval(interval time.Duration) DurationEnforcerOption {
+	return func(e *DurationEnforcer) {
+		e.interval = interval
+	}
+}
+
+// WithDurationEnforcerQuotaPolicy sets the deployment-wide quotas.
+func WithDurationEnforcerQuotaPolicy(policy QuotaPolicy) DurationEnforcerOption {
+	return func(e *DurationEnforcer) {
+		e.policy = policy
+	}
+}
+
+// WithDurationWarningLeadTime sets how long before its maximum duration a
+// stream is warned. Zero disables warnings.
+func WithDurationWarningLeadTime(lead time.Duration) DurationEnforcerOption {
+	return func(e *DurationEnforcer) {
+		e.warningLead = lead
+	}
+}
+
+// WithDurationEnforcerEventPublisher sets where duration warnings are published.
+func WithDurationEnforcerEventPublisher(events domain.EventPublisher) DurationEnforcerOption {
+	return func(e *DurationEnforcer) {
+		e.events = events
+	}
+}
+
+// NewDurationEnforcer creates a new DurationEnforcer.
+func NewDurationEnforcer(streamRepo domain.StreamRepository, mediaMTXClient *MediaMTXClient, opts ...DurationEnforcerOption) *DurationEnforcer {
+	e := &DurationEnforcer{
+		streamRepo:     streamRepo,
+		mediaMTXClient: mediaMTXClient,
+		interval:       DefaultDurationSweepInterval,
+		warningLead:    DefaultDurationWarningLeadTime,
+		logger:         slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if e.interval <= 0 {
+		e.interval = DefaultDurationSweepInterval
+	}
+
+	return e
+}
+
+// Run enforces stream durations immediately and then on every interval until
+// ctx is cancelled.
+func (e *DurationEnforcer) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Sweep(ctx); err != nil && ctx.Err() == nil {
+			e.logger.Error("stream duration sweep failed",
+				slog.String("error", err.Error()),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep warns every stream approaching its maximum duration, then kicks and
+// ends every stream over it.
+func (e *DurationEnforcer) Sweep(ctx context.Context) error {
+	if e.warningLead > 0 {
+		if err := e.warn(ctx); err != nil && ctx.Err() == nil {
+			e.logger.Error("stream duration warning failed",
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	streams, err := e.streamRepo.ListActiveOverDuration(ctx, e.policy.MaxStreamDuration)
+	if err != nil {
+		return fmt.Errorf("failed to list streams over duration: %w", err)
+	}
+
+	for _, stream := range streams {
+		e.logger.Info("ending stream over maximum duration",
+			slog.String("stream_id", stream.ID.String()),
+			slog.String("path", stream.Path),
+			slog.Time("started_at", stream.StartedAt),
+		)
+
+		if err := terminateStream(ctx, e.streamRepo, e.mediaMTXClient, e.logger, &stream, domain.StreamEndDurationExceeded); err != nil {
+			e.logger.Warn("failed to end stream over maximum duration",
+				slog.String("error", err.Error()),
+				slog.String("stream_id", stream.ID.String()),
+			)
+		}
+	}
+
+	return nil
+}
+
+// warn publishes a warning for every stream approaching its maximum duration
+// and then records it, so a stream whose warning could not be published is
+// warned again on the next sweep.
+func (e *DurationEnforcer) warn(ctx context.Context) error {
+	warnings, err := e.streamRepo.ListApproachingDuration(ctx, e.policy.MaxStreamDuration, e.warningLead)
+	if err != nil {
+		return fmt.Errorf("failed to list streams approaching duration: %w", err)
+	}
+
+	now := time.Now()
+	for _, warning := range warnings {
+		stream := warning.Stream
+		remaining := warning.EndsAt.Sub(now)
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		if err := e.publish(ctx, domain.Event{
+			Type:       domain.EventStreamDurationWarning,
+			OccurredAt: now,
+			Data: map[string]interface{}{
+				"stream_id":         stream.ID,
+				"stream_key_id":     stream.StreamKeyID,
+				"path":              stream.Path,
+				"ends_at":           warning.EndsAt,
+				"remaining_seconds": int(remaining.Seconds()),
+			},
+		}); err != nil {
+			e.logger.Error("failed to publish stream duration warning",
+				slog.String("error", err.Error()),
+				slog.String("stream_id", stream.ID.String()),
+			)
+			continue
+		}
+
+		if err := e.streamRepo.MarkDurationWarned(ctx, stream.ID, warning.EndsAt); err != nil {
+			// The stream ended or was warned by another instance meanwhile
+			if errors.Is(err, domain.ErrNotFound) {
+				continue
+			}
+			return err
+		}
+
+		e.logger.Info("stream approaching maximum duration",
+			slog.String("stream_id", stream.ID.String()),
+			slog.String("path", stream.Path),
+			slog.Time("ends_at", warning.EndsAt),
+		)
+	}
+
+	return nil
+}
+
+func (e *DurationEnforcer) publish(ctx context.Context, event domain.Event) error {
+	if e.events == nil {
+		return nil
+	}
+
+	return e.events.Publish(ctx, event)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// DefaultReconnectSweepInterval is how often interrupted streams are checked
+// by default.
+const DefaultReconnectSweepInterval = 5 * time.Second
+
+// ReconnectSweeper periodically ends interrupted streams whose publisher did
+// not reconnect within the reconnect grace period.
+type ReconnectSweeper struct {
+	streamRepo domain.StreamRepository
+	grace      time.Duration
+	interval   time.Duration
+	logger     *slog.Logger
+}
+
+// ReconnectSweeperOption is a functional option for configuring ReconnectSweeper.
+type ReconnectSweeperOption func(*ReconnectSweeper)
+
+// WithReconnectSweeperLogger sets the logger for ReconnectSweeper.
+func WithReconnectSweeperLogger(logger *slog.Logger) ReconnectSweeperOption {
+	return func(s *ReconnectSweeper) {
+		s.logger = logger
+	}
+}
+
+// WithReconnectSweepInterval sets how often interrupted streams are checked.
+func WithReconnectSweepInterval(interval time.Duration) ReconnectSweeperOption {
+	return func(s *ReconnectSweeper) {
+		s.interval = interval
+	}
+}
+
+// NewReconnectSweeper creates a new ReconnectSweeper ending streams
+// interrupted for longer than grace.
+func NewReconnectSweeper(streamRepo domain.StreamRepository, grace time.Duration, opts ...ReconnectSweeperOption) *ReconnectSweeper {
+	s := &ReconnectSweeper{
+		streamRepo: streamRepo,
+		grace:      grace,
+		interval:   DefaultReconnectSweepInterval,
+		logger:     slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.interval <= 0 {
+		s.interval = DefaultReconnectSweepInterval
+	}
+
+	return s
+}
+
+// Run sweeps immediately and then on every interval until ctx is cancelled.
+func (s *ReconnectSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Sweep(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("interrupted stream sweep failed",
+				slog.String("error", err.Error()),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep ends every stream interrupted for longer than the grace period.
+func (s *ReconnectSweeper) Sweep(ctx context.Context) error {
+	streams, err := s.streamRepo.EndInterruptedBefore(ctx, time.Now().Add(-s.grace))
+	if err != nil {
+		return fmt.Errorf("failed to end interrupted streams: %w", err)
+	}
+
+	for _, stream := range streams {
+		s.logger.Info("stream ended after publisher did not reconnect",
+			slog.String("stream_id", stream.ID.String()),
+			slog.String("path", stream.Path),
+		)
+	}
+
+	return nil
+}
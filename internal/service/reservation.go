@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// DefaultReservationSweepInterval is how often expired stream reservations
+// are deleted by default.
+const DefaultReservationSweepInterval = 10 * time.Second
+
+// ReservationSweeper periodically deletes the stream reservations made at
+// authentication that expired without a ready webhook, such as when the
+// publisher gave up before MediaMTX reported the stream ready.
+type ReservationSweeper struct {
+	streamRepo domain.StreamRepository
+	interval   time.Duration
+	logger     *slog.Logger
+}
+
+// ReservationSweeperOption is a functional option for configuring ReservationSweeper.
+type ReservationSweeperOption func(*ReservationSweeper)
+
+// WithReservationSweeperLogger sets the logger for ReservationSweeper.
+func WithReservationSweeperLogger(logger *slog.Logger) ReservationSweeperOption {
+	return func(s *ReservationSweeper) {
+		s.logger = logger
+	}
+}
+
+// WithReservationSweepInterval sets how often expired reservations are deleted.
+func WithReservationSweepInterval(interval time.Duration) ReservationSweeperOption {
+	return func(s *ReservationSweeper) {
+		s.interval = interval
+	}
+}
+
+// NewReservationSweeper creates a new ReservationSweeper.
+func NewReservationSweeper(streamRepo domain.StreamRepository, opts ...ReservationSweeperOption) *ReservationSweeper {
+	s := &ReservationSweeper{
+		streamRepo: streamRepo,
+		interval:   DefaultReservationSweepInterval,
+		logger:     slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.interval <= 0 {
+		s.interval = DefaultReservationSweepInterval
+	}
+
+	return s
+}
+
+// Run sweeps immediately and then on every interval until ctx is cancelled.
+func (s *ReservationSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Sweep(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("stream reservation sweep failed",
+				slog.String("error", err.Error()),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep deletes every expired reservation.
+func (s *ReservationSweeper) Sweep(ctx context.Context) error {
+	count, err := s.streamRepo.DeleteExpiredReservations(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to delete expired stream reservations: %w", err)
+	}
+
+	if count > 0 {
+		s.logger.Info("expired stream reservations deleted",
+			slog.Int64("count", count),
+		)
+	}
+
+	return nil
+}
@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -45,9 +48,25 @@ func NewStreamService(
 	return s
 }
 
-// ListActive returns all active streams with video URLs.
-func (s *StreamService) ListActive(ctx context.Context) ([]domain.StreamWithURLs, error) {
-	streams, err := s.streamRepo.ListActive(ctx)
+// MaxStreamTitleLength is the longest stream title that may be set.
+const MaxStreamTitleLength = 255
+
+// UpdateStreamRequest represents a request to update the labels of a stream.
+// Nil fields are left unchanged.
+type UpdateStreamRequest struct {
+	Title *string
+	Notes *string
+	Tags  *[]string
+	// MetadataPatch is applied to the stream metadata as a JSON merge patch
+	// (RFC 7396): nil values remove keys and objects are merged recursively.
+	MetadataPatch map[string]interface{}
+}
+
+// ListActive returns active streams matching the filter with video URLs.
+func (s *StreamService) ListActive(ctx context.Context, filter domain.StreamFilter) ([]domain.StreamWithURLs, error) {
+	filter.Tags = NormalizeTags(filter.Tags)
+
+	streams, err := s.streamRepo.ListActive(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -56,10 +75,7 @@ func (s *StreamService) ListActive(ctx context.Context) ([]domain.StreamWithURLs
 	for i, stream := range streams {
 		result[i] = domain.StreamWithURLs{
 			Stream: stream,
-			URLs: domain.StreamURLs{
-				HLS:    s.mediaMTXClient.GetHLSURL(stream.Path),
-				WebRTC: s.mediaMTXClient.GetWebRTCURL(stream.Path),
-			},
+			URLs:   s.mediaMTXClient.PlaybackURLs(stream.Path),
 		}
 	}
 
@@ -75,9 +91,247 @@ func (s *StreamService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Stre
 
 	return &domain.StreamWithURLs{
 		Stream: *stream,
-		URLs: domain.StreamURLs{
-			HLS:    s.mediaMTXClient.GetHLSURL(stream.Path),
-			WebRTC: s.mediaMTXClient.GetWebRTCURL(stream.Path),
-		},
+		URLs:   s.mediaMTXClient.PlaybackURLs(stream.Path),
 	}, nil
 }
+
+// Update updates the title, notes, tags and metadata of a stream. Empty
+// titles and notes are cleared. Streams shared with the caller's
+// organization are read-only.
+func (s *StreamService) Update(ctx context.Context, id uuid.UUID, req UpdateStreamRequest) (*domain.StreamWithURLs, error) {
+	stream, err := s.getOwned(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Title != nil {
+		stream.Title = emptyToNil(*req.Title)
+	}
+
+	if req.Notes != nil {
+		stream.Notes = emptyToNil(*req.Notes)
+	}
+
+	if req.Tags != nil {
+		stream.Tags = NormalizeTags(*req.Tags)
+	}
+
+	if req.MetadataPatch != nil {
+		stream.Metadata = mergePatch(stream.Metadata, req.MetadataPatch)
+	}
+
+	if err := s.streamRepo.UpdateLabels(ctx, stream); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("stream updated",
+		slog.String("stream_id", id.String()),
+	)
+
+	return s.GetByID(ctx, id)
+}
+
+// Share grants another organization read access to a stream, e.g. for a
+// mutual-aid incident. Only the owning organization can share a stream.
+func (s *StreamService) Share(ctx context.Context, id, organizationID uuid.UUID) (*domain.StreamShare, error) {
+	stream, err := s.getOwned(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if organizationID == stream.OrganizationID {
+		return nil, fmt.Errorf("%w: a stream cannot be shared with its own organization", domain.ErrInvalidShare)
+	}
+
+	share, err := s.streamRepo.Share(ctx, id, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("stream shared",
+		slog.String("stream_id", id.String()),
+		slog.String("organization_id", organizationID.String()),
+	)
+
+	return share, nil
+}
+
+// Unshare revokes an organization's access to a stream.
+func (s *StreamService) Unshare(ctx context.Context, id, organizationID uuid.UUID) error {
+	if _, err := s.getOwned(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.streamRepo.Unshare(ctx, id, organizationID); err != nil {
+		return err
+	}
+
+	s.logger.Info("stream unshared",
+		slog.String("stream_id", id.String()),
+		slog.String("organization_id", organizationID.String()),
+	)
+
+	return nil
+}
+
+// ListShares lists the organizations a stream is shared with.
+func (s *StreamService) ListShares(ctx context.Context, id uuid.UUID) ([]domain.StreamShare, error) {
+	if _, err := s.getOwned(ctx, id); err != nil {
+		return nil, err
+	}
+
+	shares, err := s.streamRepo.ListShares(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if shares == nil {
+		shares = []domain.StreamShare{}
+	}
+
+	return shares, nil
+}
+
+// ListSegments lists the publishing segments of a stream. Gaps between
+// segments are publisher reconnects.
+func (s *StreamService) ListSegments(ctx context.Context, id uuid.UUID) ([]domain.StreamSegment, error) {
+	if _, err := s.streamRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	segments, err := s.streamRepo.ListSegments(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if segments == nil {
+		segments = []domain.StreamSegment{}
+	}
+
+	return segments, nil
+}
+
+// Timeline lists the lifecycle events of a stream in the order they happened.
+func (s *StreamService) Timeline(ctx context.Context, id uuid.UUID) ([]domain.StreamEvent, error) {
+	if _, err := s.streamRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	events, err := s.streamRepo.ListEvents(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if events == nil {
+		events = []domain.StreamEvent{}
+	}
+
+	return events, nil
+}
+
+// getOwned returns a stream, or ErrForbidden if it is only shared with the
+// organization ctx is scoped to.
+func (s *StreamService) getOwned(ctx context.Context, id uuid.UUID) (*domain.Stream, error) {
+	stream, err := s.streamRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if orgID, ok := domain.OrganizationFromContext(ctx); ok && orgID != stream.OrganizationID {
+		return nil, domain.ErrForbidden
+	}
+
+	return stream, nil
+}
+
+// terminateStream kicks the publisher of an active stream from MediaMTX,
+// recording the kick, and ends the stream with the given reason. A failed kick
+// is logged, as the stream ends either way; only failing to end it is returned.
+func terminateStream(ctx context.Context, streamRepo domain.StreamRepository, mediaMTXClient *MediaMTXClient, logger *slog.Logger, stream *domain.Stream, reason domain.StreamEndReason) error {
+	kickStream(ctx, streamRepo, mediaMTXClient, logger, stream, reason)
+
+	if err := streamRepo.EndStream(ctx, stream.ID, reason); err != nil {
+		return fmt.Errorf("failed to end stream: %w", err)
+	}
+
+	return nil
+}
+
+// kickStream kicks the publisher of a stream that was active from MediaMTX,
+// recording the kick. A failed kick is logged.
+func kickStream(ctx context.Context, streamRepo domain.StreamRepository, mediaMTXClient *MediaMTXClient, logger *slog.Logger, stream *domain.Stream, reason domain.StreamEndReason) {
+	// An interrupted stream has no publisher to kick
+	if stream.Status != domain.StreamStatusActive {
+		return
+	}
+
+	if err := mediaMTXClient.KickPath(ctx, stream.Path); err != nil {
+		logger.Warn("failed to kick path from MediaMTX",
+			slog.String("error", err.Error()),
+			slog.String("path", stream.Path),
+		)
+		return
+	}
+
+	if err := streamRepo.RecordEvent(ctx, &domain.StreamEvent{
+		StreamID:   stream.ID,
+		Type:       domain.StreamEventKicked,
+		OccurredAt: time.Now(),
+		Details:    map[string]interface{}{"reason": string(reason)},
+	}); err != nil {
+		logger.Warn("failed to record stream kick",
+			slog.String("error", err.Error()),
+			slog.String("stream_id", stream.ID.String()),
+		)
+	}
+}
+
+// NormalizeTags trims and lowercases tags, dropping empty and duplicate tags.
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
+
+// mergePatch applies a JSON merge patch to target and returns the result.
+func mergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = make(map[string]interface{})
+	}
+
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+
+		patchObject, ok := value.(map[string]interface{})
+		if !ok {
+			target[key] = value
+			continue
+		}
+
+		targetObject, _ := target[key].(map[string]interface{})
+		target[key] = mergePatch(targetObject, patchObject)
+	}
+
+	return target
+}
+
+func emptyToNil(s string) *string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	return &s
+}
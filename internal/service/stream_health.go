@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// DefaultStreamHealthSampleInterval is how often stream health is sampled by
+// default.
+const DefaultStreamHealthSampleInterval = 10 * time.Second
+
+// StreamHealthMonitor periodically samples the bytes MediaMTX received on the
+// paths of active streams. A stream whose byte counter stops increasing is
+// stalled, and one whose bitrate falls below the minimum of its key is
+// degraded. Health changes are recorded in the stream timeline and published
+// as events.
+type StreamHealthMonitor struct {
+	streamRepo     domain.StreamRepository
+	mediaMTXClient *MediaMTXClient
+	events         domain.EventPublisher
+	minBitrateKbps int
+	interval       time.Duration
+	logger         *slog.Logger
+
+	mu sync.Mutex
+	// samples holds the previous sample of each active stream.
+	samples map[uuid.UUID]healthSample
+}
+
+// healthSample is the byte counter of a stream's path at a point in time.
+type healthSample struct {
+	bytesReceived uint64
+	at            time.Time
+}
+
+// StreamHealthMonitorOption is a functional option for configuring
+// StreamHealthMonitor.
+type StreamHealthMonitorOption func(*StreamHealthMonitor)
+
+// WithStreamHealthMonitorLogger sets the logger for StreamHealthMonitor.
+func WithStreamHealthMonitorLogger(logger *slog.Logger) StreamHealthMonitorOption {
+	return func(m *StreamHealthMonitor) {
+		m.logger = logger
+	}
+}
+
+// WithStreamHealthSampleInterval sets how often stream health is sampled.
+func WithStreamHealthSampleInterval(interval time.Duration) StreamHealthMonitorOption {
+	return func(m *StreamHealthMonitor) {
+		m.interval = interval
+	}
+}
+
+// WithStreamHealthMinBitrate sets the bitrate in kbps below which streams are
+// degraded, for keys without their own minimum. Zero disables the check.
+func WithStreamHealthMinBitrate(kbps int) StreamHealthMonitorOption {
+	return func(m *StreamHealthMonitor) {
+		m.minBitrateKbps = kbps
+	}
+}
+
+// WithStreamHealthEventPublisher sets the publisher notified of health changes.
+func WithStreamHealthEventPublisher(events domain.EventPublisher) StreamHealthMonitorOption {
+	return func(m *StreamHealthMonitor) {
+		m.events = events
+	}
+}
+
+// NewStreamHealthMonitor creates a new StreamHealthMonitor.
+func NewStreamHealthMonitor(
+	streamRepo domain.StreamRepository,
+	mediaMTXClient *MediaMTXClient,
+	opts ...StreamHealthMonitorOption,
+) *StreamHealthMonitor {
+	m := &StreamHealthMonitor{
+		streamRepo:     streamRepo,
+		mediaMTXClient: mediaMTXClient,
+		interval:       DefaultStreamHealthSampleInterval,
+		logger:         slog.Default(),
+		samples:        make(map[uuid.UUID]healthSample),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if m.interval <= 0 {
+		m.interval = DefaultStreamHealthSampleInterval
+	}
+
+	return m
+}
+
+// Run samples immediately and then on every interval until ctx is cancelled.
+func (m *StreamHealthMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Sample(ctx); err != nil && ctx.Err() == nil {
+			m.logger.Error("stream health sample failed",
+				slog.String("error", err.Error()),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sample samples the path of every active stream and updates the health of
+// streams it changed for. The first sample of a stream is its baseline, so a
+// stream's health is known from its second sample on. Streams are left
+// untouched when MediaMTX cannot be reached.
+func (m *StreamHealthMonitor) Sample(ctx context.Context) error {
+	stats, err := m.mediaMTXClient.PathStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	streams, err := m.streamRepo.ListMonitored(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list active streams: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	samples := make(map[uuid.UUID]healthSample, len(streams))
+
+	for i := range streams {
+		stream := &streams[i].Stream
+
+		// A path MediaMTX isn't receiving on is stalled outright
+		pathStats, ok := stats[stream.Path]
+		if !ok || !pathStats.Ready {
+			m.setHealth(ctx, stream, domain.StreamHealthStalled, 0)
+			continue
+		}
+
+		current := healthSample{bytesReceived: pathStats.BytesReceived, at: now}
+		samples[stream.ID] = current
+
+		// A counter going backwards means the path was recreated, so the
+		// sample is a new baseline
+		previous, ok := m.samples[stream.ID]
+		if !ok || current.bytesReceived < previous.bytesReceived {
+			continue
+		}
+
+		health, bitrateKbps := m.assess(streams[i].MinBitrateKbps, previous, current)
+		m.setHealth(ctx, stream, health, bitrateKbps)
+	}
+
+	m.samples = samples
+
+	return nil
+}
+
+// assess returns the health and bitrate of a stream between two samples.
+func (m *StreamHealthMonitor) assess(keyMinBitrateKbps *int, previous, current healthSample) (domain.StreamHealth, int) {
+	received := current.bytesReceived - previous.bytesReceived
+	if received == 0 {
+		return domain.StreamHealthStalled, 0
+	}
+
+	elapsed := current.at.Sub(previous.at).Seconds()
+	if elapsed <= 0 {
+		return domain.StreamHealthHealthy, 0
+	}
+	bitrateKbps := int(math.Round(float64(received) * 8 / elapsed / 1000))
+
+	minBitrateKbps := m.minBitrateKbps
+	if keyMinBitrateKbps != nil {
+		minBitrateKbps = *keyMinBitrateKbps
+	}
+
+	if minBitrateKbps > 0 && bitrateKbps < minBitrateKbps {
+		return domain.StreamHealthDegraded, bitrateKbps
+	}
+
+	return domain.StreamHealthHealthy, bitrateKbps
+}
+
+// setHealth updates the health of a stream if it changed, recording and
+// publishing the change. A stream found healthy on its first assessment is
+// not a change worth reporting.
+func (m *StreamHealthMonitor) setHealth(ctx context.Context, stream *domain.Stream, health domain.StreamHealth, bitrateKbps int) {
+	if stream.Health != nil && *stream.Health == health {
+		return
+	}
+
+	if err := m.streamRepo.UpdateHealth(ctx, stream.ID, health); err != nil {
+		// The stream ended since it was listed
+		if !errors.Is(err, domain.ErrNotFound) {
+			m.logger.Warn("failed to update stream health",
+				slog.String("error", err.Error()),
+				slog.String("stream_id", stream.ID.String()),
+			)
+		}
+		return
+	}
+
+	if stream.Health == nil && health == domain.StreamHealthHealthy {
+		return
+	}
+
+	details := map[string]interface{}{
+		"health":       string(health),
+		"bitrate_kbps": bitrateKbps,
+	}
+	if stream.Health != nil {
+		details["previous_health"] = string(*stream.Health)
+	}
+
+	now := time.Now()
+	if err := m.streamRepo.RecordEvent(ctx, &domain.StreamEvent{
+		StreamID:   stream.ID,
+		Type:       domain.StreamEventHealthChanged,
+		OccurredAt: now,
+		Details:    details,
+	}); err != nil {
+		m.logger.Warn("failed to record stream health change",
+			slog.String("error", err.Error()),
+			slog.String("stream_id", stream.ID.String()),
+		)
+	}
+
+	m.logger.Info("stream health changed",
+		slog.String("stream_id", stream.ID.String()),
+		slog.String("path", stream.Path),
+		slog.String("health", string(health)),
+		slog.Int("bitrate_kbps", bitrateKbps),
+	)
+
+	data := map[string]interface{}{
+		"stream_id":     stream.ID,
+		"stream_key_id": stream.StreamKeyID,
+		"path":          stream.Path,
+	}
+	for k, v := range details {
+		data[k] = v
+	}
+	m.publish(ctx, domain.Event{
+		Type:       domain.EventStreamHealthChanged,
+		OccurredAt: now,
+		Data:       data,
+	})
+}
+
+func (m *StreamHealthMonitor) publish(ctx context.Context, event domain.Event) {
+	if m.events == nil {
+		return
+	}
+
+	if err := m.events.Publish(ctx, event); err != nil {
+		m.logger.Error("failed to publish stream health event",
+			slog.String("error", err.Error()),
+			slog.String("type", string(event.Type)),
+		)
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"log/slog"
+	"regexp"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,10 +17,13 @@ import (
 
 // StreamKeyService handles stream key management.
 type StreamKeyService struct {
-	streamKeyRepo  domain.StreamKeyRepository
-	streamRepo     domain.StreamRepository
-	mediaMTXClient *MediaMTXClient
-	logger         *slog.Logger
+	streamKeyRepo   domain.StreamKeyRepository
+	streamRepo      domain.StreamRepository
+	broadcasterRepo domain.BroadcasterRepository
+	mediaMTXClient  *MediaMTXClient
+	unitOfWork      domain.UnitOfWork
+	quotas          QuotaPolicy
+	logger          *slog.Logger
 }
 
 // StreamKeyServiceOption is a functional option for configuring StreamKeyService.
@@ -31,18 +36,31 @@ func WithStreamKeyLogger(logger *slog.Logger) StreamKeyServiceOption {
 	}
 }
 
-// NewStreamKeyService creates a new StreamKeyService.
+// WithStreamKeyQuotaPolicy sets the deployment-wide broadcaster quotas.
+func WithStreamKeyQuotaPolicy(policy QuotaPolicy) StreamKeyServiceOption {
+	return func(s *StreamKeyService) {
+		s.quotas = policy
+	}
+}
+
+// NewStreamKeyService creates a new StreamKeyService. Status changes are made
+// in unitOfWork, so that a key is locked while its stream is ended and its
+// status updated.
 func NewStreamKeyService(
 	streamKeyRepo domain.StreamKeyRepository,
 	streamRepo domain.StreamRepository,
+	broadcasterRepo domain.BroadcasterRepository,
 	mediaMTXClient *MediaMTXClient,
+	unitOfWork domain.UnitOfWork,
 	opts ...StreamKeyServiceOption,
 ) *StreamKeyService {
 	s := &StreamKeyService{
-		streamKeyRepo:  streamKeyRepo,
-		streamRepo:     streamRepo,
-		mediaMTXClient: mediaMTXClient,
-		logger:         slog.Default(),
+		streamKeyRepo:   streamKeyRepo,
+		streamRepo:      streamRepo,
+		broadcasterRepo: broadcasterRepo,
+		mediaMTXClient:  mediaMTXClient,
+		unitOfWork:      unitOfWork,
+		logger:          slog.Default(),
 	}
 
 	for _, opt := range opts {
@@ -55,10 +73,19 @@ func NewStreamKeyService(
 // CreateRequest represents a request to create a stream key.
 type CreateRequest struct {
 	BroadcasterID uuid.UUID
+	Label         *string
+	Description   *string
 	ExpiresAt     *time.Time
+	// BatchID adds the key to a batch, making its value exportable once. A
+	// broadcaster is issued at most one key per batch; if it already has one,
+	// that key is returned instead.
+	BatchID *uuid.UUID
+	// DeviceID binds the key to one of the broadcaster's devices.
+	DeviceID *uuid.UUID
 }
 
-// Create creates a new stream key for a broadcaster.
+// Create creates a new stream key for a broadcaster. Archived broadcasters
+// cannot be issued keys, nor can broadcasters at their active key quota.
 func (s *StreamKeyService) Create(ctx context.Context, req CreateRequest) (*domain.StreamKey, error) {
 	keyValue, err := generateStreamKey()
 	if err != nil {
@@ -69,15 +96,52 @@ func (s *StreamKeyService) Create(ctx context.Context, req CreateRequest) (*doma
 		ID:            uuid.New(),
 		KeyValue:      keyValue,
 		BroadcasterID: req.BroadcasterID,
+		BatchID:       req.BatchID,
+		DeviceID:      req.DeviceID,
+		Label:         optionalString(req.Label),
+		Description:   optionalString(req.Description),
 		Status:        domain.StreamKeyStatusActive,
 		CreatedAt:     time.Now(),
 		ExpiresAt:     req.ExpiresAt,
 	}
 
-	if err := s.streamKeyRepo.Create(ctx, key); err != nil {
+	// The broadcaster stays locked until the key is created, so concurrent
+	// creations cannot exceed its quota
+	var existing *domain.StreamKey
+	err = s.unitOfWork.WithTx(ctx, func(repos domain.Repositories) error {
+		broadcaster, err := repos.Broadcasters.GetAndLockByID(ctx, req.BroadcasterID)
+		if err != nil {
+			return err
+		}
+
+		if broadcaster.IsArchived() {
+			return domain.ErrBroadcasterArchived
+		}
+
+		if req.BatchID != nil {
+			existing, err = repos.StreamKeys.GetByBatchAndBroadcaster(ctx, *req.BatchID, req.BroadcasterID)
+			if err == nil {
+				return nil
+			}
+			if !errors.Is(err, domain.ErrNotFound) {
+				return err
+			}
+		}
+
+		if err := checkActiveKeyQuota(ctx, repos.StreamKeys, broadcaster, s.quotas); err != nil {
+			return err
+		}
+
+		return repos.StreamKeys.Create(ctx, key)
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	if existing != nil {
+		return existing, nil
+	}
+
 	s.logger.Info("stream key created",
 		slog.String("key_id", key.ID.String()),
 		slog.String("broadcaster_id", key.BroadcasterID.String()),
@@ -86,6 +150,11 @@ func (s *StreamKeyService) Create(ctx context.Context, req CreateRequest) (*doma
 	return key, nil
 }
 
+// IngestURLs returns the publish URLs for a stream key value.
+func (s *StreamKeyService) IngestURLs(keyValue string) domain.IngestURLs {
+	return s.mediaMTXClient.IngestURLs(keyValue)
+}
+
 // GetByID retrieves a stream key by ID.
 func (s *StreamKeyService) GetByID(ctx context.Context, id uuid.UUID) (*domain.StreamKey, error) {
 	return s.streamKeyRepo.GetByID(ctx, id)
@@ -106,6 +175,11 @@ func (s *StreamKeyService) List(ctx context.Context) ([]domain.StreamKey, error)
 	return keys, nil
 }
 
+// Quotas returns the deployment-wide broadcaster quotas.
+func (s *StreamKeyService) Quotas() QuotaPolicy {
+	return s.quotas
+}
+
 // ListByBroadcaster retrieves all stream keys for a broadcaster.
 func (s *StreamKeyService) ListByBroadcaster(ctx context.Context, broadcasterID uuid.UUID) ([]domain.StreamKey, error) {
 	keys, err := s.streamKeyRepo.ListByBroadcaster(ctx, broadcasterID)
@@ -121,53 +195,258 @@ func (s *StreamKeyService) ListByBroadcaster(ctx context.Context, broadcasterID
 	return keys, nil
 }
 
-// Revoke revokes a stream key and terminates any active stream.
-func (s *StreamKeyService) Revoke(ctx context.Context, id uuid.UUID) error {
-	key, err := s.streamKeyRepo.GetByID(ctx, id)
+// ListByGroup retrieves the stream keys of every member of a broadcaster group.
+func (s *StreamKeyService) ListByGroup(ctx context.Context, groupID uuid.UUID) ([]domain.StreamKey, error) {
+	keys, err := s.streamKeyRepo.ListByGroup(ctx, groupID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// Clear key values for security
+	for i := range keys {
+		keys[i].KeyValue = ""
 	}
 
-	if key.Status != domain.StreamKeyStatusActive {
-		return domain.ErrInvalidStatus
+	return keys, nil
+}
+
+// MaxStreamKeyLabelLength is the longest stream key label that may be set.
+const MaxStreamKeyLabelLength = 255
+
+// UpdateStreamKeyRequest represents a request to update a stream key. Nil
+// fields are left unchanged; an empty label or description clears it.
+type UpdateStreamKeyRequest struct {
+	Label       *string
+	Description *string
+	ExpiresAt   *time.Time
+	// ClearExpiresAt removes the expiry so the key never expires.
+	ClearExpiresAt bool
+	DeviceID       *uuid.UUID
+	// ClearDeviceID unbinds the key from its device.
+	ClearDeviceID  bool
+	MinBitrateKbps *int
+	// ClearMinBitrate makes the key's streams use the deployment-wide
+	// minimum bitrate.
+	ClearMinBitrate bool
+	// MaxStreamDurationSeconds caps how long the key's streams may run; zero
+	// means unlimited.
+	MaxStreamDurationSeconds *int
+	// ClearMaxStreamDuration makes the key's streams use the broadcaster's
+	// maximum stream duration.
+	ClearMaxStreamDuration bool
+}
+
+// Update updates the label, description, expiry, device, minimum bitrate and
+// maximum stream duration of a stream key.
+// The expiry of a revoked or expired key cannot be changed, and a new expiry
+// must be in the future. Extending a key past its expiry counts it as active
+// again, subject to the broadcaster's active key quota. Streams already
+// started keep the device they were published from.
+func (s *StreamKeyService) Update(ctx context.Context, id uuid.UUID, req UpdateStreamKeyRequest) (*domain.StreamKey, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, domain.ErrInvalidExpiry
+	}
+
+	var key *domain.StreamKey
+	err := s.unitOfWork.WithTx(ctx, func(repos domain.Repositories) error {
+		var err error
+		key, err = repos.StreamKeys.GetAndLockByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		wasValid := key.IsValid()
+
+		if req.Label != nil {
+			key.Label = emptyToNil(*req.Label)
+		}
+
+		if req.Description != nil {
+			key.Description = emptyToNil(*req.Description)
+		}
+
+		if req.ExpiresAt != nil || req.ClearExpiresAt {
+			if !key.IsRevocable() {
+				return domain.ErrInvalidStatus
+			}
+			key.ExpiresAt = req.ExpiresAt
+		}
+
+		if req.DeviceID != nil || req.ClearDeviceID {
+			key.DeviceID = req.DeviceID
+		}
+
+		if req.MinBitrateKbps != nil || req.ClearMinBitrate {
+			key.MinBitrateKbps = req.MinBitrateKbps
+		}
+
+		var durationChanged bool
+		if req.MaxStreamDurationSeconds != nil || req.ClearMaxStreamDuration {
+			old := key.MaxStreamDurationSeconds
+			durationChanged = (old == nil) != (req.MaxStreamDurationSeconds == nil) ||
+				(old != nil && *old != *req.MaxStreamDurationSeconds)
+			key.MaxStreamDurationSeconds = req.MaxStreamDurationSeconds
+		}
+
+		if !wasValid && key.IsValid() {
+			broadcaster, err := repos.Broadcasters.GetAndLockByID(ctx, key.BroadcasterID)
+			if err != nil {
+				return err
+			}
+
+			if err := checkActiveKeyQuota(ctx, repos.StreamKeys, broadcaster, s.quotas); err != nil {
+				return err
+			}
+		}
+
+		if err := repos.StreamKeys.Update(ctx, key); err != nil {
+			return err
+		}
+
+		// A live stream is warned again about its new maximum duration
+		if durationChanged {
+			return repos.Streams.ClearDurationWarning(ctx, key.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Check for active stream
-	activeStream, err := s.streamRepo.GetActiveByStreamKeyID(ctx, id)
-	if err != nil && err != domain.ErrNotFound {
-		return fmt.Errorf("failed to check active stream: %w", err)
+	s.logger.Info("stream key updated",
+		slog.String("key_id", id.String()),
+	)
+
+	key.KeyValue = ""
+	return key, nil
+}
+
+// Suspend temporarily disables an active stream key and terminates any active
+// stream. Suspended keys are rejected until resumed.
+func (s *StreamKeyService) Suspend(ctx context.Context, id uuid.UUID) (*domain.StreamKey, error) {
+	var ended *domain.Stream
+	err := s.unitOfWork.WithTx(ctx, func(repos domain.Repositories) error {
+		key, err := repos.StreamKeys.GetAndLockByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if key.Status != domain.StreamKeyStatusActive {
+			return domain.ErrInvalidStatus
+		}
+
+		if err := repos.StreamKeys.SetSuspended(ctx, id, true); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return domain.ErrInvalidStatus
+			}
+			return err
+		}
+
+		ended, err = s.endActiveStream(ctx, repos.Streams, id, domain.StreamEndKeySuspended)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Terminate active stream if exists
-	if activeStream != nil {
-		s.logger.Info("terminating active stream due to key revocation",
-			slog.String("stream_id", activeStream.ID.String()),
-			slog.String("key_id", id.String()),
-		)
+	s.kickEnded(ctx, ended, domain.StreamEndKeySuspended)
 
-		// Kick from MediaMTX
-		if err := s.mediaMTXClient.KickPath(ctx, activeStream.Path); err != nil {
-			s.logger.Warn("failed to kick path from MediaMTX",
-				slog.String("error", err.Error()),
-				slog.String("path", activeStream.Path),
-			)
+	s.logger.Info("stream key suspended",
+		slog.String("key_id", id.String()),
+	)
+
+	return s.getWithoutValue(ctx, id)
+}
+
+// Resume re-enables a suspended stream key, subject to the broadcaster's
+// active key quota.
+func (s *StreamKeyService) Resume(ctx context.Context, id uuid.UUID) (*domain.StreamKey, error) {
+	err := s.unitOfWork.WithTx(ctx, func(repos domain.Repositories) error {
+		key, err := repos.StreamKeys.GetAndLockByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if key.Status != domain.StreamKeyStatusSuspended {
+			return domain.ErrInvalidStatus
+		}
+
+		broadcaster, err := repos.Broadcasters.GetAndLockByID(ctx, key.BroadcasterID)
+		if err != nil {
+			return err
 		}
 
-		// End stream in database
-		if err := s.streamRepo.EndStream(ctx, activeStream.ID); err != nil {
-			s.logger.Warn("failed to end stream",
-				slog.String("error", err.Error()),
-				slog.String("stream_id", activeStream.ID.String()),
-			)
+		if err := checkActiveKeyQuota(ctx, repos.StreamKeys, broadcaster, s.quotas); err != nil {
+			return err
 		}
+
+		if err := repos.StreamKeys.SetSuspended(ctx, id, false); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return domain.ErrInvalidStatus
+			}
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("stream key resumed",
+		slog.String("key_id", id.String()),
+	)
+
+	return s.getWithoutValue(ctx, id)
+}
+
+// optionalString returns nil for a nil or blank string.
+func optionalString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	return emptyToNil(*s)
+}
+
+// getWithoutValue retrieves a stream key with its key value cleared.
+func (s *StreamKeyService) getWithoutValue(ctx context.Context, id uuid.UUID) (*domain.StreamKey, error) {
+	key, err := s.streamKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
+	key.KeyValue = ""
+	return key, nil
+}
+
+// Revoke revokes a stream key and terminates any active stream. The key
+// stays locked until both are done, so it cannot be authenticated in between;
+// the publisher is kicked once the key is revoked.
+func (s *StreamKeyService) Revoke(ctx context.Context, id uuid.UUID) error {
+	var ended *domain.Stream
+	err := s.unitOfWork.WithTx(ctx, func(repos domain.Repositories) error {
+		key, err := repos.StreamKeys.GetAndLockByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if !key.IsRevocable() {
+			return domain.ErrInvalidStatus
+		}
+
+		ended, err = s.endActiveStream(ctx, repos.Streams, id, domain.StreamEndKeyRevoked)
+		if err != nil {
+			return err
+		}
 
-	// Revoke the key
-	now := time.Now()
-	if err := s.streamKeyRepo.UpdateStatus(ctx, id, domain.StreamKeyStatusRevoked, &now); err != nil {
+		// Revoke the key
+		now := time.Now()
+		return repos.StreamKeys.UpdateStatus(ctx, id, domain.StreamKeyStatusRevoked, &now)
+	})
+	if err != nil {
 		return err
 	}
 
+	s.kickEnded(ctx, ended, domain.StreamEndKeyRevoked)
+
 	s.logger.Info("stream key revoked",
 		slog.String("key_id", id.String()),
 	)
@@ -175,6 +454,79 @@ func (s *StreamKeyService) Revoke(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// Expire marks an active stream key as expired and terminates any active stream.
+func (s *StreamKeyService) Expire(ctx context.Context, id uuid.UUID) error {
+	var ended *domain.Stream
+	err := s.unitOfWork.WithTx(ctx, func(repos domain.Repositories) error {
+		if err := repos.StreamKeys.MarkExpired(ctx, id); err != nil {
+			return err
+		}
+
+		var err error
+		ended, err = s.endActiveStream(ctx, repos.Streams, id, domain.StreamEndKeyExpired)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.kickEnded(ctx, ended, domain.StreamEndKeyExpired)
+
+	s.logger.Info("stream key expired",
+		slog.String("key_id", id.String()),
+	)
+
+	return nil
+}
+
+// endActiveStream drops the pending reservation of a key, so a publish
+// approved just before cannot go live, and ends its active stream, if any,
+// with the given reason and returns it. Its publisher is kicked by kickEnded
+// once the unit of work has been committed, so MediaMTX is not called while
+// the key is locked.
+func (s *StreamKeyService) endActiveStream(ctx context.Context, streamRepo domain.StreamRepository, keyID uuid.UUID, reason domain.StreamEndReason) (*domain.Stream, error) {
+	if err := streamRepo.DeleteReservation(ctx, keyID); err != nil {
+		return nil, err
+	}
+
+	activeStream, err := streamRepo.GetActiveByStreamKeyID(ctx, keyID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to check active stream: %w", err)
+	}
+
+	s.logger.Info("terminating active stream",
+		slog.String("stream_id", activeStream.ID.String()),
+		slog.String("key_id", keyID.String()),
+		slog.String("reason", string(reason)),
+	)
+
+	if err := streamRepo.EndStream(ctx, activeStream.ID, reason); err != nil {
+		return nil, fmt.Errorf("failed to end stream: %w", err)
+	}
+
+	return activeStream, nil
+}
+
+// kickEnded kicks the publisher of a stream ended by endActiveStream, if any.
+func (s *StreamKeyService) kickEnded(ctx context.Context, stream *domain.Stream, reason domain.StreamEndReason) {
+	if stream == nil {
+		return
+	}
+
+	kickStream(ctx, s.streamRepo, s.mediaMTXClient, s.logger, stream, reason)
+}
+
+// streamKeyFormat matches the stream keys generateStreamKey generates.
+var streamKeyFormat = regexp.MustCompile(`^sk_[A-Za-z0-9_-]{43}$`)
+
+// looksLikeStreamKey reports whether s has the format of a generated stream key.
+func looksLikeStreamKey(s string) bool {
+	return streamKeyFormat.MatchString(s)
+}
+
 // generateStreamKey generates a cryptographically secure stream key.
 // Format: sk_ + 43 characters of base64url (32 bytes = 256 bits of entropy)
 func generateStreamKey() (string, error) {
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+// MaxStreamKeyBatchSize is the largest number of keys a batch may issue.
+const MaxStreamKeyBatchSize = 200
+
+// StreamKeyBatchService issues stream keys in bulk.
+type StreamKeyBatchService struct {
+	batchRepo        domain.StreamKeyBatchRepository
+	unitOfWork       domain.UnitOfWork
+	streamKeyService *StreamKeyService
+	logger           *slog.Logger
+}
+
+// StreamKeyBatchServiceOption is a functional option for configuring StreamKeyBatchService.
+type StreamKeyBatchServiceOption func(*StreamKeyBatchService)
+
+// WithStreamKeyBatchLogger sets the logger for StreamKeyBatchService.
+func WithStreamKeyBatchLogger(logger *slog.Logger) StreamKeyBatchServiceOption {
+	return func(s *StreamKeyBatchService) {
+		s.logger = logger
+	}
+}
+
+// NewStreamKeyBatchService creates a new StreamKeyBatchService. Batches are
+// created in unitOfWork.
+func NewStreamKeyBatchService(
+	batchRepo domain.StreamKeyBatchRepository,
+	unitOfWork domain.UnitOfWork,
+	streamKeyService *StreamKeyService,
+	opts ...StreamKeyBatchServiceOption,
+) *StreamKeyBatchService {
+	s := &StreamKeyBatchService{
+		batchRepo:        batchRepo,
+		unitOfWork:       unitOfWork,
+		streamKeyService: streamKeyService,
+		logger:           slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// CreateBatchRequest represents a request to issue a batch of stream keys.
+// One key is issued for each existing broadcaster and each new broadcaster.
+type CreateBatchRequest struct {
+	BroadcasterIDs  []uuid.UUID
+	NewBroadcasters []CreateBroadcasterRequest
+	// Label is set on the batch and every key issued.
+	Label     *string
+	ExpiresAt *time.Time
+}
+
+// StreamKeyBatchResult is a created batch with its keys.
+type StreamKeyBatchResult struct {
+	domain.StreamKeyBatch
+	Keys []domain.StreamKeyBatchEntry `json:"keys"`
+}
+
+// Create issues a stream key for every requested broadcaster, creating new
+// broadcasters as needed, in a single transaction. The existing broadcasters
+// are locked while their active key quotas are checked. Key values are only
+// available through the one-time export.
+func (s *StreamKeyBatchService) Create(ctx context.Context, req CreateBatchRequest) (*StreamKeyBatchResult, error) {
+	now := time.Now()
+	label := optionalString(req.Label)
+
+	batch := &domain.StreamKeyBatch{
+		ID:        uuid.New(),
+		Label:     label,
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: now,
+	}
+
+	var keys []domain.StreamKey
+	newKey := func(broadcasterID uuid.UUID) error {
+		keyValue, err := generateStreamKey()
+		if err != nil {
+			return fmt.Errorf("failed to generate stream key: %w", err)
+		}
+		keys = append(keys, domain.StreamKey{
+			ID:            uuid.New(),
+			KeyValue:      keyValue,
+			BroadcasterID: broadcasterID,
+			Label:         label,
+			Status:        domain.StreamKeyStatusActive,
+			CreatedAt:     now,
+			ExpiresAt:     req.ExpiresAt,
+		})
+		return nil
+	}
+
+	broadcasters := make([]domain.Broadcaster, 0, len(req.NewBroadcasters))
+	for _, nb := range req.NewBroadcasters {
+		broadcaster := domain.Broadcaster{
+			ID:          uuid.New(),
+			DisplayName: nb.DisplayName,
+			Metadata:    nb.Metadata,
+			Status:      domain.BroadcasterStatusOffline,
+			Quotas:      nb.Quotas,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if broadcaster.Metadata == nil {
+			broadcaster.Metadata = make(map[string]interface{})
+		}
+		broadcasters = append(broadcasters, broadcaster)
+
+		if err := newKey(broadcaster.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Broadcasters are locked in a consistent order so that concurrent
+	// batches cannot deadlock. Repeated IDs are issued a single key, as each
+	// quota check only sees the keys already committed.
+	existing := slices.Clone(req.BroadcasterIDs)
+	slices.SortFunc(existing, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+	existing = slices.Compact(existing)
+
+	err := s.unitOfWork.WithTx(ctx, func(repos domain.Repositories) error {
+		for _, id := range existing {
+			broadcaster, err := repos.Broadcasters.GetAndLockByID(ctx, id)
+			if err != nil {
+				return fmt.Errorf("broadcaster %s: %w", id, err)
+			}
+
+			if broadcaster.IsArchived() {
+				return fmt.Errorf("broadcaster %s: %w", id, domain.ErrBroadcasterArchived)
+			}
+
+			if err := checkActiveKeyQuota(ctx, repos.StreamKeys, broadcaster, s.streamKeyService.Quotas()); err != nil {
+				return fmt.Errorf("broadcaster %s: %w", id, err)
+			}
+
+			if err := newKey(id); err != nil {
+				return err
+			}
+		}
+
+		return repos.StreamKeyBatches.Create(ctx, batch, broadcasters, keys)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("stream key batch created",
+		slog.String("batch_id", batch.ID.String()),
+		slog.Int("keys", len(keys)),
+		slog.Int("new_broadcasters", len(broadcasters)),
+	)
+
+	return s.Get(ctx, batch.ID)
+}
+
+// Get retrieves a batch with its keys, without key values.
+func (s *StreamKeyBatchService) Get(ctx context.Context, id uuid.UUID) (*StreamKeyBatchResult, error) {
+	batch, err := s.batchRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.batchRepo.ListEntries(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if entries == nil {
+		entries = []domain.StreamKeyBatchEntry{}
+	}
+
+	return &StreamKeyBatchResult{StreamKeyBatch: *batch, Keys: entries}, nil
+}
+
+// Export returns the key values and ingest URLs of a batch. It can only be
+// retrieved once, after any group job issuing its keys has completed; other
+// calls return ErrExportUnavailable.
+func (s *StreamKeyBatchService) Export(ctx context.Context, id uuid.UUID) ([]domain.StreamKeyExportEntry, error) {
+	entries, err := s.batchRepo.ClaimExport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	export := make([]domain.StreamKeyExportEntry, 0, len(entries))
+	for _, entry := range entries {
+		export = append(export, domain.StreamKeyExportEntry{
+			BroadcasterID: entry.BroadcasterID,
+			DisplayName:   entry.DisplayName,
+			StreamKeyID:   entry.ID,
+			KeyValue:      entry.KeyValue,
+			Label:         entry.Label,
+			ExpiresAt:     entry.ExpiresAt,
+			IngestURLs:    s.streamKeyService.IngestURLs(entry.KeyValue),
+		})
+	}
+
+	s.logger.Info("stream key batch exported",
+		slog.String("batch_id", id.String()),
+		slog.Int("keys", len(export)),
+	)
+
+	return export, nil
+}
@@ -0,0 +1,482 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
+)
+
+const (
+	// DefaultWebhookInboxPollInterval is how often the inbox looks for due
+	// events by default.
+	DefaultWebhookInboxPollInterval = time.Second
+	// DefaultWebhookInboxMaxAttempts is how many times an event is attempted
+	// by default before it is marked failed.
+	DefaultWebhookInboxMaxAttempts = 5
+	// DefaultWebhookInboxRetryBackoff is the delay before the first retry by
+	// default; it doubles with every further attempt.
+	DefaultWebhookInboxRetryBackoff = 2 * time.Second
+
+	// DefaultWebhookEventPageSize is the number of events listed when no
+	// limit is requested.
+	DefaultWebhookEventPageSize = 100
+	// MaxWebhookEventPageSize is the largest number of events that may be
+	// listed at once.
+	MaxWebhookEventPageSize = 1000
+
+	// webhookEventLease is how long a claimed event is held before another
+	// processor may claim it, in case its processor died.
+	webhookEventLease = time.Minute
+)
+
+// WebhookProcessor applies MediaMTX lifecycle webhooks to streams.
+type WebhookProcessor struct {
+	streamRepo     domain.StreamRepository
+	streamKeyRepo  domain.StreamKeyRepository
+	mediaMTXClient *MediaMTXClient
+	reconnectGrace time.Duration
+	logger         *slog.Logger
+}
+
+// WebhookProcessorOption is a functional option for configuring WebhookProcessor.
+type WebhookProcessorOption func(*WebhookProcessor)
+
+// WithWebhookProcessorLogger sets the logger for WebhookProcessor.
+func WithWebhookProcessorLogger(logger *slog.Logger) WebhookProcessorOption {
+	return func(p *WebhookProcessor) {
+		p.logger = logger
+	}
+}
+
+// WithWebhookReconnectGrace sets how long a stream whose publisher
+// disconnected is kept interrupted, so the same key republishing resumes it.
+// Zero, the default, ends streams as soon as their publisher disconnects.
+func WithWebhookReconnectGrace(grace time.Duration) WebhookProcessorOption {
+	return func(p *WebhookProcessor) {
+		p.reconnectGrace = grace
+	}
+}
+
+// WithWebhookMediaMTXClient lets the processor kick publishers whose ready
+// webhook arrives after their key stopped being active.
+func WithWebhookMediaMTXClient(client *MediaMTXClient) WebhookProcessorOption {
+	return func(p *WebhookProcessor) {
+		p.mediaMTXClient = client
+	}
+}
+
+// NewWebhookProcessor creates a new WebhookProcessor.
+func NewWebhookProcessor(
+	streamRepo domain.StreamRepository,
+	streamKeyRepo domain.StreamKeyRepository,
+	opts ...WebhookProcessorOption,
+) *WebhookProcessor {
+	p := &WebhookProcessor{
+		streamRepo:    streamRepo,
+		streamKeyRepo: streamKeyRepo,
+		logger:        slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Process applies a webhook to streams as of when it was received. It only
+// returns an error if processing should be retried; webhooks that cannot apply,
+// such as a not-ready for a path without an active stream, are logged.
+func (p *WebhookProcessor) Process(ctx context.Context, event *domain.WebhookEvent) error {
+	switch event.Type {
+	case domain.WebhookEventReady:
+		return p.processReady(ctx, event)
+	case domain.WebhookEventNotReady:
+		return p.processNotReady(ctx, event)
+	default:
+		p.logger.Warn("ignoring unknown webhook event",
+			slog.String("type", string(event.Type)),
+			slog.String("path", event.Path),
+		)
+		return nil
+	}
+}
+
+func (p *WebhookProcessor) processReady(ctx context.Context, event *domain.WebhookEvent) error {
+	// Look up the stream key that authenticated the publish to this path,
+	// falling back to the path being the key value itself
+	streamKey, err := p.streamKeyRepo.GetByPublishPath(ctx, event.Path)
+	if errors.Is(err, domain.ErrNotFound) {
+		streamKey, err = p.streamKeyRepo.GetByKeyValue(ctx, event.Path)
+	}
+	if errors.Is(err, domain.ErrNotFound) {
+		p.logger.Warn("stream key not found for path",
+			slog.String("path", event.Path),
+		)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up stream key: %w", err)
+	}
+
+	// The key may have been revoked, suspended or expired since the publish
+	// was approved, or while the event was being retried
+	if !streamKey.IsValid() {
+		p.rejectReady(ctx, event, streamKey.ID)
+		return nil
+	}
+
+	// A retried event may have started its stream before failing
+	if event.SourceID != nil {
+		active, err := p.streamRepo.GetActiveByPath(ctx, event.Path)
+		if err == nil && active.SourceID != nil && *active.SourceID == *event.SourceID {
+			return nil
+		}
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("failed to look up active stream: %w", err)
+		}
+	}
+
+	stream := &domain.Stream{
+		ID:          uuid.New(),
+		StreamKeyID: streamKey.ID,
+		Path:        event.Path,
+		Status:      domain.StreamStatusActive,
+		StartedAt:   event.ReceivedAt,
+		SourceType:  event.SourceType,
+		SourceID:    event.SourceID,
+		Metadata:    event.Metadata,
+	}
+
+	if stream.Metadata == nil {
+		stream.Metadata = make(map[string]interface{})
+	}
+	if title, ok := stream.Metadata[PublishQueryTitle].(string); ok && title != "" {
+		stream.Title = &title
+	}
+
+	// A reconnect within the grace period resumes the interrupted stream,
+	// keeping its ID, labels and original publish metadata
+	if p.reconnectGrace > 0 {
+		err := p.streamRepo.ResumeInterrupted(ctx, stream, event.ReceivedAt.Add(-p.reconnectGrace))
+		if err == nil {
+			p.logger.Info("stream resumed",
+				slog.String("stream_id", stream.ID.String()),
+				slog.String("stream_key_id", streamKey.ID.String()),
+				slog.String("path", event.Path),
+			)
+			return nil
+		}
+		if !errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("failed to resume interrupted stream: %w", err)
+		}
+	}
+
+	if err := p.streamRepo.Create(ctx, stream); err != nil {
+		if errors.Is(err, domain.ErrInvalidStreamKey) {
+			p.rejectReady(ctx, event, streamKey.ID)
+			return nil
+		}
+		// Another connection holds the key; retrying would not change that
+		if errors.Is(err, domain.ErrStreamKeyInUse) {
+			p.logger.Warn("stream key already in use by another connection",
+				slog.String("stream_key_id", streamKey.ID.String()),
+				slog.String("path", event.Path),
+			)
+			return nil
+		}
+		return fmt.Errorf("failed to create stream record: %w", err)
+	}
+
+	p.logger.Info("stream started",
+		slog.String("stream_id", stream.ID.String()),
+		slog.String("stream_key_id", streamKey.ID.String()),
+		slog.String("path", event.Path),
+	)
+
+	return nil
+}
+
+// rejectReady kicks the publisher of a ready webhook whose key is no longer
+// active, instead of starting its stream.
+func (p *WebhookProcessor) rejectReady(ctx context.Context, event *domain.WebhookEvent, keyID uuid.UUID) {
+	p.logger.Warn("stream key no longer active, rejecting publisher",
+		slog.String("stream_key_id", keyID.String()),
+		slog.String("path", event.Path),
+	)
+
+	if p.mediaMTXClient == nil {
+		return
+	}
+
+	if err := p.mediaMTXClient.KickPath(ctx, event.Path); err != nil {
+		p.logger.Warn("failed to kick path from MediaMTX",
+			slog.String("error", err.Error()),
+			slog.String("path", event.Path),
+		)
+	}
+}
+
+func (p *WebhookProcessor) processNotReady(ctx context.Context, event *domain.WebhookEvent) error {
+	// Within the grace period the stream is interrupted rather than ended;
+	// the reconnect sweeper ends it unless its key republishes
+	var err error
+	if p.reconnectGrace > 0 {
+		err = p.streamRepo.InterruptStreamByPath(ctx, event.Path, event.ReceivedAt)
+	} else {
+		err = p.streamRepo.EndStreamByPath(ctx, event.Path, domain.StreamEndPublisherDisconnected, event.ReceivedAt)
+	}
+
+	if errors.Is(err, domain.ErrNotFound) {
+		p.logger.Warn("no active stream found for path",
+			slog.String("path", event.Path),
+		)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to end or interrupt stream: %w", err)
+	}
+
+	if p.reconnectGrace > 0 {
+		p.logger.Info("stream interrupted",
+			slog.String("path", event.Path),
+			slog.Duration("reconnect_grace", p.reconnectGrace),
+		)
+	} else {
+		p.logger.Info("stream ended",
+			slog.String("path", event.Path),
+		)
+	}
+
+	return nil
+}
+
+// WebhookInbox persists MediaMTX webhooks as they are received and applies
+// them in the background, retrying failures with exponential backoff. Events
+// that run out of attempts are kept as failed until they are replayed.
+type WebhookInbox struct {
+	inboxRepo    domain.WebhookInboxRepository
+	processor    *WebhookProcessor
+	interval     time.Duration
+	maxAttempts  int
+	retryBackoff time.Duration
+	retention    time.Duration
+	logger       *slog.Logger
+
+	// wake signals Run that an event was enqueued, so it is applied without
+	// waiting for the next poll.
+	wake chan struct{}
+}
+
+// WebhookInboxOption is a functional option for configuring WebhookInbox.
+type WebhookInboxOption func(*WebhookInbox)
+
+// WithWebhookInboxLogger sets the logger for WebhookInbox.
+func WithWebhookInboxLogger(logger *slog.Logger) WebhookInboxOption {
+	return func(i *WebhookInbox) {
+		i.logger = logger
+	}
+}
+
+// WithWebhookInboxPollInterval sets how often the inbox looks for due events.
+func WithWebhookInboxPollInterval(interval time.Duration) WebhookInboxOption {
+	return func(i *WebhookInbox) {
+		i.interval = interval
+	}
+}
+
+// WithWebhookInboxMaxAttempts sets how many times an event is attempted
+// before it is marked failed.
+func WithWebhookInboxMaxAttempts(attempts int) WebhookInboxOption {
+	return func(i *WebhookInbox) {
+		i.maxAttempts = attempts
+	}
+}
+
+// WithWebhookInboxRetryBackoff sets the delay before the first retry of an
+// event; it doubles with every further attempt.
+func WithWebhookInboxRetryBackoff(backoff time.Duration) WebhookInboxOption {
+	return func(i *WebhookInbox) {
+		i.retryBackoff = backoff
+	}
+}
+
+// WithWebhookInboxRetention sets how long processed events are kept. Zero,
+// the default, keeps them forever.
+func WithWebhookInboxRetention(retention time.Duration) WebhookInboxOption {
+	return func(i *WebhookInbox) {
+		i.retention = retention
+	}
+}
+
+// NewWebhookInbox creates a new WebhookInbox applying events with processor.
+func NewWebhookInbox(inboxRepo domain.WebhookInboxRepository, processor *WebhookProcessor, opts ...WebhookInboxOption) *WebhookInbox {
+	i := &WebhookInbox{
+		inboxRepo:    inboxRepo,
+		processor:    processor,
+		interval:     DefaultWebhookInboxPollInterval,
+		maxAttempts:  DefaultWebhookInboxMaxAttempts,
+		retryBackoff: DefaultWebhookInboxRetryBackoff,
+		logger:       slog.Default(),
+		wake:         make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	if i.interval <= 0 {
+		i.interval = DefaultWebhookInboxPollInterval
+	}
+	if i.maxAttempts <= 0 {
+		i.maxAttempts = DefaultWebhookInboxMaxAttempts
+	}
+	if i.retryBackoff <= 0 {
+		i.retryBackoff = DefaultWebhookInboxRetryBackoff
+	}
+
+	return i
+}
+
+// Enqueue persists a received webhook for processing. A redelivered webhook
+// is dropped.
+func (i *WebhookInbox) Enqueue(ctx context.Context, event *domain.WebhookEvent) error {
+	if err := i.inboxRepo.Enqueue(ctx, event); err != nil {
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			i.logger.Info("dropping duplicate webhook",
+				slog.String("type", string(event.Type)),
+				slog.String("path", event.Path),
+			)
+			return nil
+		}
+		return err
+	}
+
+	select {
+	case i.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// GetByID retrieves an inbox event by ID.
+func (i *WebhookInbox) GetByID(ctx context.Context, id int64) (*domain.WebhookEvent, error) {
+	return i.inboxRepo.GetByID(ctx, id)
+}
+
+// List retrieves the inbox events matching the filter, most recent first.
+func (i *WebhookInbox) List(ctx context.Context, filter domain.WebhookEventFilter) ([]domain.WebhookEvent, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultWebhookEventPageSize
+	}
+	if filter.Limit > MaxWebhookEventPageSize {
+		filter.Limit = MaxWebhookEventPageSize
+	}
+
+	return i.inboxRepo.List(ctx, filter)
+}
+
+// Replay queues a failed event to be processed again, ahead of any events
+// still pending on its path. Events superseded by a later processed event on
+// their path cannot be replayed, as applying them would undo it.
+func (i *WebhookInbox) Replay(ctx context.Context, id int64) (*domain.WebhookEvent, error) {
+	event, err := i.inboxRepo.Replay(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	i.logger.Info("webhook event replayed",
+		slog.Int64("event_id", event.ID),
+		slog.String("path", event.Path),
+	)
+
+	return event, nil
+}
+
+// Run processes due events immediately, then on every interval and whenever
+// an event is enqueued, until ctx is cancelled.
+func (i *WebhookInbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(i.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := i.ProcessPending(ctx); err != nil && ctx.Err() == nil {
+			i.logger.Error("webhook inbox processing failed",
+				slog.String("error", err.Error()),
+			)
+		}
+
+		if i.retention > 0 {
+			if _, err := i.inboxRepo.DeleteProcessedBefore(ctx, time.Now().Add(-i.retention)); err != nil && ctx.Err() == nil {
+				i.logger.Error("failed to prune processed webhook events",
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-i.wake:
+		}
+	}
+}
+
+// ProcessPending processes due events one at a time until none are left.
+func (i *WebhookInbox) ProcessPending(ctx context.Context) error {
+	for ctx.Err() == nil {
+		event, err := i.inboxRepo.ClaimNext(ctx, webhookEventLease)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if err := i.process(ctx, event); err != nil {
+			return fmt.Errorf("webhook event %d: %w", event.ID, err)
+		}
+	}
+
+	return ctx.Err()
+}
+
+// process applies a claimed event, scheduling a retry or marking it failed if
+// that fails.
+func (i *WebhookInbox) process(ctx context.Context, event *domain.WebhookEvent) error {
+	procErr := i.processor.Process(ctx, event)
+	if procErr == nil {
+		return i.inboxRepo.MarkProcessed(ctx, event.ID)
+	}
+
+	if event.Attempts >= i.maxAttempts {
+		i.logger.Error("webhook event failed",
+			slog.Int64("event_id", event.ID),
+			slog.String("type", string(event.Type)),
+			slog.String("path", event.Path),
+			slog.Int("attempts", event.Attempts),
+			slog.String("error", procErr.Error()),
+		)
+		return i.inboxRepo.MarkFailed(ctx, event.ID, procErr.Error())
+	}
+
+	backoff := i.retryBackoff << (event.Attempts - 1)
+	i.logger.Warn("webhook event will be retried",
+		slog.Int64("event_id", event.ID),
+		slog.String("type", string(event.Type)),
+		slog.String("path", event.Path),
+		slog.Int("attempts", event.Attempts),
+		slog.Duration("backoff", backoff),
+		slog.String("error", procErr.Error()),
+	)
+
+	return i.inboxRepo.Retry(ctx, event.ID, procErr.Error(), time.Now().Add(backoff))
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/testcontainers/testcontainers-go/wait"
 
 	"github.com/searchandrescuegg/rescuestream-api/internal/database"
+	"github.com/searchandrescuegg/rescuestream-api/internal/domain"
 )
 
 // TestDatabase wraps a PostgreSQL test container and connection pool.
@@ -81,11 +82,16 @@ func (td *TestDatabase) TruncateTables(t *testing.T) {
 	t.Helper()
 	ctx := context.Background()
 
-	tables := []string{"streams", "stream_keys", "broadcasters"}
+	tables := []string{"group_job_results", "group_jobs", "broadcaster_group_members", "broadcaster_groups", "stream_shares", "api_clients", "enrollment_redemptions", "enrollment_tokens", "stream_events", "stream_segments", "streams", "stream_key_expiry_notices", "stream_keys", "devices", "stream_key_batches", "broadcasters", "auth_lockouts", "webhook_inbox"}
 	for _, table := range tables {
 		_, err := td.Pool.Exec(ctx, fmt.Sprintf("TRUNCATE TABLE %s CASCADE", table))
 		if err != nil {
 			t.Fatalf("failed to truncate table %s: %v", table, err)
 		}
 	}
+
+	// Keep the default organization created by the migrations
+	if _, err := td.Pool.Exec(ctx, "DELETE FROM organizations WHERE id <> $1", domain.DefaultOrganizationID); err != nil {
+		t.Fatalf("failed to delete organizations: %v", err)
+	}
 }